@@ -2,13 +2,18 @@ package checker
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"df2redis/internal/redisx"
 )
 
@@ -24,14 +29,31 @@ const (
 	ModeValueLength CheckMode = "length"
 	// ModeSmartBigKey performs smart comparison (length-only for big keys)
 	ModeSmartBigKey CheckMode = "smart"
+	// ModeKeyExists only checks that source keys exist on the target, with
+	// pipelined EXISTS per target master; no source reads, flat memory
+	ModeKeyExists CheckMode = "exists"
 )
 
 // Config holds validation configuration
 type Config struct {
-	SourceAddr      string
-	SourcePassword  string
-	TargetAddr      string
-	TargetPassword  string
+	SourceAddr     string
+	SourcePassword string
+	TargetAddr     string
+	TargetPassword string
+
+	// TargetCluster routes ModeKeyExists lookups by slot across the target
+	// cluster (TargetSeeds, or TargetAddr when empty)
+	TargetCluster bool
+	TargetSeeds   []string
+
+	// TLS settings for each side (see redisx.Config)
+	SourceTLS         bool
+	SourceTLSCAFile   string
+	SourceTLSInsecure bool
+	TargetTLS         bool
+	TargetTLSCAFile   string
+	TargetTLSInsecure bool
+
 	Mode            CheckMode
 	QPS             int
 	Parallel        int
@@ -46,6 +68,19 @@ type Config struct {
 	LogLevel        string
 	MaxKeys         int
 	TaskName        string
+	ScanCount       int    // SCAN COUNT hint (defaults to BatchSize)
+	ScanType        string // optional SCAN TYPE filter (string/list/set/zset/hash/stream)
+
+	// ExcludePatterns skips keys matching any glob (SCAN MATCH syntax), e.g.
+	// heartbeat or lock keys that are expected to differ
+	ExcludePatterns []string
+
+	// Resume continues an interrupted check from the SCAN cursor saved in ResultDir
+	Resume bool
+
+	// CheckEncoding also compares OBJECT ENCODING of keys present on both
+	// sides with the same type; mismatches are counted apart from value ones
+	CheckEncoding bool
 }
 
 // Result holds validation results
@@ -54,9 +89,14 @@ type Result struct {
 	ConsistentKeys      int64
 	InconsistentKeys    int64
 	MissingKeys         int64
+	ExcludedKeys        int64 // skipped by ExcludePatterns
 	Duration            time.Duration
 	ResultFile          string
 	InconsistentSamples []string
+
+	// EncodingMismatches counts keys whose OBJECT ENCODING differs (CheckEncoding)
+	EncodingMismatches int64
+	EncodingSamples    []string
 }
 
 // Progress indicates the current progress of the check
@@ -98,6 +138,9 @@ func NewChecker(config Config) *Checker {
 	if config.BigKeyThreshold <= 0 {
 		config.BigKeyThreshold = 5000
 	}
+	if config.ScanCount <= 0 {
+		config.ScanCount = config.BatchSize
+	}
 	return &Checker{config: config}
 }
 
@@ -114,111 +157,284 @@ func (c *Checker) Run(ctx context.Context, progressCh chan<- Progress) (*Result,
 	startTime := time.Now()
 
 	log.Printf("🚀 Starting native check (Mode: %s, Parallel: %d)", c.config.Mode, c.config.Parallel)
+	if c.config.ScanType != "" {
+		log.Printf("   Only validating keys of type %s (SCAN COUNT %d)", c.config.ScanType, c.config.ScanCount)
+	}
+	if len(c.config.ExcludePatterns) > 0 {
+		log.Printf("   Excluding keys matching %s", strings.Join(c.config.ExcludePatterns, ", "))
+	}
+
+	var inconsistenciesMutex sync.Mutex
+	startCursor := "0"
+	if c.config.Resume {
+		saved, err := c.loadProgress()
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil {
+			saved.restore(result)
+			startCursor = saved.Cursor
+			log.Printf("   Resuming from SCAN cursor %s (%d keys already checked)", startCursor, saved.TotalKeys)
+		}
+	}
 
 	// Connect to Source and Target
-	src, err := redisx.Dial(ctx, redisx.Config{Addr: c.config.SourceAddr, Password: c.config.SourcePassword})
+	src, err := dialWithRetry(ctx, c.sourceConfig(), "source")
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to source: %w", err)
 	}
 	defer src.Close()
 
-	tgt, err := redisx.Dial(ctx, redisx.Config{Addr: c.config.TargetAddr, Password: c.config.TargetPassword})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to target: %w", err)
+	// The scanner uses src; value modes give every worker its own source and
+	// target connection, since a redisx.Client serializes its commands
+	var workers []workerConns
+	var tgtCluster *redisx.ClusterClient
+	if c.config.Mode == ModeKeyExists {
+		tgtCluster, err = c.dialTargetCluster(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to target: %w", err)
+		}
+		defer tgtCluster.Close()
+	} else {
+		workers, err = c.dialWorkers(ctx)
+		defer closeWorkers(workers)
+		if err != nil {
+			return nil, err
+		}
 	}
-	defer tgt.Close()
+
+	// A stopped check must not sit in a blocking read until the server answers
+	stopInterrupt := context.AfterFunc(ctx, func() {
+		src.Interrupt()
+		for _, w := range workers {
+			w.src.Interrupt()
+			w.tgt.Interrupt()
+		}
+		if tgtCluster != nil {
+			tgtCluster.Close()
+		}
+	})
+	defer stopInterrupt()
 
 	// Channels for pipeline
 	keyChan := make(chan string, c.config.BatchSize*2)
+	tracker := newScanTracker(startCursor, c.config.Parallel*processBatchSize)
 
 	// Start Scanner
+	var scanErr error
 	var scanWg sync.WaitGroup
 	scanWg.Add(1)
 	go func() {
 		defer scanWg.Done()
 		defer close(keyChan)
-		c.scanSource(ctx, src, keyChan)
+		scanErr = c.scanSource(ctx, src, startCursor, keyChan, result, tracker)
+	}()
+
+	// Periodically persist the resumable cursor
+	saveDone := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(progressSaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.saveProgress(result, &inconsistenciesMutex, tracker.checkpoint(false), false); err != nil {
+					log.Printf("⚠ %v", err)
+				}
+			case <-saveDone:
+				return
+			}
+		}
 	}()
 
-	// Start Workers
+	// Start Workers: Parallel goroutines pull keys from the SCAN channel,
+	// paced together by the QPS limiter
 	var workerWg sync.WaitGroup
-	var inconsistenciesMutex sync.Mutex
+	limiter := newKeyLimiter(c.config.QPS)
 
 	for i := 0; i < c.config.Parallel; i++ {
+		check := func(batch []string) {
+			c.processExistsBatch(ctx, tgtCluster, batch, result, &inconsistenciesMutex, progressCh)
+		}
+		if c.config.Mode != ModeKeyExists {
+			w := workers[i]
+			check = func(batch []string) {
+				c.processBatch(ctx, w.src, w.tgt, batch, result, &inconsistenciesMutex, progressCh)
+			}
+		}
 		workerWg.Add(1)
 		go func() {
 			defer workerWg.Done()
-			// Create dedicated clients for workers if needed or reuse if client is thread-safe (redisx is likely thread-safe if it uses go-redis)
-			// Assuming redisx.Client is a wrapper around go-redis which is thread safe.
-			c.processKeys(ctx, src, tgt, keyChan, result, &inconsistenciesMutex, progressCh)
+			c.processKeys(ctx, keyChan, limiter, check, tracker)
 		}()
 	}
 
 	// Wait for completion
 	workerWg.Wait()
+	scanWg.Wait()
+	close(saveDone)
 	result.Duration = time.Since(startTime)
 
+	if scanErr == nil {
+		scanErr = ctx.Err()
+	}
+	cursor := tracker.checkpoint(true)
+	if err := c.saveProgress(result, &inconsistenciesMutex, cursor, scanErr == nil); err != nil {
+		log.Printf("⚠ %v", err)
+	}
+	if scanErr != nil {
+		return result, fmt.Errorf("check interrupted at SCAN cursor %s (rerun with --resume-check to continue): %w", cursor, scanErr)
+	}
+
 	c.PrintResult(result)
 	return result, nil
 }
 
-func (c *Checker) scanSource(ctx context.Context, client *redisx.Client, out chan<- string) {
-	cursor := "0"
+func (c *Checker) scanSource(ctx context.Context, client *redisx.Client, cursor string, out chan<- string, res *Result, tracker *scanTracker) error {
+	scanned := 0
+	patterns := c.filterPatterns()
 	for {
-		// key filter pattern
-		pattern := "*"
-		// TODO: Support filter list parsing if needed, complicates SCAN.
-		// For now simple catch-all
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 
-		reply, err := client.Do("SCAN", cursor, "COUNT", c.config.BatchSize, "MATCH", pattern)
+		reply, err := client.Do("SCAN", c.scanArgs(cursor, patterns)...)
 		if err != nil {
-			log.Printf("SCAN failed: %v", err)
-			return
+			return fmt.Errorf("SCAN failed: %w", err)
 		}
 
 		arr, ok := reply.([]interface{})
 		if !ok || len(arr) != 2 {
-			log.Printf("SCAN returned unexpected format: %T", reply)
-			return
+			return fmt.Errorf("SCAN returned unexpected format: %T", reply)
 		}
 
 		// Cursor
 		cursor, err = redisx.ToString(arr[0])
 		if err != nil {
-			log.Printf("SCAN cursor parse failed: %v", err)
-			return
+			return fmt.Errorf("SCAN cursor parse failed: %w", err)
 		}
 
 		// Keys
 		keys, err := redisx.ToStringSlice(arr[1])
 		if err != nil {
-			log.Printf("SCAN keys parse failed: %v", err)
-			return
+			return fmt.Errorf("SCAN keys parse failed: %w", err)
 		}
 
+		queued := 0
 		for _, k := range keys {
+			if len(patterns) > 1 && !matchesAny(patterns, k) {
+				continue
+			}
+			if c.excluded(k) {
+				atomic.AddInt64(&res.ExcludedKeys, 1)
+				continue
+			}
+			if c.config.MaxKeys > 0 && scanned >= c.config.MaxKeys {
+				break
+			}
 			out <- k
+			queued++
+			scanned++
 		}
+		tracker.pageDone(cursor, queued)
 
 		if cursor == "0" {
-			break
+			return nil
+		}
+		if c.config.MaxKeys > 0 && scanned >= c.config.MaxKeys {
+			log.Printf("   Reached --max-keys limit (%d), stopping scan", c.config.MaxKeys)
+			return nil
 		}
 	}
 }
 
-func (c *Checker) processKeys(ctx context.Context, src, tgt *redisx.Client, keys <-chan string, res *Result, lock *sync.Mutex, progressCh chan<- Progress) {
-	batchSize := 100
-	batch := make([]string, 0, batchSize)
+// processBatchSize is the number of keys a worker compares per pipeline round
+const processBatchSize = 100
+
+func (c *Checker) processKeys(ctx context.Context, keys <-chan string, limiter *rate.Limiter, check func([]string), tracker *scanTracker) {
+	batch := make([]string, 0, processBatchSize)
+	run := func() {
+		// WaitN only fails once ctx is cancelled; the batch is dropped like any other then
+		if limiter.Limit() != rate.Inf && limiter.WaitN(ctx, len(batch)) != nil {
+			return
+		}
+		check(batch)
+		tracker.addProcessed(len(batch))
+	}
 
 	for key := range keys {
+		// Once cancelled, keep draining so the scanner never blocks on a send
+		if ctx.Err() != nil {
+			continue
+		}
 		batch = append(batch, key)
-		if len(batch) >= batchSize {
-			c.processBatch(ctx, src, tgt, batch, res, lock, progressCh)
+		if len(batch) >= processBatchSize {
+			run()
 			batch = batch[:0]
 		}
 	}
-	if len(batch) > 0 {
-		c.processBatch(ctx, src, tgt, batch, res, lock, progressCh)
+	if len(batch) > 0 && ctx.Err() == nil {
+		run()
+	}
+}
+
+// newKeyLimiter paces workers to qps keys per second (0 = unlimited). The
+// burst fits one worker batch so WaitN never asks for more than it holds.
+func newKeyLimiter(qps int) *rate.Limiter {
+	if qps <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(qps), max(qps, processBatchSize))
+}
+
+// workerConns is the source/target connection pair of one worker.
+type workerConns struct {
+	src, tgt *redisx.Client
+}
+
+// dialWorkers opens Parallel connection pairs. On error the pairs opened so
+// far are returned too, for closeWorkers.
+func (c *Checker) dialWorkers(ctx context.Context) ([]workerConns, error) {
+	workers := make([]workerConns, 0, c.config.Parallel)
+	for i := 0; i < c.config.Parallel; i++ {
+		src, err := dialWithRetry(ctx, c.sourceConfig(), "source")
+		if err != nil {
+			return workers, fmt.Errorf("failed to connect to source: %w", err)
+		}
+		tgt, err := dialWithRetry(ctx, c.targetConfig(), "target")
+		if err != nil {
+			src.Close()
+			return workers, fmt.Errorf("failed to connect to target: %w", err)
+		}
+		workers = append(workers, workerConns{src: src, tgt: tgt})
+	}
+	return workers, nil
+}
+
+func closeWorkers(workers []workerConns) {
+	for _, w := range workers {
+		w.src.Close()
+		w.tgt.Close()
+	}
+}
+
+func (c *Checker) sourceConfig() redisx.Config {
+	return redisx.Config{
+		Addr:        c.config.SourceAddr,
+		Password:    c.config.SourcePassword,
+		TLS:         c.config.SourceTLS,
+		TLSCAFile:   c.config.SourceTLSCAFile,
+		TLSInsecure: c.config.SourceTLSInsecure,
+	}
+}
+
+func (c *Checker) targetConfig() redisx.Config {
+	return redisx.Config{
+		Addr:        c.config.TargetAddr,
+		Password:    c.config.TargetPassword,
+		TLS:         c.config.TargetTLS,
+		TLSCAFile:   c.config.TargetTLSCAFile,
+		TLSInsecure: c.config.TargetTLSInsecure,
 	}
 }
 
@@ -247,6 +463,7 @@ func (c *Checker) processBatch(ctx context.Context, src, tgt *redisx.Client, key
 	// 2. Analyze Types and Group Strings
 	stringKeys := make([]string, 0)
 	otherKeys := make([]struct{ k, t string }, 0)
+	var sameType []string // present on both sides with the same type (CheckEncoding)
 
 	for i, key := range keys {
 		atomic.AddInt64(&res.TotalKeys, 1)
@@ -271,6 +488,9 @@ func (c *Checker) processBatch(ctx context.Context, src, tgt *redisx.Client, key
 			c.recordInconsistency(res, lock, key, srcType, tgtType)
 			continue
 		}
+		if c.config.CheckEncoding {
+			sameType = append(sameType, key)
+		}
 
 		// Types match. Check Value if needed.
 		if c.config.Mode == ModeFullValue || c.config.Mode == ModeValueLength { // Todo: ModeValueLength handling
@@ -285,6 +505,10 @@ func (c *Checker) processBatch(ctx context.Context, src, tgt *redisx.Client, key
 		}
 	}
 
+	if c.config.CheckEncoding {
+		c.compareEncodings(src, tgt, sameType, res, lock)
+	}
+
 	// 3. Batch Verify Strings
 	if len(stringKeys) > 0 {
 		c.batchVerifyStrings(src, tgt, stringKeys, res, lock)
@@ -422,4 +646,71 @@ func must(val interface{}, err error) interface{} {
 
 func (c *Checker) PrintResult(result *Result) {
 	fmt.Printf("\n📊 Check Result: %d keys scanned, %d inconsistent\n", result.TotalKeys, result.InconsistentKeys)
+	if result.ExcludedKeys > 0 {
+		fmt.Printf("   %d keys excluded by pattern\n", result.ExcludedKeys)
+	}
+	if c.config.CheckEncoding {
+		fmt.Printf("   %d keys with a different OBJECT ENCODING\n", result.EncodingMismatches)
+		for _, sample := range result.EncodingSamples {
+			fmt.Printf("     %s\n", sample)
+		}
+	}
+}
+
+// Summary is the machine-readable form of Result written by --output.
+type Summary struct {
+	Mode                CheckMode `json:"mode"`
+	SourceAddr          string    `json:"sourceAddr"`
+	TargetAddr          string    `json:"targetAddr"`
+	FinishedAt          time.Time `json:"finishedAt"`
+	DurationSeconds     float64   `json:"durationSeconds"`
+	TotalKeys           int64     `json:"totalKeys"`
+	ConsistentKeys      int64     `json:"consistentKeys"`
+	InconsistentKeys    int64     `json:"inconsistentKeys"`
+	MissingKeys         int64     `json:"missingKeys"`
+	ExcludedKeys        int64     `json:"excludedKeys"`
+	Consistent          bool      `json:"consistent"`
+	ResultFile          string    `json:"resultFile,omitempty"`
+	InconsistentSamples []string  `json:"inconsistentSamples"`
+	EncodingMismatches  int64     `json:"encodingMismatches,omitempty"`
+	EncodingSamples     []string  `json:"encodingSamples,omitempty"`
+}
+
+// WriteSummary writes the result as JSON to path for CI consumption.
+func (c *Checker) WriteSummary(path string, result *Result) error {
+	samples := result.InconsistentSamples
+	if samples == nil {
+		samples = []string{}
+	}
+	summary := Summary{
+		Mode:                c.config.Mode,
+		SourceAddr:          c.config.SourceAddr,
+		TargetAddr:          c.config.TargetAddr,
+		FinishedAt:          time.Now(),
+		DurationSeconds:     result.Duration.Seconds(),
+		TotalKeys:           result.TotalKeys,
+		ConsistentKeys:      result.ConsistentKeys,
+		InconsistentKeys:    result.InconsistentKeys,
+		MissingKeys:         result.MissingKeys,
+		ExcludedKeys:        result.ExcludedKeys,
+		Consistent:          result.InconsistentKeys == 0 && result.MissingKeys == 0,
+		ResultFile:          result.ResultFile,
+		InconsistentSamples: samples,
+		EncodingMismatches:  result.EncodingMismatches,
+		EncodingSamples:     result.EncodingSamples,
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %w", err)
+	}
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,150 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// stallingServer answers PING and CLIENT (connection naming) and never
+// replies to anything else, like a source stuck on a slow SCAN. closed
+// receives one value per client connection that goes away.
+func stallingServer(t *testing.T) (addr string, closed <-chan struct{}) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan struct{}, 16)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { ch <- struct{}{} }()
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+					switch strings.ToUpper(args[0]) {
+					case "PING":
+						conn.Write([]byte("+PONG\r\n"))
+					case "CLIENT":
+						conn.Write([]byte("+OK\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || n < 1 {
+		return nil, errors.New("bad array header")
+	}
+	args := make([]string, n)
+	for i := range args {
+		if _, err := r.ReadString('\n'); err != nil { // $len
+			return nil, err
+		}
+		v, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args[i] = strings.TrimSuffix(v, "\r\n")
+	}
+	return args, nil
+}
+
+// TestRunStopsOnCancel starts a check against a stalled source and stops it
+// right away: Run must return promptly and close both connections.
+func TestRunStopsOnCancel(t *testing.T) {
+	addr, closed := stallingServer(t)
+	c := NewChecker(Config{
+		SourceAddr: addr,
+		TargetAddr: addr,
+		ResultDir:  t.TempDir(),
+		Parallel:   2,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		wg     sync.WaitGroup
+		runErr error
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, runErr = c.Run(ctx, make(chan Progress, 1))
+	}()
+
+	time.Sleep(200 * time.Millisecond) // let SCAN block
+	cancel()
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second): // well below the client's read timeout
+		t.Fatal("Run did not return after cancel")
+	}
+	if runErr == nil {
+		t.Fatal("Run returned nil error after cancel")
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("connection %d was not closed after Run returned", i+1)
+		}
+	}
+}
+
+func TestProcessKeysPacedByQPS(t *testing.T) {
+	c := NewChecker(Config{ResultDir: t.TempDir()})
+	keys := make(chan string, 300)
+	for i := 0; i < 300; i++ {
+		keys <- strconv.Itoa(i)
+	}
+	close(keys)
+
+	var checked int
+	start := time.Now()
+	// 1000 keys/s with a 1000-key burst: 300 keys pass without waiting
+	c.processKeys(context.Background(), keys, newKeyLimiter(1000), func(b []string) { checked += len(b) }, newScanTracker("0", 0))
+	if checked != 300 {
+		t.Fatalf("checked %d keys, want 300", checked)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("limiter blocked within its burst")
+	}
+
+	if l := newKeyLimiter(0); l.Limit() != rate.Inf {
+		t.Fatalf("qps 0 should be unlimited, got %v", l.Limit())
+	}
+	if l := newKeyLimiter(10); l.Burst() < processBatchSize {
+		t.Fatalf("burst %d smaller than a batch", l.Burst())
+	}
+}
@@ -0,0 +1,167 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"df2redis/internal/redisx"
+)
+
+// CountResult compares the number of keys on each side, as produced by
+// CountKeys.
+type CountResult struct {
+	SourceKeys int64
+	TargetKeys int64
+	Scanned    bool // counted with SCAN because a filter was set, rather than DBSIZE
+	Duration   time.Duration
+}
+
+// Match reports whether both sides hold the same number of keys.
+func (r *CountResult) Match() bool {
+	return r.SourceKeys == r.TargetKeys
+}
+
+// CountKeys is the quick sanity gate before a full check: it compares DBSIZE
+// on the source with DBSIZE summed over every target master. When a key
+// filter (FilterList, ScanType or ExcludePatterns) is set, both sides are
+// counted with SCAN instead so only matching keys are compared; that walks
+// the keyspace but transfers no values.
+func (c *Checker) CountKeys(ctx context.Context) (*CountResult, error) {
+	start := time.Now()
+	src, err := dialWithRetry(ctx, redisx.Config{
+		Addr:        c.config.SourceAddr,
+		Password:    c.config.SourcePassword,
+		TLS:         c.config.SourceTLS,
+		TLSCAFile:   c.config.SourceTLSCAFile,
+		TLSInsecure: c.config.SourceTLSInsecure,
+	}, "source")
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to source: %w", err)
+	}
+	defer src.Close()
+
+	tgt, err := c.dialTargetCluster(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to target: %w", err)
+	}
+	defer tgt.Close()
+
+	stopInterrupt := context.AfterFunc(ctx, func() {
+		src.Interrupt()
+		tgt.Close()
+	})
+	defer stopInterrupt()
+
+	res := &CountResult{Scanned: c.countFiltered()}
+	count := dbSize
+	if res.Scanned {
+		count = func(ctx context.Context, client *redisx.Client) (int64, error) {
+			return c.scanCount(ctx, client)
+		}
+	}
+
+	if res.SourceKeys, err = count(ctx, src); err != nil {
+		return nil, fmt.Errorf("source %s: %w", c.config.SourceAddr, err)
+	}
+	err = tgt.ForEachMaster(func(client *redisx.Client) error {
+		n, err := count(ctx, client)
+		if err != nil {
+			return fmt.Errorf("target %s: %w", client.Addr(), err)
+		}
+		res.TargetKeys += n
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	res.Duration = time.Since(start)
+	return res, nil
+}
+
+// countFiltered reports whether counting has to honour a key filter.
+func (c *Checker) countFiltered() bool {
+	return len(c.filterPatterns()) > 0 || c.config.ScanType != "" || len(c.config.ExcludePatterns) > 0
+}
+
+// filterPatterns splits FilterList ("user:*|session:*") into glob patterns.
+func (c *Checker) filterPatterns() []string {
+	var patterns []string
+	for _, p := range strings.Split(c.config.FilterList, "|") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+func dbSize(_ context.Context, client *redisx.Client) (int64, error) {
+	reply, err := client.Do("DBSIZE")
+	if err != nil {
+		return 0, err
+	}
+	return redisx.ToInt64(reply)
+}
+
+// scanCount counts the keys on one node that pass the configured filters.
+func (c *Checker) scanCount(ctx context.Context, client *redisx.Client) (int64, error) {
+	patterns := c.filterPatterns()
+	var total int64
+	cursor := "0"
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		reply, err := client.Do("SCAN", c.scanArgs(cursor, patterns)...)
+		if err != nil {
+			return 0, fmt.Errorf("SCAN failed: %w", err)
+		}
+		arr, ok := reply.([]interface{})
+		if !ok || len(arr) != 2 {
+			return 0, fmt.Errorf("SCAN returned unexpected format: %T", reply)
+		}
+		if cursor, err = redisx.ToString(arr[0]); err != nil {
+			return 0, fmt.Errorf("SCAN cursor parse failed: %w", err)
+		}
+		keys, err := redisx.ToStringSlice(arr[1])
+		if err != nil {
+			return 0, fmt.Errorf("SCAN keys parse failed: %w", err)
+		}
+		for _, k := range keys {
+			if c.excluded(k) || (len(patterns) > 1 && !matchesAny(patterns, k)) {
+				continue
+			}
+			total++
+		}
+		if cursor == "0" {
+			return total, nil
+		}
+	}
+}
+
+// scanArgs builds the SCAN arguments for the configured filters. A single
+// filter pattern is pushed down as SCAN MATCH so the server skips the rest of
+// the keyspace; several are matched locally (see matchesAny), since one SCAN
+// takes one MATCH and separate passes would report overlapping keys twice.
+func (c *Checker) scanArgs(cursor string, patterns []string) []interface{} {
+	match := "*"
+	if len(patterns) == 1 {
+		match = patterns[0]
+	}
+	args := []interface{}{cursor, "COUNT", c.config.ScanCount, "MATCH", match}
+	if c.config.ScanType != "" {
+		// SCAN ... TYPE requires Redis 6.2+ semantics on the source
+		args = append(args, "TYPE", c.config.ScanType)
+	}
+	return args
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, p := range patterns {
+		if redisx.MatchGlob(p, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"df2redis/internal/redisx"
+)
+
+// keyspaceServer answers PING, DBSIZE, a single-page SCAN (honouring MATCH)
+// and TYPE (every key is a string) over a fixed key list.
+func keyspaceServer(t *testing.T, keys []string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+					switch strings.ToUpper(args[0]) {
+					case "PING":
+						conn.Write([]byte("+PONG\r\n"))
+					case "DBSIZE":
+						fmt.Fprintf(conn, ":%d\r\n", len(keys))
+					case "SCAN":
+						match := "*"
+						for i := 1; i+1 < len(args); i++ {
+							if strings.EqualFold(args[i], "MATCH") {
+								match = args[i+1]
+							}
+						}
+						var page []string
+						for _, k := range keys {
+							if redisx.MatchGlob(match, k) {
+								page = append(page, k)
+							}
+						}
+						fmt.Fprintf(conn, "*2\r\n$1\r\n0\r\n*%d\r\n", len(page))
+						for _, k := range page {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(k), k)
+						}
+					case "TYPE":
+						typ := "none"
+						for _, k := range keys {
+							if k == args[1] {
+								typ = "string"
+							}
+						}
+						fmt.Fprintf(conn, "+%s\r\n", typ)
+					default:
+						conn.Write([]byte("-ERR unknown command\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestCountKeys(t *testing.T) {
+	src := keyspaceServer(t, []string{"user:1", "user:2", "session:1", "lock:1"})
+	tgt := keyspaceServer(t, []string{"user:1", "user:2", "session:1", "other:1"})
+
+	cases := []struct {
+		name        string
+		filter      string
+		exclude     []string
+		scanned     bool
+		source, tgt int64
+	}{
+		{"dbsize", "", nil, false, 4, 4},
+		{"single pattern", "user:*", nil, true, 2, 2},
+		{"several patterns", "user:*|session:*|user:1", nil, true, 3, 3},
+		{"exclude", "", []string{"lock:*", "other:*"}, true, 3, 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewChecker(Config{
+				SourceAddr:      src,
+				TargetAddr:      tgt,
+				FilterList:      tc.filter,
+				ExcludePatterns: tc.exclude,
+			})
+			res, err := c.CountKeys(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Scanned != tc.scanned || res.SourceKeys != tc.source || res.TargetKeys != tc.tgt {
+				t.Fatalf("got scanned=%v source=%d target=%d, want %v %d %d",
+					res.Scanned, res.SourceKeys, res.TargetKeys, tc.scanned, tc.source, tc.tgt)
+			}
+			if !res.Match() {
+				t.Fatal("Match() = false for equal counts")
+			}
+		})
+	}
+}
+
+func TestRunFilterList(t *testing.T) {
+	keys := []string{"user:1", "user:2", "session:1", "lock:1"}
+	src := keyspaceServer(t, keys)
+	tgt := keyspaceServer(t, keys)
+
+	for _, tc := range []struct {
+		filter string
+		want   int64
+	}{
+		{"", 4},
+		{"user:*", 2},
+		{"user:*|session:*|user:1", 3},
+	} {
+		c := NewChecker(Config{
+			SourceAddr: src,
+			TargetAddr: tgt,
+			ResultDir:  t.TempDir(),
+			FilterList: tc.filter,
+		})
+		res, err := c.Run(context.Background(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.TotalKeys != tc.want || res.ConsistentKeys != tc.want {
+			t.Errorf("filter %q: checked %d keys (%d consistent), want %d", tc.filter, res.TotalKeys, res.ConsistentKeys, tc.want)
+		}
+	}
+}
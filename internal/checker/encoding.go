@@ -0,0 +1,62 @@
+package checker
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"df2redis/internal/redisx"
+)
+
+// compareEncodings compares OBJECT ENCODING on both sides for keys whose type
+// already matched (Config.CheckEncoding). A different encoding isn't a value
+// mismatch, but it shows config drift such as set-max-intset-entries or
+// *-max-listpack-entries that changes memory use and latency. Encoding names
+// are compared verbatim, so an older target reporting ziplist for a source
+// listpack counts as a mismatch too.
+func (c *Checker) compareEncodings(src, tgt *redisx.Client, keys []string, res *Result, lock *sync.Mutex) {
+	if len(keys) == 0 {
+		return
+	}
+	cmds := make([][]interface{}, len(keys))
+	for i, key := range keys {
+		cmds[i] = []interface{}{"OBJECT", "ENCODING", key}
+	}
+
+	srcReplies, err := src.Pipeline(cmds)
+	if err != nil {
+		log.Printf("Source OBJECT ENCODING pipeline failed: %v", err)
+		return
+	}
+	tgtReplies, err := tgt.Pipeline(cmds)
+	if err != nil {
+		log.Printf("Target OBJECT ENCODING pipeline failed: %v", err)
+		return
+	}
+
+	for i, key := range keys {
+		// nil: the key expired or was deleted since TYPE
+		if srcReplies[i] == nil || tgtReplies[i] == nil {
+			continue
+		}
+		srcEnc, err1 := redisx.ToString(srcReplies[i])
+		tgtEnc, err2 := redisx.ToString(tgtReplies[i])
+		if err1 != nil || err2 != nil {
+			log.Printf("Failed to parse OBJECT ENCODING for %s: %v %v", key, err1, err2)
+			continue
+		}
+		if srcEnc != tgtEnc {
+			c.recordEncodingMismatch(res, lock, key, srcEnc, tgtEnc)
+		}
+	}
+}
+
+func (c *Checker) recordEncodingMismatch(res *Result, lock *sync.Mutex, key, srcEnc, tgtEnc string) {
+	atomic.AddInt64(&res.EncodingMismatches, 1)
+	lock.Lock()
+	if len(res.EncodingSamples) < 100 {
+		res.EncodingSamples = append(res.EncodingSamples, fmt.Sprintf("%s (src:%s, tgt:%s)", key, srcEnc, tgtEnc))
+	}
+	lock.Unlock()
+}
@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// encodingServer holds sets with a fixed OBJECT ENCODING per key.
+func encodingServer(t *testing.T, encodings map[string]string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+					switch strings.ToUpper(args[0]) {
+					case "PING":
+						conn.Write([]byte("+PONG\r\n"))
+					case "CLIENT":
+						conn.Write([]byte("+OK\r\n"))
+					case "SCAN":
+						fmt.Fprintf(conn, "*2\r\n$1\r\n0\r\n*%d\r\n", len(encodings))
+						for k := range encodings {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(k), k)
+						}
+					case "TYPE":
+						conn.Write([]byte("+set\r\n"))
+					case "OBJECT":
+						enc := encodings[args[2]]
+						fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(enc), enc)
+					default:
+						conn.Write([]byte("-ERR unknown command\r\n"))
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestCheckEncoding(t *testing.T) {
+	src := encodingServer(t, map[string]string{"ids": "intset", "tags": "listpack"})
+	tgt := encodingServer(t, map[string]string{"ids": "hashtable", "tags": "listpack"})
+
+	c := NewChecker(Config{
+		SourceAddr:    src,
+		TargetAddr:    tgt,
+		ResultDir:     t.TempDir(),
+		Mode:          ModeKeyOutline,
+		CheckEncoding: true,
+	})
+	res, err := c.Run(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.InconsistentKeys != 0 || res.ConsistentKeys != 2 {
+		t.Errorf("encoding must not count as a value mismatch: consistent=%d inconsistent=%d",
+			res.ConsistentKeys, res.InconsistentKeys)
+	}
+	if res.EncodingMismatches != 1 || len(res.EncodingSamples) != 1 ||
+		res.EncodingSamples[0] != "ids (src:intset, tgt:hashtable)" {
+		t.Errorf("encoding mismatches = %d %v", res.EncodingMismatches, res.EncodingSamples)
+	}
+}
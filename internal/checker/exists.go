@@ -0,0 +1,115 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"df2redis/internal/redisx"
+)
+
+// dialTargetCluster connects to the target through the slot-aware client used
+// by ModeKeyExists, retrying once like dialWithRetry.
+func (c *Checker) dialTargetCluster(ctx context.Context) (*redisx.ClusterClient, error) {
+	opts := redisx.ClusterOptions{
+		TLS:         c.config.TargetTLS,
+		TLSCAFile:   c.config.TargetTLSCAFile,
+		TLSInsecure: c.config.TargetTLSInsecure,
+		ClientName:  "df2redis:checker:target",
+	}
+	dial := func() (*redisx.ClusterClient, error) {
+		if !c.config.TargetCluster {
+			return redisx.DialStandaloneWithOptions(ctx, c.config.TargetAddr, c.config.TargetPassword, opts)
+		}
+		seeds := c.config.TargetSeeds
+		if len(seeds) == 0 {
+			seeds = []string{c.config.TargetAddr}
+		}
+		return redisx.DialClusterWithOptions(ctx, seeds, c.config.TargetPassword, opts)
+	}
+
+	client, err := dial()
+	if err == nil {
+		return client, nil
+	}
+	log.Printf("   ⚠ Connecting to target %s failed (%v), retrying in %v", c.config.TargetAddr, err, dialRetryDelay)
+	select {
+	case <-time.After(dialRetryDelay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return dial()
+}
+
+// processExistsBatch checks that every source key exists on the target with
+// one pipelined EXISTS per target master. Nothing is read from the source and
+// nothing is held beyond the batch, so memory stays flat however large the
+// keyspace is; together with the saved SCAN cursor this is the cheap pass for
+// 100M+ key datasets.
+func (c *Checker) processExistsBatch(ctx context.Context, tgt *redisx.ClusterClient, keys []string, res *Result, lock *sync.Mutex, progressCh chan<- Progress) {
+	byNode := make(map[string][]string)
+	for _, key := range keys {
+		addr := tgt.MasterAddr(redisx.Slot(key))
+		byNode[addr] = append(byNode[addr], key)
+	}
+
+	for addr, nodeKeys := range byNode {
+		if ctx.Err() != nil {
+			return
+		}
+		replies, err := c.existsOnNode(tgt, addr, nodeKeys)
+		if err != nil {
+			// Typically MOVED/ASK mid-resharding; the cluster client follows redirects
+			log.Printf("Target EXISTS pipeline on %s failed (%v), checking keys one by one", addr, err)
+		}
+		for i, key := range nodeKeys {
+			atomic.AddInt64(&res.TotalKeys, 1)
+			var reply interface{}
+			if err == nil {
+				reply = replies[i]
+			} else if r, doErr := tgt.Do("EXISTS", key); doErr != nil {
+				log.Printf("EXISTS %s failed: %v", key, doErr)
+				c.recordInconsistency(res, lock, key, "exists", "error")
+				continue
+			} else {
+				reply = r
+			}
+			n, convErr := redisx.ToInt64(reply)
+			if convErr != nil {
+				c.recordInconsistency(res, lock, key, "exists", "error")
+				continue
+			}
+			if n == 0 {
+				atomic.AddInt64(&res.MissingKeys, 1)
+				c.recordInconsistency(res, lock, key, "exists", "none")
+				continue
+			}
+			atomic.AddInt64(&res.ConsistentKeys, 1)
+		}
+	}
+
+	c.reportProgress(res, progressCh)
+}
+
+func (c *Checker) existsOnNode(tgt *redisx.ClusterClient, addr string, keys []string) ([]interface{}, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("no master for slot")
+	}
+	client, err := tgt.GetNodeClient(addr)
+	if err != nil {
+		return nil, err
+	}
+	cmds := make([][]interface{}, len(keys))
+	for i, key := range keys {
+		cmds[i] = []interface{}{"EXISTS", key}
+	}
+	replies, err := client.Pipeline(cmds)
+	if err != nil {
+		// Unread replies would answer the next pipeline; reconnect instead
+		client.Close()
+	}
+	return replies, err
+}
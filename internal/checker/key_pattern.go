@@ -0,0 +1,13 @@
+package checker
+
+import "df2redis/internal/redisx"
+
+// excluded reports whether key matches any of the configured exclude patterns.
+func (c *Checker) excluded(key string) bool {
+	for _, p := range c.config.ExcludePatterns {
+		if redisx.MatchGlob(p, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"df2redis/internal/redisx"
+)
+
+const (
+	progressSaveInterval = 10 * time.Second
+	dialRetryDelay       = 2 * time.Second
+)
+
+// checkProgress is the resumable state of a check, persisted in ResultDir.
+type checkProgress struct {
+	Mode       CheckMode `json:"mode"`
+	SourceAddr string    `json:"sourceAddr"`
+	TargetAddr string    `json:"targetAddr"`
+	Cursor     string    `json:"cursor"` // source SCAN cursor to resume from
+	Completed  bool      `json:"completed"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	TotalKeys        int64    `json:"totalKeys"`
+	ConsistentKeys   int64    `json:"consistentKeys"`
+	InconsistentKeys int64    `json:"inconsistentKeys"`
+	MissingKeys      int64    `json:"missingKeys"`
+	ExcludedKeys     int64    `json:"excludedKeys"`
+	Samples          []string `json:"samples,omitempty"`
+
+	EncodingMismatches int64    `json:"encodingMismatches,omitempty"`
+	EncodingSamples    []string `json:"encodingSamples,omitempty"`
+}
+
+// scanTracker maps processed key counts back to a SCAN cursor that is safe
+// to resume from. Workers finish batches out of order, so a page only counts
+// as done once the processed total exceeds its keys by slack (one in-flight
+// batch per worker); keys in that window may be compared twice after a resume.
+type scanTracker struct {
+	mu         sync.Mutex
+	pages      []scanPage
+	enqueued   int64
+	processed  int64 // atomic
+	slack      int64
+	safeCursor string
+}
+
+type scanPage struct {
+	cursor   string // cursor returned after this page
+	enqueued int64  // total keys enqueued up to and including this page
+}
+
+func newScanTracker(startCursor string, slack int) *scanTracker {
+	return &scanTracker{safeCursor: startCursor, slack: int64(slack)}
+}
+
+func (t *scanTracker) pageDone(cursor string, keys int) {
+	t.mu.Lock()
+	t.enqueued += int64(keys)
+	t.pages = append(t.pages, scanPage{cursor: cursor, enqueued: t.enqueued})
+	t.mu.Unlock()
+}
+
+func (t *scanTracker) addProcessed(n int) {
+	atomic.AddInt64(&t.processed, int64(n))
+}
+
+// checkpoint returns the latest cursor whose keys have all been compared.
+// When every worker has exited, done should be true so no slack is applied.
+func (t *scanTracker) checkpoint(done bool) string {
+	processed := atomic.LoadInt64(&t.processed)
+	if !done {
+		processed -= t.slack
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for len(t.pages) > 0 && t.pages[0].enqueued <= processed {
+		t.safeCursor = t.pages[0].cursor
+		t.pages = t.pages[1:]
+	}
+	return t.safeCursor
+}
+
+func (c *Checker) progressPath() string {
+	name := "check-progress.json"
+	if c.config.TaskName != "" {
+		name = c.config.TaskName + "_" + name
+	}
+	return filepath.Join(c.config.ResultDir, name)
+}
+
+// loadProgress returns the saved progress of an unfinished check against the
+// same source, target and mode, or nil when there is nothing to resume.
+func (c *Checker) loadProgress() (*checkProgress, error) {
+	data, err := os.ReadFile(c.progressPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read check progress: %w", err)
+	}
+	var p checkProgress
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse check progress %s: %w", c.progressPath(), err)
+	}
+	if p.Completed {
+		log.Printf("   Previous check in %s already completed, starting over", c.progressPath())
+		return nil, nil
+	}
+	if p.Mode != c.config.Mode || p.SourceAddr != c.config.SourceAddr || p.TargetAddr != c.config.TargetAddr {
+		return nil, fmt.Errorf("check progress %s belongs to a different check (mode=%s source=%s target=%s)",
+			c.progressPath(), p.Mode, p.SourceAddr, p.TargetAddr)
+	}
+	return &p, nil
+}
+
+func (c *Checker) saveProgress(res *Result, lock *sync.Mutex, cursor string, completed bool) error {
+	p := checkProgress{
+		Mode:             c.config.Mode,
+		SourceAddr:       c.config.SourceAddr,
+		TargetAddr:       c.config.TargetAddr,
+		Cursor:           cursor,
+		Completed:        completed,
+		UpdatedAt:        time.Now(),
+		TotalKeys:        atomic.LoadInt64(&res.TotalKeys),
+		ConsistentKeys:   atomic.LoadInt64(&res.ConsistentKeys),
+		InconsistentKeys: atomic.LoadInt64(&res.InconsistentKeys),
+		MissingKeys:      atomic.LoadInt64(&res.MissingKeys),
+		ExcludedKeys:     atomic.LoadInt64(&res.ExcludedKeys),
+
+		EncodingMismatches: atomic.LoadInt64(&res.EncodingMismatches),
+	}
+	lock.Lock()
+	p.Samples = append([]string(nil), res.InconsistentSamples...)
+	p.EncodingSamples = append([]string(nil), res.EncodingSamples...)
+	lock.Unlock()
+
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.progressPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write check progress: %w", err)
+	}
+	return os.Rename(tmp, c.progressPath())
+}
+
+// restore seeds res with the counters of a resumed check.
+func (p *checkProgress) restore(res *Result) {
+	res.TotalKeys = p.TotalKeys
+	res.ConsistentKeys = p.ConsistentKeys
+	res.InconsistentKeys = p.InconsistentKeys
+	res.MissingKeys = p.MissingKeys
+	res.ExcludedKeys = p.ExcludedKeys
+	res.InconsistentSamples = append(res.InconsistentSamples, p.Samples...)
+	res.EncodingMismatches = p.EncodingMismatches
+	res.EncodingSamples = append(res.EncodingSamples, p.EncodingSamples...)
+}
+
+// dialWithRetry connects to addr, retrying once so a transient startup
+// failure (e.g. a node restarting) doesn't fail the whole check.
+func dialWithRetry(ctx context.Context, cfg redisx.Config, role string) (*redisx.Client, error) {
+	if cfg.Name == "" {
+		cfg.Name = "df2redis:checker:" + role
+	}
+	client, err := redisx.Dial(ctx, cfg)
+	if err == nil {
+		return client, nil
+	}
+	log.Printf("   ⚠ Connecting to %s %s failed (%v), retrying in %v", role, cfg.Addr, err, dialRetryDelay)
+	select {
+	case <-time.After(dialRetryDelay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return redisx.Dial(ctx, cfg)
+}
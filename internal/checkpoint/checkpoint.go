@@ -18,12 +18,29 @@ type Checkpoint struct {
 	FlowLSNs      map[int]uint64 `json:"flow_lsns"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	Version       int            `json:"version"`
+
+	// FlowFiles lists the per-FLOW LSN files (per-FLOW mode), relative to
+	// the manifest. Their LSNs take precedence over FlowLSNs on load.
+	FlowFiles map[int]string `json:"flow_files,omitempty"`
+}
+
+// FlowCheckpoint is the durable LSN of a single FLOW in per-FLOW mode.
+type FlowCheckpoint struct {
+	ReplicationID string    `json:"replication_id"`
+	SessionID     string    `json:"session_id"`
+	FlowID        int       `json:"flow_id"`
+	LSN           uint64    `json:"lsn"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // Manager coordinates checkpoint reads/writes
 type Manager struct {
 	filePath string
 	mu       sync.Mutex
+
+	// per-FLOW mode: each FLOW's LSN lives in its own file next to the manifest
+	perFlow  bool
+	savedLSN map[int]uint64
 }
 
 // NewManager constructs a checkpoint manager for the provided path
@@ -33,6 +50,21 @@ func NewManager(filePath string) *Manager {
 	}
 }
 
+// NewPerFlowManager constructs a manager that writes one LSN file per FLOW
+// and uses filePath as the manifest. A FLOW whose file fails to write keeps
+// its previous durable LSN without holding back the others.
+func NewPerFlowManager(filePath string) *Manager {
+	return &Manager{
+		filePath: filePath,
+		perFlow:  true,
+		savedLSN: make(map[int]uint64),
+	}
+}
+
+func (m *Manager) flowFileName(flowID int) string {
+	return fmt.Sprintf("%s.flow%d", filepath.Base(m.filePath), flowID)
+}
+
 // Load reads an existing checkpoint if present
 func (m *Manager) Load() (*Checkpoint, error) {
 	m.mu.Lock()
@@ -55,6 +87,29 @@ func (m *Manager) Load() (*Checkpoint, error) {
 		return nil, fmt.Errorf("failed to parse checkpoint JSON: %w", err)
 	}
 
+	// Per-FLOW files are written before the manifest, so they are at least
+	// as recent; files from another replication session are ignored
+	dir := filepath.Dir(m.filePath)
+	for flowID, name := range cp.FlowFiles {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		var fc FlowCheckpoint
+		if err := json.Unmarshal(data, &fc); err != nil {
+			continue
+		}
+		if fc.FlowID != flowID || fc.ReplicationID != cp.ReplicationID || fc.SessionID != cp.SessionID {
+			continue
+		}
+		if cp.FlowLSNs == nil {
+			cp.FlowLSNs = make(map[int]uint64)
+		}
+		if fc.LSN > cp.FlowLSNs[flowID] {
+			cp.FlowLSNs[flowID] = fc.LSN
+		}
+	}
+
 	return &cp, nil
 }
 
@@ -69,25 +124,63 @@ func (m *Manager) Save(cp *Checkpoint) error {
 		cp.Version = 1
 	}
 
-	// Encode JSON
-	data, err := json.MarshalIndent(cp, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to serialize checkpoint JSON: %w", err)
-	}
-
 	// Ensure directory exists
 	dir := filepath.Dir(m.filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Atomic write: temp file + rename
-	tmpFile := m.filePath + ".tmp"
+	var flowErr error
+	if m.perFlow {
+		// Commit each FLOW first, then the manifest that references them
+		cp.FlowFiles = make(map[int]string, len(cp.FlowLSNs))
+		for flowID, lsn := range cp.FlowLSNs {
+			cp.FlowFiles[flowID] = m.flowFileName(flowID)
+			if saved, ok := m.savedLSN[flowID]; ok && saved == lsn {
+				continue
+			}
+			fc := &FlowCheckpoint{
+				ReplicationID: cp.ReplicationID,
+				SessionID:     cp.SessionID,
+				FlowID:        flowID,
+				LSN:           lsn,
+				UpdatedAt:     cp.UpdatedAt,
+			}
+			if err := writeJSONAtomic(filepath.Join(dir, cp.FlowFiles[flowID]), fc); err != nil {
+				if flowErr == nil {
+					flowErr = fmt.Errorf("FLOW-%d: %w", flowID, err)
+				}
+				// The manifest must not claim an LSN that isn't durable
+				if saved, ok := m.savedLSN[flowID]; ok {
+					cp.FlowLSNs[flowID] = saved
+				} else {
+					delete(cp.FlowLSNs, flowID)
+				}
+				continue
+			}
+			m.savedLSN[flowID] = lsn
+		}
+	}
+
+	if err := writeJSONAtomic(m.filePath, cp); err != nil {
+		return err
+	}
+	return flowErr
+}
+
+// writeJSONAtomic writes v to path via a temp file + rename.
+func writeJSONAtomic(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize checkpoint JSON: %w", err)
+	}
+
+	tmpFile := path + ".tmp"
 	if err := ioutil.WriteFile(tmpFile, data, 0644); err != nil {
 		return fmt.Errorf("failed to write temporary file: %w", err)
 	}
 
-	if err := os.Rename(tmpFile, m.filePath); err != nil {
+	if err := os.Rename(tmpFile, path); err != nil {
 		os.Remove(tmpFile) // cleanup best effort
 		return fmt.Errorf("failed to rename checkpoint file: %w", err)
 	}
@@ -104,5 +197,13 @@ func (m *Manager) Delete() error {
 		return fmt.Errorf("failed to delete checkpoint file: %w", err)
 	}
 
+	if m.perFlow {
+		flowFiles, _ := filepath.Glob(m.filePath + ".flow*")
+		for _, f := range flowFiles {
+			os.Remove(f)
+		}
+		m.savedLSN = make(map[int]uint64)
+	}
+
 	return nil
 }
@@ -2,21 +2,31 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"df2redis/internal/checker"
+	"df2redis/internal/checkpoint"
 	"df2redis/internal/config"
+	"df2redis/internal/cutover"
 	"df2redis/internal/logger"
+	"df2redis/internal/redisx"
 	"df2redis/internal/replica"
+	"df2redis/internal/rollback"
 	"df2redis/internal/state"
 	"df2redis/internal/web"
 )
@@ -53,10 +63,22 @@ func Execute(args []string) int {
 		return runCheck(args[1:])
 	case "status":
 		return runStatus(args[1:])
+	case "stats":
+		return runStats(args[1:])
 	case "rollback":
 		return runRollback(args[1:])
+	case "cutover":
+		return runCutover(args[1:])
 	case "dashboard":
 		return runDashboard(args[1:])
+	case "inspect-rdb":
+		return runInspectRDB(args[1:])
+	case "validate-config":
+		return runValidateConfig(args[1:])
+	case "replay-file":
+		return runReplayFile(args[1:])
+	case "topology":
+		return runTopology(args[1:])
 
 	case "help", "-h", "--help":
 		printUsage()
@@ -83,11 +105,359 @@ func runPrepare(args []string) int {
 		log.Printf("Failed to create state directory: %v", err)
 		return 1
 	}
+	if err := precheckSnapshot(cfg.ResolvedMigrateConfig().SnapshotPath); err != nil {
+		log.Printf("Snapshot precheck failed: %v", err)
+		return 1
+	}
 	log.Printf("🛠️ Preparation complete:\n  📂 stateDir  : %s\n  📝 statusFile: %s",
 		cfg.ResolveStateDir(), cfg.StatusFilePath())
 	return 0
 }
 
+// runValidateConfig loads a config the way migrate/replicate would and prints
+// the effective result (defaults, absolute paths, redacted secrets) together
+// with the redis-shake invocation, without connecting to anything.
+func runValidateConfig(args []string) int {
+	cfg, err := loadConfigFromArgs("validate-config", args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		log.Printf("Failed to load config: %v", err)
+		return 2
+	}
+
+	data, err := json.MarshalIndent(cfg.Effective(), "", "  ")
+	if err != nil {
+		log.Printf("Failed to render config: %v", err)
+		return 1
+	}
+	fmt.Printf("# Effective config (%s)\n%s\n", cfg.Path(), data)
+
+	mc := cfg.ResolvedMigrateConfig()
+	fmt.Println("\n# Paths")
+	for _, p := range []struct{ name, path string }{
+		{"snapshot", mc.SnapshotPath},
+		{"shakeBinary", mc.ShakeBinary},
+		{"shakeConfigFile", mc.ShakeConfigFile},
+		{"checkpoint", cfg.ResolveCheckpointPath()},
+		{"stateDir", cfg.ResolveStateDir()},
+		{"statusFile", cfg.StatusFilePath()},
+	} {
+		if p.path == "" {
+			continue
+		}
+		status := "exists"
+		if _, err := os.Stat(p.path); err != nil {
+			status = "missing"
+		}
+		fmt.Printf("  %-16s %s (%s)\n", p.name, p.path, status)
+	}
+
+	fmt.Println("\n# redis-shake")
+	switch {
+	case mc.ShakeConfigFile != "":
+		fmt.Printf("  %s %s\n", mc.ShakeBinary, mc.ShakeConfigFile)
+	case mc.ShakeArgs != "":
+		fmt.Printf("  %s %s\n", mc.ShakeBinary, cfg.RedactArgs(mc.ShakeArgs))
+	default:
+		fmt.Println("  (no shakeArgs/shakeConfigFile; native replication does not invoke redis-shake)")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("\n❌ %v\n", err)
+		return 2
+	}
+	fmt.Println("\n✅ Config is valid")
+	return 0
+}
+
+// precheckSnapshot verifies the configured RDB snapshot is readable,
+// decompressing gzip/zstd input on the fly to check the RDB magic.
+// A missing file is only reported since native replication doesn't need it.
+func precheckSnapshot(path string) error {
+	info, err := os.Stat(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	// A directory or glob of per-shard files: check each of them
+	if (err == nil && info.IsDir()) || (os.IsNotExist(err) && strings.ContainsAny(path, "*?[")) {
+		paths, err := replica.ExpandSnapshotPaths(path)
+		if err != nil {
+			log.Printf("ℹ️ %v, skipping snapshot precheck", err)
+			return nil
+		}
+		for _, p := range paths {
+			if err := precheckSnapshot(p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		log.Printf("ℹ️ Snapshot %s not found, skipping snapshot precheck", path)
+		return nil
+	}
+
+	rc, compression, err := replica.OpenSnapshotFile(path)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	magic := make([]byte, 5)
+	if _, err := io.ReadFull(rc, magic); err != nil {
+		return fmt.Errorf("read RDB header from %s: %w", path, err)
+	}
+	if string(magic) != "REDIS" {
+		return fmt.Errorf("%s is not an RDB file (compression=%s, header=%q)", path, compression, magic)
+	}
+	log.Printf("📦 Snapshot %s: %d bytes on disk, compression=%s", path, info.Size(), compression)
+	return nil
+}
+
+// runInspectRDB parses a local (optionally gzip/zstd compressed) RDB file
+// offline and prints a type histogram, the largest keys and the first parse error.
+func runInspectRDB(args []string) int {
+	fs := flag.NewFlagSet("inspect-rdb", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var (
+		file    string
+		topN    int
+		verbose bool
+	)
+	fs.StringVar(&file, "file", "", "RDB file to inspect (.rdb, .gz or .zst), or a directory/glob of per-shard files")
+	fs.StringVar(&file, "f", "", "RDB file to inspect (.rdb, .gz or .zst), or a directory/glob of per-shard files")
+	fs.IntVar(&topN, "top", 10, "Number of largest keys to list")
+	fs.BoolVar(&verbose, "verbose", false, "Print parser debug logs")
+	var (
+		slotHistogram  bool
+		masters        int
+		target         string
+		targetPassword string
+	)
+	fs.BoolVar(&slotHistogram, "slot-histogram", false, "Report how keys would spread across cluster slots and masters")
+	fs.IntVar(&masters, "masters", 3, "With --slot-histogram: assume this many masters with evenly split slots")
+	fs.StringVar(&target, "target", "", "With --slot-histogram: use the slot map of this live cluster node instead of --masters")
+	fs.StringVar(&targetPassword, "target-password", "", "Password for --target")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		log.Printf("Failed to parse arguments: %v", err)
+		return 1
+	}
+	if file == "" {
+		fs.Usage()
+		return 2
+	}
+	if topN < 0 {
+		log.Printf("--top must be >= 0")
+		return 2
+	}
+	if slotHistogram && target == "" && masters <= 0 {
+		log.Printf("--masters must be > 0")
+		return 2
+	}
+
+	// Resolve slot owners before the (possibly long) parse so a bad --target fails fast
+	var owner func(slot int) string
+	if slotHistogram {
+		var err error
+		if owner, err = slotOwners(target, targetPassword, masters); err != nil {
+			log.Printf("Failed to load slot map from %s: %v", target, err)
+			return 1
+		}
+	}
+
+	paths, err := replica.ExpandSnapshotPaths(file)
+	if err != nil {
+		log.Printf("Failed to open RDB file: %v", err)
+		return 1
+	}
+
+	// The parser logs per-opcode progress meant for live streams; keep it quiet by default
+	if !verbose {
+		prev := log.Writer()
+		log.SetOutput(io.Discard)
+		defer log.SetOutput(prev)
+	}
+
+	// One file per shard: parse them concurrently, like FLOWs
+	start := time.Now()
+	reports := make([]*replica.RDBInspectReport, len(paths))
+	compressions := make([]replica.SnapshotCompression, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			rc, compression, err := replica.OpenSnapshotFile(path)
+			if err != nil {
+				reports[i] = &replica.RDBInspectReport{TypeCounts: map[string]int64{}, TypeBytes: map[string]int64{}, Err: err}
+				return
+			}
+			defer rc.Close()
+			compressions[i] = compression
+			reports[i] = replica.InspectRDB(rc, topN, slotHistogram)
+		}(i, path)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := reports[0]
+	if len(paths) == 1 {
+		fmt.Printf("📦 %s (compression=%s)\n", file, compressions[0])
+	} else {
+		fmt.Printf("📦 %s: %d files\n", file, len(paths))
+		for i, path := range paths {
+			status := "ok"
+			if reports[i].Err != nil {
+				status = fmt.Sprintf("error: %v", reports[i].Err)
+			}
+			fmt.Printf("  %-40s compression=%-5s keys=%-10d %s\n", path, compressions[i], reports[i].Keys, status)
+		}
+		for _, other := range reports[1:] {
+			report.Merge(other, topN)
+		}
+	}
+	fmt.Printf("  keys=%d  expiring=%d  already-expired=%d  approx-bytes=%d  parsed-in=%v\n",
+		report.Keys, report.Expiring, report.Expired, report.Bytes, elapsed.Round(time.Millisecond))
+
+	if len(report.TypeCounts) > 0 {
+		types := make([]string, 0, len(report.TypeCounts))
+		for t := range report.TypeCounts {
+			types = append(types, t)
+		}
+		sort.Slice(types, func(i, j int) bool { return report.TypeCounts[types[i]] > report.TypeCounts[types[j]] })
+		fmt.Println("\nTypes:")
+		for _, t := range types {
+			fmt.Printf("  %-28s keys=%-10d bytes=%d\n", t, report.TypeCounts[t], report.TypeBytes[t])
+		}
+	}
+
+	if len(report.Largest) > 0 {
+		fmt.Printf("\nLargest %d keys:\n", len(report.Largest))
+		for _, k := range report.Largest {
+			fmt.Printf("  %-12d %-28s elements=%-8d db=%d key=%q\n", k.Size, k.Type, k.Elements, k.DbIndex, k.Key)
+		}
+	}
+
+	if slotHistogram {
+		printSlotHistogram(report, owner, topN)
+	}
+
+	if report.Err != nil {
+		fmt.Printf("\n❌ Parsing stopped after %d keys: %v\n", report.Keys, report.Err)
+		if report.LastKey != "" {
+			fmt.Printf("   last key parsed successfully: %q\n", report.LastKey)
+		}
+		return 1
+	}
+	fmt.Println("\n✅ Parsed to EOF without errors")
+	return 0
+}
+
+// slotOwners maps a slot to the master that serves it: the live slot map of
+// target when given, otherwise masters evenly split slot ranges (as
+// redis-cli --cluster create assigns them).
+func slotOwners(target, password string, masters int) (func(slot int) string, error) {
+	if target == "" {
+		return func(slot int) string {
+			i := slot * masters / 16384
+			if (i+1)*16384/masters <= slot {
+				i++
+			}
+			return fmt.Sprintf("master-%d (slots %d-%d)", i, i*16384/masters, (i+1)*16384/masters-1)
+		}, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cc, err := redisx.DialCluster(ctx, []string{target}, password)
+	if err != nil {
+		return nil, err
+	}
+	defer cc.Close()
+	var addrs [16384]string
+	for slot := range addrs {
+		addrs[slot] = cc.MasterAddr(uint16(slot))
+		if addrs[slot] == "" {
+			addrs[slot] = "(unassigned)"
+		}
+	}
+	return func(slot int) string { return addrs[slot] }, nil
+}
+
+// printSlotHistogram prints keys per master, the hottest slots and the most
+// used hash tags of an inspect-rdb report.
+func printSlotHistogram(report *replica.RDBInspectReport, owner func(slot int) string, topN int) {
+	perMaster := make(map[string]int64)
+	for slot, n := range report.SlotKeys {
+		perMaster[owner(slot)] += n
+	}
+	names := make([]string, 0, len(perMaster))
+	for name := range perMaster {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Println("\nKeys per master:")
+	var maxKeys int64
+	for _, name := range names {
+		n := perMaster[name]
+		maxKeys = max(maxKeys, n)
+		pct := 0.0
+		if report.Keys > 0 {
+			pct = float64(n) * 100 / float64(report.Keys)
+		}
+		fmt.Printf("  %-40s keys=%-10d %5.1f%%\n", name, n, pct)
+	}
+	if avg := float64(report.Keys) / float64(len(names)); avg > 0 {
+		fmt.Printf("  imbalance: busiest master holds %.2fx the average\n", float64(maxKeys)/avg)
+	}
+
+	if topN == 0 {
+		return
+	}
+	slots := make([]int, 0, len(report.SlotKeys))
+	for slot, n := range report.SlotKeys {
+		if n > 0 {
+			slots = append(slots, slot)
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return report.SlotKeys[slots[i]] > report.SlotKeys[slots[j]] })
+	if len(slots) > topN {
+		slots = slots[:topN]
+	}
+	fmt.Printf("\nHottest %d slots:\n", len(slots))
+	for _, slot := range slots {
+		fmt.Printf("  slot=%-6d keys=%-10d %s\n", slot, report.SlotKeys[slot], owner(slot))
+	}
+
+	if len(report.HashTags) == 0 {
+		return
+	}
+	tags := make([]string, 0, len(report.HashTags))
+	for tag := range report.HashTags {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if report.HashTags[tags[i]] != report.HashTags[tags[j]] {
+			return report.HashTags[tags[i]] > report.HashTags[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) > topN {
+		tags = tags[:topN]
+	}
+	fmt.Printf("\nTop %d hash tags (%d distinct):\n", len(tags), len(report.HashTags))
+	for _, tag := range tags {
+		slot := int(redisx.Slot("{" + tag + "}"))
+		fmt.Printf("  keys=%-10d slot=%-6d %s {%s}\n", report.HashTags[tag], slot, owner(slot), tag)
+	}
+}
+
 func runMigrate(args []string) int {
 	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
@@ -103,6 +473,14 @@ func runMigrate(args []string) int {
 	fs.IntVar(&showPort, "show", 0, "Start embedded dashboard on the given port (e.g. --show 8080)")
 	fs.StringVar(&showAddr, "show-addr", "", "Start embedded dashboard on the given address (e.g. --show-addr 0.0.0.0:8080)")
 	fs.BoolVar(&verify, "verify", false, "Run data consistency check after migration (smart mode)")
+	var verifyAfter string
+	fs.StringVar(&verifyAfter, "verify-after", "", "Run the check in this mode (full/length/outline/smart/exists) after a successful migration; exit 1 on inconsistencies")
+	var traceWrites string
+	fs.StringVar(&traceWrites, "trace-writes", "", "Log every command sent to the target to this file (values redacted)")
+	var onlyTypes string
+	fs.StringVar(&onlyTypes, "only-types", "", "Only migrate these data types, e.g. string,hash (overrides migrate.includeTypes)")
+	tlsOpts := addTLSFlags(fs)
+	noEmoji := addPlainFlag(fs)
 
 	if err := fs.Parse(args); err != nil {
 		if err == flag.ErrHelp {
@@ -116,16 +494,28 @@ func runMigrate(args []string) int {
 		fs.Usage()
 		return 2
 	}
+	if _, ok := parseCheckMode(verifyAfter); verifyAfter != "" && !ok {
+		log.Printf("Unknown --verify-after mode: %s", verifyAfter)
+		return 2
+	}
 
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		log.Printf("Failed to load config: %v", err)
 		return 2
 	}
+	if onlyTypes != "" {
+		cfg.Migrate.IncludeTypes = splitCommaList(onlyTypes)
+	}
 	if err := cfg.Validate(); err != nil {
 		log.Printf("Config validation failed: %v", err)
 		return 2
 	}
+	if traceWrites != "" {
+		cfg.Observability.TraceWrites = traceWrites
+	}
+	tlsOpts.apply(cfg)
+	applyPlainLogs(cfg, *noEmoji)
 	log.Printf("✅ Config loaded:\n%s", cfg.PrettySummary())
 
 	if dryRun {
@@ -193,92 +583,128 @@ func runMigrate(args []string) int {
 	logger.Console("📂 Log dir: %s", cfg.Log.Dir)
 	logger.Console("🎯 Target: %s", cfg.Target.Addr)
 
-	// Build replicator
-	replicator := replica.NewReplicator(cfg)
-	replicator.AttachStateStore(store)
+	ctx, cancel := shutdownContext(cfg)
+	defer cancel()
 
-	// Configure signal handling
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	if err := replica.RunMigration(ctx, cfg, store); err != nil {
+		if errors.Is(err, context.Canceled) {
+			return 0
+		}
+		logger.Error("❌ Migration failed: %v", err)
+		return 1
+	}
+	logger.Console("\n✅ Migration completed successfully")
+
+	// ---------------------
+	// Post-Migration Verify
+	// ---------------------
+	if verify && verifyAfter == "" {
+		verifyAfter = string(checker.ModeSmartBigKey)
+	}
+	if verifyAfter != "" {
+		return runVerifyAfter(ctx, cfg, checker.CheckMode(verifyAfter))
+	}
+	return 0
+}
 
-	// Start replicator
-	errCh := make(chan error, 1)
+// runVerifyAfter runs the consistency check right after a successful copy,
+// against the same source/target settings the copy used, and returns the
+// command's exit code (1 on any inconsistency).
+func runVerifyAfter(ctx context.Context, cfg *config.Config, mode checker.CheckMode) int {
+	logger.Console("\n🔍 Starting post-migration verification (%s mode)...", mode)
+
+	checkCfg := checkerEndpoints(cfg)
+	checkCfg.Mode = mode
+	checkCfg.QPS = 5000
+	checkCfg.Parallel = 4
+	checkCfg.ResultDir = "check-results"
+	checkCfg.BatchSize = 1000
+	checkCfg.Timeout = 3600
+	checkCfg.BigKeyThreshold = 5000
+	checkCfg.TaskName = "verify-after-migrate"
+
+	c := checker.NewChecker(checkCfg)
+	progressCh := make(chan checker.Progress, 100)
+
+	// Simple progress reporter for CLI
 	go func() {
-		if err := replicator.Start(); err != nil {
-			errCh <- err
-		} else {
-			errCh <- nil // Success
+		for p := range progressCh {
+			if p.TotalKeys > 0 && p.TotalKeys%1000 == 0 {
+				fmt.Printf("\rChecked: %d keys | Inconsistent: %d | Missing: %d", p.CheckedKeys, p.InconsistentKeys, p.MissingKeys)
+			}
 		}
+		fmt.Println()
 	}()
 
-	// Wait for completion or signal
-	select {
-	case err := <-errCh:
-		if err != nil {
-			logger.Error("❌ Migration failed: %v", err)
-			return 1
-		}
-		logger.Console("\n✅ Migration completed successfully")
-
-		// ---------------------
-		// Post-Migration Verify
-		// ---------------------
-		if verify {
-			logger.Console("\n🔍 Starting post-migration verification (smart mode)...")
-
-			// Create checker config
-			checkCfg := checker.Config{
-				SourceAddr:      cfg.Source.Addr,
-				SourcePassword:  cfg.Source.Password,
-				TargetAddr:      cfg.Target.Addr,
-				TargetPassword:  cfg.Target.Password,
-				Mode:            checker.ModeSmartBigKey, // Default to smart mode for verify flag
-				QPS:             5000,
-				Parallel:        4,
-				ResultDir:       "check-results",
-				BatchSize:       1000,
-				Timeout:         3600,
-				BigKeyThreshold: 5000,
-				TaskName:        "verify-after-migrate",
-			}
+	result, err := c.Run(ctx, progressCh)
+	close(progressCh)
 
-			c := checker.NewChecker(checkCfg)
-			progressCh := make(chan checker.Progress, 100)
+	if err != nil {
+		logger.Error("❌ Verification failed: %v", err)
+		return 1
+	}
 
-			// Simple progress reporter for CLI
-			go func() {
-				for p := range progressCh {
-					if p.TotalKeys > 0 && p.TotalKeys%1000 == 0 {
-						fmt.Printf("\rChecked: %d keys | Inconsistent: %d | Missing: %d", p.CheckedKeys, p.InconsistentKeys, p.MissingKeys)
-					}
-				}
-				fmt.Println()
-			}()
+	c.PrintResult(result)
 
-			ctx := context.Background()
-			result, err := c.Run(ctx, progressCh)
-			close(progressCh)
+	if result.InconsistentKeys > 0 || result.MissingKeys > 0 {
+		logger.Error("❌ Verification found inconsistencies! See %s", result.ResultFile)
+		return 1
+	}
+	logger.Console("✅ Verification passed! Source and Target are consistent.")
+	return 0
+}
 
-			if err != nil {
-				logger.Error("❌ Verification failed: %v", err)
-				return 1
-			}
+// checkerEndpoints returns a checker config with the source and target
+// connection settings (addresses, credentials, TLS, cluster seeds) of cfg.
+func checkerEndpoints(cfg *config.Config) checker.Config {
+	return checker.Config{
+		SourceAddr:        cfg.Source.Addr,
+		SourcePassword:    cfg.Source.Password,
+		TargetAddr:        cfg.Target.Addr,
+		TargetPassword:    cfg.Target.Password,
+		TargetCluster:     strings.Contains(strings.ToLower(cfg.Target.Type), "cluster"),
+		TargetSeeds:       cfg.Target.Cluster.Seeds,
+		SourceTLS:         cfg.Source.TLS,
+		SourceTLSCAFile:   cfg.Source.TLSCAFile,
+		SourceTLSInsecure: cfg.Source.TLSInsecure,
+		TargetTLS:         cfg.Target.TLS,
+		TargetTLSCAFile:   cfg.Target.TLSCAFile,
+		TargetTLSInsecure: cfg.Target.TLSInsecure,
+	}
+}
 
-			c.PrintResult(result)
+// parseCheckMode maps a --mode / --verify-after value to a checker mode.
+func parseCheckMode(mode string) (checker.CheckMode, bool) {
+	switch m := checker.CheckMode(mode); m {
+	case checker.ModeFullValue, checker.ModeValueLength, checker.ModeKeyOutline,
+		checker.ModeSmartBigKey, checker.ModeKeyExists:
+		return m, true
+	}
+	return "", false
+}
 
-			if result.InconsistentKeys > 0 || result.MissingKeys > 0 {
-				logger.Error("❌ Verification found inconsistencies! See %s", result.ResultFile)
-				return 1
-			}
-			logger.Console("✅ Verification passed! Source and Target are consistent.")
-		}
+// shutdownContext returns a context cancelled on SIGINT/SIGTERM or when
+// rollback drops a stop request, logging which one triggered the shutdown.
+func shutdownContext(cfg *config.Config) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
 
-		return 0
-	case sig := <-sigCh:
-		logger.Console("\n📡 Signal %v received, shutting down...", sig)
-		replicator.Stop()
-		return 0
-	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	stopReq := watchStopRequest(ctx, cfg.StopRequestPath())
+
+	go func() {
+		defer signal.Stop(sigCh)
+		select {
+		case sig := <-sigCh:
+			logger.Console("\n📡 Signal %v received, shutting down...", sig)
+			cancel()
+		case <-stopReq:
+			logger.Console("\n↩️ Stop requested by rollback, shutting down...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
 }
 
 func runStatus(args []string) int {
@@ -305,30 +731,420 @@ func runStatus(args []string) int {
 	if len(snap.Events) > 0 {
 		log.Println("events:")
 		for _, ev := range snap.Events {
-			log.Printf("  🗒️ [%s] %s - %s", ev.Timestamp.Format(time.RFC3339), ev.Type, ev.Message)
+			severity := ev.Severity
+			if severity == "" {
+				severity = state.SeverityInfo
+			}
+			log.Printf("  🗒️ [%s] %-5s %s - %s", ev.Timestamp.Format(time.RFC3339), severity, ev.Type, ev.Message)
+		}
+	}
+	return 0
+}
+
+// topologyMaster is one master of the topology report.
+type topologyMaster struct {
+	Addr      string   `json:"addr"`
+	SlotCount int      `json:"slotCount"`
+	Slots     [][2]int `json:"slots"`
+}
+
+// topologyReport is what the topology command prints.
+type topologyReport struct {
+	Target     string             `json:"target"`
+	Type       string             `json:"type"`
+	Masters    []topologyMaster   `json:"masters"`
+	SlotMap    []redisx.SlotRange `json:"slotMap"`
+	Unassigned [][2]int           `json:"unassigned,omitempty"`
+}
+
+// runTopology connects to the target the way migrate/replicate do and prints
+// the masters and the 16384-slot map the writers route by, compressed to
+// ranges. Slots no master claims are the ones that fail with "no master
+// found for slot N".
+func runTopology(args []string) int {
+	cfg, err := loadConfigFromArgs("topology", args)
+	if err != nil {
+		return errorToExitCode(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cc, err := replica.DialTarget(ctx, cfg.Target, "df2redis:topology")
+	if err != nil {
+		log.Printf("Failed to connect to target %s: %v", cfg.Target.Endpoint(), err)
+		return 1
+	}
+	defer cc.Close()
+
+	report := topologyReport{
+		Target:  cfg.Target.Endpoint(),
+		Type:    cfg.Target.Type,
+		Masters: []topologyMaster{},
+		SlotMap: cc.SlotRanges(),
+	}
+	byAddr := make(map[string]int)
+	for _, r := range report.SlotMap {
+		span := [2]int{r.Start, r.End}
+		if r.Addr == "" {
+			report.Unassigned = append(report.Unassigned, span)
+			continue
 		}
+		i, ok := byAddr[r.Addr]
+		if !ok {
+			i = len(report.Masters)
+			byAddr[r.Addr] = i
+			report.Masters = append(report.Masters, topologyMaster{Addr: r.Addr})
+		}
+		report.Masters[i].Slots = append(report.Masters[i].Slots, span)
+		report.Masters[i].SlotCount += r.End - r.Start + 1
+	}
+	sort.Slice(report.Masters, func(i, j int) bool { return report.Masters[i].Addr < report.Masters[j].Addr })
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Printf("Failed to render topology: %v", err)
+		return 1
 	}
+	fmt.Println(string(data))
 	return 0
 }
 
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var (
+		configPath string
+		watch      bool
+		interval   time.Duration
+	)
+	fs.StringVar(&configPath, "config", "", "Configuration file path (YAML)")
+	fs.StringVar(&configPath, "c", "", "Configuration file path (YAML)")
+	fs.BoolVar(&watch, "watch", false, "Redraw the table until interrupted")
+	fs.DurationVar(&interval, "interval", 2*time.Second, "Refresh interval for --watch")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		log.Printf("Failed to parse arguments: %v", err)
+		return 1
+	}
+	if configPath == "" {
+		fs.Usage()
+		return 2
+	}
+	if interval <= 0 {
+		log.Printf("--interval must be > 0")
+		return 2
+	}
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		log.Printf("Failed to load config: %v", err)
+		return 1
+	}
+	store := state.NewStore(cfg.StatusFilePath())
+
+	if !watch {
+		snap, err := store.Load()
+		if err != nil {
+			log.Printf("Failed to read status file: %v", err)
+			return 1
+		}
+		printStats(os.Stdout, snap)
+		return 0
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		snap, err := store.Load()
+		// Clear the screen and move the cursor home before redrawing
+		fmt.Print("\033[H\033[2J")
+		if err != nil {
+			fmt.Printf("Failed to read status file %s: %v\n", cfg.StatusFilePath(), err)
+		} else {
+			printStats(os.Stdout, snap)
+		}
+		fmt.Printf("\nRefreshing every %v, Ctrl+C to exit\n", interval)
+
+		select {
+		case <-sigCh:
+			return 0
+		case <-ticker.C:
+		}
+	}
+}
+
+// printStats renders per-FLOW progress and aggregate replay counters from a status snapshot.
+func printStats(w io.Writer, snap state.Snapshot) {
+	m := snap.Metrics
+	metric := func(name string) float64 { return m[name] }
+
+	age := "-"
+	if !snap.UpdatedAt.IsZero() {
+		age = time.Since(snap.UpdatedAt).Truncate(time.Second).String() + " ago"
+	}
+	fmt.Fprintf(w, "Pipeline: %s (updated %s)\n", snap.PipelineStatus, age)
+
+	// FLOW ids are only discoverable through their metric names
+	seen := make(map[int]bool)
+	var flows []int
+	for name := range m {
+		var id int
+		var suffix string
+		if n, _ := fmt.Sscanf(name, "flow.%d.%s", &id, &suffix); n == 2 && !seen[id] &&
+			(suffix == "imported_keys" || suffix == "lsn" || suffix == "lsn.received") {
+			seen[id] = true
+			flows = append(flows, id)
+		}
+	}
+	sort.Ints(flows)
+
+	// A FLOW's lag is how far its applied position trails what it received
+	lags := make(map[int]float64, len(flows))
+	var totalLag float64
+	for _, id := range flows {
+		received, ok := m[fmt.Sprintf(state.MetricFlowReceivedLSNFormat, id)]
+		if !ok {
+			continue
+		}
+		lag := received - m[fmt.Sprintf(state.MetricFlowAppliedLSNFormat, id)]
+		if lag < 0 {
+			lag = 0
+		}
+		lags[id] = lag
+		totalLag += lag
+	}
+
+	fmt.Fprintf(w, "Lag: %.0f entries   LSN applied: %.0f   QPS: %.0f (peak %.0f)   Latency p99: %.2f ms\n\n",
+		totalLag, metric(state.MetricIncrementalLSNApplied),
+		metric(state.MetricQPSCurrent), metric(state.MetricQPSPeak), metric(state.MetricLatencyP99))
+
+	fmt.Fprintf(w, "%-8s %15s %10s %15s %13s %18s\n", "FLOW", "LSN", "LAG", "IMPORTED KEYS", "QUEUE", "BLOCKED (ms)")
+	for _, id := range flows {
+		lsn := "-"
+		if v, ok := m[fmt.Sprintf(state.MetricFlowLSNFormat, id)]; ok {
+			lsn = strconv.FormatFloat(v, 'f', 0, 64)
+		}
+		lag := "-"
+		if v, ok := lags[id]; ok {
+			lag = strconv.FormatFloat(v, 'f', 0, 64)
+		}
+		queue := fmt.Sprintf("%.0f/%.0f", m[fmt.Sprintf(state.MetricFlowQueueLenFormat, id)], m[fmt.Sprintf(state.MetricFlowQueueCapFormat, id)])
+		blocked := fmt.Sprintf("%.0f (%.0f)", m[fmt.Sprintf(state.MetricFlowEnqueueBlockedFormat, id)], m[fmt.Sprintf(state.MetricFlowEnqueueBlockedMsFormat, id)])
+		fmt.Fprintf(w, "%-8s %15s %10s %15.0f %13s %18s\n", fmt.Sprintf("FLOW-%d", id), lsn, lag,
+			m[fmt.Sprintf(state.MetricFlowImportedFormat, id)], queue, blocked)
+	}
+	if len(flows) == 0 {
+		fmt.Fprintln(w, "(no FLOW metrics yet)")
+	}
+
+	fmt.Fprintf(w, "\nSnapshot: %.0f / %.0f keys synced\n",
+		metric(state.MetricSyncedKeys), metric(state.MetricSourceKeysEstimated))
+	fmt.Fprintf(w, "Replay:   total=%.0f success=%.0f skipped=%.0f blocked=%.0f duplicate=%.0f failed=%.0f\n",
+		metric(state.MetricIncrementalOpsTotal), metric(state.MetricIncrementalOpsSuccess),
+		metric(state.MetricIncrementalOpsSkipped), metric(state.MetricIncrementalOpsBlocked),
+		metric(state.MetricIncrementalOpsDuplicate), metric(state.MetricIncrementalOpsFailed))
+}
+
 func runRollback(args []string) int {
-	cfg, err := loadConfigFromArgs("rollback", args)
+	fs := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var (
+		configPath  string
+		flushTarget bool
+		match       string
+		stopTimeout int
+	)
+	fs.StringVar(&configPath, "config", "", "Configuration file path (YAML)")
+	fs.StringVar(&configPath, "c", "", "Configuration file path (YAML)")
+	fs.BoolVar(&flushTarget, "flush-target", false, "Delete migrated keys from the Redis target (requires --match)")
+	fs.StringVar(&match, "match", "", "Key pattern scoping --flush-target (e.g. 'user:*', '*' for all keys)")
+	fs.IntVar(&stopTimeout, "stop-timeout", 60, "Seconds to wait for a running replicator to stop")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		log.Printf("Failed to parse arguments: %v", err)
+		return 1
+	}
+	if configPath == "" {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load(configPath)
 	if err != nil {
 		return errorToExitCode(err)
 	}
+	if err := cfg.EnsureStateDir(); err != nil {
+		log.Printf("Failed to create state directory: %v", err)
+		return 1
+	}
 	store := state.NewStore(cfg.StatusFilePath())
-	if err := store.SetPipelineStatus("rolling_back", "Starting rollback process"); err != nil {
-		log.Printf("Failed to update status: %v", err)
+
+	marker, err := rollback.Run(context.Background(), cfg, store, rollback.Options{
+		FlushTarget: flushTarget,
+		Match:       match,
+		StopTimeout: time.Duration(stopTimeout) * time.Second,
+	})
+	if err != nil {
+		log.Printf("❌ Rollback failed: %v", err)
+		return 1
+	}
+	log.Printf("↩️ Rollback complete: source=%s role=%s replicatorStopped=%v targetFlushed=%v deletedKeys=%d",
+		marker.SourceAddr, marker.SourceRole, marker.ReplicatorStop, marker.TargetFlushed, marker.DeletedKeys)
+	log.Printf("   Marker written to %s", cfg.RollbackMarkerPath())
+	return 0
+}
+
+// runReplayFile applies a journal stream captured with --capture-journal
+// (observability.captureJournal) to the configured target. The source in the
+// config is never contacted.
+func runReplayFile(args []string) int {
+	fs := flag.NewFlagSet("replay-file", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var (
+		configPath  string
+		file        string
+		flowID      int
+		traceWrites string
+	)
+	fs.StringVar(&configPath, "config", "", "Configuration file path (YAML); its target receives the commands")
+	fs.StringVar(&configPath, "c", "", "Configuration file path (YAML); its target receives the commands")
+	fs.StringVar(&file, "file", "", "Captured journal of one FLOW, e.g. journal.bin.flow-0")
+	fs.StringVar(&file, "f", "", "Captured journal of one FLOW, e.g. journal.bin.flow-0")
+	fs.IntVar(&flowID, "flow", 0, "FLOW id to label the replayed entries with")
+	fs.StringVar(&traceWrites, "trace-writes", "", "Log every command sent to the target to this file (values redacted)")
+	tlsOpts := addTLSFlags(fs)
+	noEmoji := addPlainFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		log.Printf("Failed to parse arguments: %v", err)
+		return 1
+	}
+	if configPath == "" || file == "" {
+		fs.Usage()
+		return 2
+	}
+	if flowID < 0 {
+		log.Printf("Invalid --flow: must be >= 0")
+		return 2
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return errorToExitCode(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return errorToExitCode(err)
+	}
+	if traceWrites != "" {
+		cfg.Observability.TraceWrites = traceWrites
+	}
+	tlsOpts.apply(cfg)
+	applyPlainLogs(cfg, *noEmoji)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := replica.ReplayJournalFile(ctx, cfg, file, flowID); err != nil {
+		log.Printf("❌ Journal replay failed: %v", err)
+		return 1
+	}
+	log.Printf("✅ Journal file %s replayed", file)
+	return 0
+}
+
+func runCutover(args []string) int {
+	fs := flag.NewFlagSet("cutover", flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	var (
+		configPath   string
+		pauseWrites  bool
+		pauseTimeout int
+		drainTimeout int
+		sample       int
+	)
+	fs.StringVar(&configPath, "config", "", "Configuration file path (YAML)")
+	fs.StringVar(&configPath, "c", "", "Configuration file path (YAML)")
+	fs.BoolVar(&pauseWrites, "pause-writes", false, "Block writes on the Dragonfly source (CLIENT PAUSE WRITE) before draining")
+	fs.IntVar(&pauseTimeout, "pause-timeout", 300, "Seconds the source stays paused with --pause-writes")
+	fs.IntVar(&drainTimeout, "drain-timeout", 120, "Seconds to wait for replication lag to reach zero")
+	fs.IntVar(&sample, "sample", 1000, "Keys compared by the final outline check (0 = skip)")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		log.Printf("Failed to parse arguments: %v", err)
+		return 1
+	}
+	if configPath == "" {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return errorToExitCode(err)
+	}
+	if err := cfg.EnsureStateDir(); err != nil {
+		log.Printf("Failed to create state directory: %v", err)
 		return 1
 	}
-	if err := store.SetPipelineStatus("rolled_back", "Rollback marked; awaiting manual cutover"); err != nil {
-		log.Printf("Failed to update status: %v", err)
+	store := state.NewStore(cfg.StatusFilePath())
+
+	marker, err := cutover.Run(context.Background(), cfg, store, cutover.Options{
+		PauseWrites:  pauseWrites,
+		PauseTimeout: time.Duration(pauseTimeout) * time.Second,
+		DrainTimeout: time.Duration(drainTimeout) * time.Second,
+		SampleKeys:   sample,
+	})
+	if err != nil {
+		log.Printf("❌ Cutover preconditions failed: %v", err)
 		return 1
 	}
-	log.Printf("↩️ Rollback marker written, stateDir=%s", cfg.ResolveStateDir())
+	log.Printf("✅ Cutover ready: source=%s target=%s writesPaused=%v sampledKeys=%d",
+		marker.SourceAddr, marker.TargetAddr, marker.WritesPaused, marker.SampledKeys)
+	if marker.WritesPaused {
+		log.Printf("   Switch clients to the target before %s, when source writes resume", marker.PausedUntil.Format(time.RFC3339))
+	}
+	log.Printf("   Marker written to %s", cfg.CutoverMarkerPath())
 	return 0
 }
 
+// watchStopRequest polls for the stop request marker written by rollback.
+// A stale marker from a previous run is removed before watching starts.
+// Polling stops when ctx is done.
+func watchStopRequest(ctx context.Context, path string) <-chan struct{} {
+	_ = os.Remove(path)
+	ch := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := os.Stat(path); err == nil {
+					close(ch)
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
 func runDashboard(args []string) int {
 	fs := flag.NewFlagSet("dashboard", flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
@@ -451,6 +1267,24 @@ func runReplicate(args []string) int {
 	fs.StringVar(&configPath, "c", "", "Configuration file path (YAML)")
 	fs.StringVar(&dashboardAddr, "dashboard-addr", "", "Embedded dashboard listen address (empty to use config, set to empty string to disable)")
 	fs.StringVar(&taskNameFlag, "task-name", "", "Task name (used for log prefix; overrides config file)")
+	var lsnSpec string
+	fs.StringVar(&lsnSpec, "lsn", "", "Expert: force the start LSN per FLOW for partial sync, e.g. flow0=123,flow1=456 (or @file, which may be a checkpoint JSON)")
+	var traceWrites string
+	fs.StringVar(&traceWrites, "trace-writes", "", "Log every command sent to the target to this file (values redacted)")
+	var captureJournal string
+	fs.StringVar(&captureJournal, "capture-journal", "", "Copy each FLOW's raw journal stream to <path>.flow-<n> for replay-file")
+	var flowCount int
+	fs.IntVar(&flowCount, "flows", 0, "Debug: open only the first N FLOW connections (remaining shards are not replicated)")
+	var verifyAfter string
+	fs.StringVar(&verifyAfter, "verify-after", "", "With migrate.snapshotOnly: run the check in this mode (full/length/outline/smart/exists) after the copy; exit 1 on inconsistencies")
+	var onlyTypes string
+	fs.StringVar(&onlyTypes, "only-types", "", "Only copy these data types in the full sync, e.g. string,hash (overrides migrate.includeTypes)")
+	var checkpointInterval time.Duration
+	fs.DurationVar(&checkpointInterval, "checkpoint-interval", 0, "Save the checkpoint this often, whole seconds, e.g. 5s (overrides checkpoint.intervalSeconds)")
+	var checkpointEntries int
+	fs.IntVar(&checkpointEntries, "checkpoint-entries", 0, "Also save the checkpoint every N applied journal entries (overrides checkpoint.everyEntries)")
+	tlsOpts := addTLSFlags(fs)
+	noEmoji := addPlainFlag(fs)
 
 	if err := fs.Parse(args); err != nil {
 		if err == flag.ErrHelp {
@@ -463,17 +1297,71 @@ func runReplicate(args []string) int {
 		fs.Usage()
 		return 2
 	}
+	if flowCount < 0 {
+		log.Printf("Invalid --flows: must be >= 0 (0 = all FLOWs)")
+		return 2
+	}
+	if checkpointInterval < 0 || checkpointInterval%time.Second != 0 {
+		// checkpoint.intervalSeconds has whole-second resolution; 1500ms would silently become 1s
+		log.Printf("Invalid --checkpoint-interval: must be a whole number of seconds, e.g. 5s")
+		return 2
+	}
+	if checkpointEntries < 0 {
+		log.Printf("Invalid --checkpoint-entries: must be >= 0")
+		return 2
+	}
+	if _, ok := parseCheckMode(verifyAfter); verifyAfter != "" && !ok {
+		log.Printf("Unknown --verify-after mode: %s", verifyAfter)
+		return 2
+	}
 
 	cfg, err := config.Load(configPath)
 	if err != nil {
 		return errorToExitCode(err)
 	}
+	if onlyTypes != "" {
+		cfg.Migrate.IncludeTypes = splitCommaList(onlyTypes)
+	}
+	if checkpointInterval > 0 {
+		cfg.Checkpoint.Interval = int(checkpointInterval / time.Second)
+	}
+	if checkpointEntries > 0 {
+		cfg.Checkpoint.Entries = checkpointEntries
+	}
 	if err := cfg.Validate(); err != nil {
 		return errorToExitCode(err)
 	}
+	if len(cfg.Migrate.IncludeTypes) > 0 && !cfg.Migrate.SnapshotOnly {
+		log.Printf("⚠️  migrate.includeTypes only filters the full sync; journal commands are replayed for every type")
+	}
 	if taskNameFlag != "" {
 		cfg.TaskName = taskNameFlag
 	}
+	if traceWrites != "" {
+		cfg.Observability.TraceWrites = traceWrites
+	}
+	if captureJournal != "" {
+		cfg.Observability.CaptureJournal = captureJournal
+	}
+	tlsOpts.apply(cfg)
+	applyPlainLogs(cfg, *noEmoji)
+	if verifyAfter != "" && !cfg.Migrate.SnapshotOnly {
+		log.Printf("--verify-after needs migrate.snapshotOnly: continuous replication never finishes on its own")
+		return 2
+	}
+	if flowCount > 0 {
+		cfg.Source.FlowOverride = flowCount
+		log.Printf("⚠️  Limiting replication to %d FLOW(s) (debugging only)", flowCount)
+	}
+	if lsnSpec != "" {
+		lsns, err := parseStartLSNs(lsnSpec)
+		if err != nil {
+			log.Printf("Invalid --lsn: %v", err)
+			return 2
+		}
+		cfg.Checkpoint.StartLSNs = lsns
+		log.Printf("⚠️  Forcing start LSNs %v (checkpoint ignored)", lsns)
+	}
 	if dashboardAddr == "" {
 		dashboardAddr = cfg.Dashboard.Addr
 	}
@@ -493,10 +1381,6 @@ func runReplicate(args []string) int {
 	logger.Console("📝 Log level: %s", cfg.Log.Level)
 	logger.Console("📄 Log file: %s", logger.GetLogFilePath())
 
-	// Build replicator
-	replicator := replica.NewReplicator(cfg)
-	replicator.AttachStateStore(store)
-
 	if dashboardAddr != "" {
 		server, err := web.New(web.Options{
 			Addr:  dashboardAddr,
@@ -531,39 +1415,135 @@ func runReplicate(args []string) int {
 		}
 	}
 
-	// Configure signal handling
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	ctx, cancel := shutdownContext(cfg)
+	defer cancel()
 
-	// Start replicator
-	errCh := make(chan error, 1)
-	go func() {
-		if err := replicator.Start(); err != nil {
-			errCh <- err
-			return
-		}
-		// Replicator exited normally (SnapshotOnly mode or graceful shutdown via Ctrl+C)
+	err = replica.Run(ctx, cfg, store)
+	switch {
+	case err == nil && verifyAfter != "" && ctx.Err() == nil:
+		return runVerifyAfter(ctx, cfg, checker.CheckMode(verifyAfter))
+	case err == nil:
 		logger.Console("\n⌨️  Replicator stopped")
-	}()
-
-	// Wait for error or signal
-	select {
-	case err := <-errCh:
-		// CRITICAL: Call Stop() to gracefully close connections before exiting
-		// This prevents triggering Dragonfly v1.36.0 cleanup bug (see docs/zh/Dragonfly-v1.36.0-Bug-Workaround.md)
-		// Without graceful shutdown, connections close abruptly (TCP RST) which can crash Dragonfly
+		return 0
+	case errors.Is(err, context.Canceled):
+		return 0
+	default:
+		// replica.Run already closed connections gracefully (FIN instead of RST) to avoid
+		// triggering the Dragonfly v1.36.0 cleanup bug (see docs/zh/Dragonfly-v1.36.0-Bug-Workaround.md)
 		logger.Error("❌ Replication failed: %v", err)
-		logger.Console("\n🔌 Gracefully closing connections to avoid crashing Dragonfly...")
-		replicator.Stop() // Send FIN instead of RST
 		logger.Console("\n📄 Check logs for details: %s", logger.GetLogFilePath())
 		return 1
-	case sig := <-sigCh:
-		logger.Console("\n📡 Signal %v received, shutting down...", sig)
-		replicator.Stop()
-		return 0
 	}
 }
 
+// splitCommaList splits "a, b,c" into its non-empty, trimmed items.
+func splitCommaList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// tlsFlags are the TLS overrides shared by the commands that connect to
+// the source and target.
+type tlsFlags struct {
+	caFile   string
+	insecure bool
+}
+
+func addTLSFlags(fs *flag.FlagSet) *tlsFlags {
+	t := &tlsFlags{}
+	fs.StringVar(&t.caFile, "tls-ca", "", "PEM CA bundle to verify source/target certificates (overrides tlsCaFile)")
+	fs.BoolVar(&t.insecure, "tls-insecure", false, "Skip TLS certificate verification (self-signed certs)")
+	return t
+}
+
+// apply copies the flags into both endpoints; TLS itself is still enabled
+// per endpoint with tls: true.
+func (t *tlsFlags) apply(cfg *config.Config) {
+	if t.caFile != "" {
+		cfg.Source.TLSCAFile = t.caFile
+		cfg.Target.TLSCAFile = t.caFile
+	}
+	if t.insecure {
+		cfg.Source.TLSInsecure = true
+		cfg.Target.TLSInsecure = true
+	}
+}
+
+// addPlainFlag registers --no-emoji (alias --no-color) for ASCII-only logs.
+func addPlainFlag(fs *flag.FlagSet) *bool {
+	on := new(bool)
+	fs.BoolVar(on, "no-emoji", false, "Plain ASCII logs: strip emoji and box-drawing characters (overrides log.plain)")
+	fs.BoolVar(on, "no-color", false, "Alias for --no-emoji")
+	return on
+}
+
+// applyPlainLogs switches the logger (and the standard log output) to plain
+// ASCII when log.plain or --no-emoji is set.
+func applyPlainLogs(cfg *config.Config, noEmoji bool) {
+	if noEmoji {
+		cfg.Log.Plain = true
+	}
+	logger.SetPlain(cfg.Log.Plain)
+	if cfg.Log.Plain {
+		log.SetOutput(logger.PlainWriter(log.Writer()))
+	}
+}
+
+// parseStartLSNs parses "flow0=123,flow1=456" (the "flow" prefix is optional).
+// A leading "@" reads the same format from a file, one or more entries per line.
+func parseStartLSNs(spec string) (map[int]uint64, error) {
+	if strings.HasPrefix(spec, "@") {
+		data, err := os.ReadFile(spec[1:])
+		if err != nil {
+			return nil, err
+		}
+		// A checkpoint file (or per-FLOW manifest) resumes each FLOW from its durable LSN
+		if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+			cp, err := checkpoint.NewManager(spec[1:]).Load()
+			if err != nil {
+				return nil, err
+			}
+			if cp == nil || len(cp.FlowLSNs) == 0 {
+				return nil, fmt.Errorf("checkpoint %s has no FLOW LSNs", spec[1:])
+			}
+			return cp.FlowLSNs, nil
+		}
+		spec = string(data)
+	}
+
+	lsns := make(map[int]uint64)
+	fields := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' ' || r == '\t' || r == '\r'
+	})
+	for _, field := range fields {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, fmt.Errorf("entry %q is not flowN=LSN", field)
+		}
+		flowID, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(name), "flow"))
+		if err != nil || flowID < 0 {
+			return nil, fmt.Errorf("entry %q has an invalid FLOW id", field)
+		}
+		lsn, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("entry %q has an invalid LSN", field)
+		}
+		if _, dup := lsns[flowID]; dup {
+			return nil, fmt.Errorf("FLOW %d listed twice", flowID)
+		}
+		lsns[flowID] = lsn
+	}
+	if len(lsns) == 0 {
+		return nil, fmt.Errorf("no FLOW LSNs given")
+	}
+	return lsns, nil
+}
+
 func runCheck(args []string) int {
 	fs := flag.NewFlagSet("check", flag.ContinueOnError)
 	fs.SetOutput(os.Stdout)
@@ -581,12 +1561,19 @@ func runCheck(args []string) int {
 		logFile         string
 		logLevel        string
 		maxKeys         int
+		scanCount       int
+		keyType         string
+		outputFile      string
+		excludes        []string
+		resume          bool
+		countOnly       bool
+		checkEncoding   bool
 	)
 	fs.StringVar(&configPath, "config", "", "Configuration file path (YAML)")
 	fs.StringVar(&configPath, "c", "", "Configuration file path (YAML)")
-	fs.StringVar(&mode, "mode", "outline", "Validation mode: full/length/outline/smart")
-	fs.IntVar(&qps, "qps", 500, "QPS limit")
-	fs.IntVar(&parallel, "parallel", 4, "Parallelism")
+	fs.StringVar(&mode, "mode", "outline", "Validation mode: full/length/outline/smart/exists")
+	fs.IntVar(&qps, "qps", 500, "Keys compared per second across all workers (0 = unlimited)")
+	fs.IntVar(&parallel, "parallel", 4, "Comparison workers, each with its own source and target connection")
 	fs.StringVar(&resultDir, "result-dir", "./check-results", "Result output directory")
 
 	fs.StringVar(&filterList, "filter", "", "Key filter list with prefix matching (e.g. 'user:*|session:*')")
@@ -596,6 +1583,22 @@ func runCheck(args []string) int {
 	fs.StringVar(&logFile, "log-file", "", "Log file path")
 	fs.StringVar(&logLevel, "log-level", "info", "Log level: debug/info/warn/error")
 	fs.IntVar(&maxKeys, "max-keys", 0, "Maximum keys to validate (0 = unlimited)")
+	fs.IntVar(&scanCount, "scan-count", 0, "SCAN COUNT hint for the source scan (0 = batch size)")
+	fs.StringVar(&outputFile, "output", "", "Write a JSON summary of the result to this file (for CI)")
+	fs.StringVar(&keyType, "type", "", "Only validate keys of this type: string/list/set/zset/hash/stream (SCAN TYPE, Redis 6.2+)")
+	fs.BoolVar(&resume, "resume-check", false, "Continue an interrupted check from the progress saved in --result-dir")
+	fs.BoolVar(&countOnly, "count-only", false, "Only compare key counts (DBSIZE, or SCAN when --filter/--type/--exclude is set); exit 1 on mismatch")
+	fs.BoolVar(&checkEncoding, "check-encoding", false, "Also compare OBJECT ENCODING per key (e.g. intset vs hashtable); reported separately, exit 1 on mismatch")
+	tlsOpts := addTLSFlags(fs)
+	noEmoji := addPlainFlag(fs)
+	fs.Func("exclude", "Skip keys matching these glob patterns (e.g. 'heartbeat:*|lock:*'); repeatable", func(v string) error {
+		for _, p := range strings.Split(v, "|") {
+			if p = strings.TrimSpace(p); p != "" {
+				excludes = append(excludes, p)
+			}
+		}
+		return nil
+	})
 
 	if err := fs.Parse(args); err != nil {
 		if err == flag.ErrHelp {
@@ -615,47 +1618,73 @@ func runCheck(args []string) int {
 		log.Printf("Failed to load config: %v", err)
 		return 2
 	}
+	tlsOpts.apply(cfg)
+	applyPlainLogs(cfg, *noEmoji)
 
 	// Build checker configuration
-	checkerMode := checker.ModeKeyOutline
-	switch mode {
-	case "full":
-		checkerMode = checker.ModeFullValue
-	case "length":
-		checkerMode = checker.ModeValueLength
-	case "outline":
-		checkerMode = checker.ModeKeyOutline
-	case "smart":
-		checkerMode = checker.ModeSmartBigKey
-	default:
+	checkerMode, ok := parseCheckMode(mode)
+	if !ok {
 		log.Printf("Unknown validation mode: %s", mode)
 		return 2
 	}
 
-	checkerCfg := checker.Config{
-		SourceAddr:      cfg.Source.Addr,
-		SourcePassword:  cfg.Source.Password,
-		TargetAddr:      cfg.Target.Addr,
-		TargetPassword:  cfg.Target.Password,
-		Mode:            checkerMode,
-		QPS:             qps,
-		Parallel:        parallel,
-		ResultDir:       resultDir,
-		FilterList:      filterList,
-		CompareTimes:    compareTimes,
-		Interval:        interval,
-		BigKeyThreshold: bigKeyThreshold,
-		LogFile:         logFile,
-		LogLevel:        logLevel,
-		MaxKeys:         maxKeys,
-		TaskName:        cfg.TaskName,
+	if checkEncoding && checkerMode == checker.ModeKeyExists {
+		log.Printf("--check-encoding reads the source and can't be combined with --mode exists")
+		return 2
+	}
+
+	keyType = strings.ToLower(strings.TrimSpace(keyType))
+	switch keyType {
+	case "", "string", "list", "set", "zset", "hash", "stream":
+	default:
+		log.Printf("Unknown key type: %s", keyType)
+		return 2
 	}
 
+	checkerCfg := checkerEndpoints(cfg)
+	checkerCfg.Mode = checkerMode
+	checkerCfg.QPS = qps
+	checkerCfg.Parallel = parallel
+	checkerCfg.ResultDir = resultDir
+	checkerCfg.FilterList = filterList
+	checkerCfg.CompareTimes = compareTimes
+	checkerCfg.Interval = interval
+	checkerCfg.BigKeyThreshold = bigKeyThreshold
+	checkerCfg.LogFile = logFile
+	checkerCfg.LogLevel = logLevel
+	checkerCfg.MaxKeys = maxKeys
+	checkerCfg.TaskName = cfg.TaskName
+	checkerCfg.ScanCount = scanCount
+	checkerCfg.ScanType = keyType
+	checkerCfg.ExcludePatterns = excludes
+	checkerCfg.Resume = resume
+	checkerCfg.CheckEncoding = checkEncoding
+
 	// Instantiate checker
 	c := checker.NewChecker(checkerCfg)
 
-	// Run comparison
-	ctx := context.Background()
+	// Run comparison; Ctrl+C stops the scan and saves progress for --resume-check
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if countOnly {
+		counts, err := c.CountKeys(ctx)
+		if err != nil {
+			log.Printf("Key count failed: %v", err)
+			return 1
+		}
+		method := "DBSIZE"
+		if counts.Scanned {
+			method = "SCAN (filtered)"
+		}
+		fmt.Printf("\n📊 Key count via %s: source=%d target=%d (%v)\n", method, counts.SourceKeys, counts.TargetKeys, counts.Duration.Truncate(time.Millisecond))
+		if !counts.Match() {
+			fmt.Printf("❌ Key counts differ by %d\n", counts.SourceKeys-counts.TargetKeys)
+			return 1
+		}
+		fmt.Println("✅ Key counts match")
+		return 0
+	}
 	result, err := c.Run(ctx, nil)
 	if err != nil {
 		log.Printf("Validation failed: %v", err)
@@ -665,8 +1694,16 @@ func runCheck(args []string) int {
 	// Print summary
 	c.PrintResult(result)
 
+	if outputFile != "" {
+		if err := c.WriteSummary(outputFile, result); err != nil {
+			log.Printf("Failed to write JSON summary: %v", err)
+			return 1
+		}
+		log.Printf("📝 JSON summary written to %s", outputFile)
+	}
+
 	// Non-zero exit code on inconsistency
-	if result.InconsistentKeys > 0 {
+	if result.InconsistentKeys > 0 || result.EncodingMismatches > 0 {
 		return 1
 	}
 
@@ -686,15 +1723,28 @@ Available commands:
   replicate  Start the Dragonfly replicator (handshake test)
   check      Validate data consistency (redis-full-check)
   status     Show current migration status
-  rollback   Trigger rollback back to Dragonfly
+  stats      Print replication metrics from the status file (--watch to refresh)
+  rollback   Stop replication and roll back to Dragonfly (verifies source is primary)
+  cutover    Verify zero lag, optionally pause source writes, run a final check and record the switch
   dashboard  Launch standalone dashboard
+  inspect-rdb Parse a local RDB file and report types, sizes and parse errors
+  validate-config Print the effective config (defaults, resolved paths, redacted secrets) and validate it
+  replay-file Apply a journal captured with --capture-journal to the target, without a source
+  topology   Print the target masters and slot map df2redis routes by, as JSON
   help       Show this help
   version    Show version info
 
 Examples:
   %[1]s migrate --config examples/migrate.sample.yaml --dry-run
   %[1]s replicate --config examples/migrate.sample.yaml
+  %[1]s migrate --config examples/migrate.sample.yaml --only-types string,hash
   %[1]s check --config examples/migrate.sample.yaml --mode outline
+  %[1]s stats --config examples/replicate.sample.yaml --watch
+  %[1]s inspect-rdb --file dump.rdb --top 20
+  %[1]s inspect-rdb --file dump.rdb --slot-histogram --target 10.0.0.1:7000
+  %[1]s validate-config --config examples/migrate.sample.yaml
+  %[1]s topology --config examples/migrate.sample.yaml
+  %[1]s replay-file --config examples/replicate.sample.yaml --file logs/journal.bin.flow-0 --flow 0
 `, binary)
 }
 
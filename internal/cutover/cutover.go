@@ -0,0 +1,306 @@
+package cutover
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"df2redis/internal/checker"
+	"df2redis/internal/config"
+	"df2redis/internal/redisx"
+	"df2redis/internal/state"
+)
+
+// Options controls the cutover preconditions.
+type Options struct {
+	// PauseWrites blocks writes on the Dragonfly source (CLIENT PAUSE ... WRITE)
+	// before draining, so the lag can actually reach zero.
+	PauseWrites bool
+	// PauseTimeout is how long the source stays paused; clients must be
+	// switched to the target within this window.
+	PauseTimeout time.Duration
+	// DrainTimeout bounds how long we wait for replication lag to reach zero.
+	DrainTimeout time.Duration
+	// SampleKeys is the number of keys compared by the final check (0 = skip).
+	SampleKeys int
+}
+
+// Marker is persisted to stateDir/cutover.json as the audit record of a cutover.
+type Marker struct {
+	CutoverAt        time.Time          `json:"cutoverAt"`
+	SourceAddr       string             `json:"sourceAddr"`
+	TargetAddr       string             `json:"targetAddr"`
+	PreviousStatus   string             `json:"previousStatus"`
+	WritesPaused     bool               `json:"writesPaused"`
+	PausedUntil      time.Time          `json:"pausedUntil,omitempty"`
+	ReplicaLag       int64              `json:"replicaLag"`
+	FlowLSNs         map[string]float64 `json:"flowLSNs,omitempty"`
+	SampledKeys      int64              `json:"sampledKeys"`
+	InconsistentKeys int64              `json:"inconsistentKeys"`
+}
+
+// statusFreshness is how recent the status file must be for the replicator
+// to count as running.
+const statusFreshness = 30 * time.Second
+
+// Run checks that the replicator is caught up, optionally pauses writes on
+// the source, runs a sampled consistency check and records the cutover.
+// On failure after pausing, the source is unpaused again.
+func Run(ctx context.Context, cfg *config.Config, store *state.Store, opts Options) (marker *Marker, err error) {
+	if opts.DrainTimeout <= 0 {
+		opts.DrainTimeout = 2 * time.Minute
+	}
+	if opts.PauseTimeout <= 0 {
+		opts.PauseTimeout = 5 * time.Minute
+	}
+
+	// 1. A replicator must be streaming the journal right now.
+	snap, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("read status file: %w", err)
+	}
+	if snap.PipelineStatus != "incremental" {
+		return nil, fmt.Errorf("cutover requires a replicator in incremental sync (status=%s)", snap.PipelineStatus)
+	}
+	if age := time.Since(snap.UpdatedAt); age > statusFreshness {
+		return nil, fmt.Errorf("status file was last updated %v ago; is the replicator still running?", age.Truncate(time.Second))
+	}
+	marker = &Marker{
+		SourceAddr:     cfg.Source.Addr,
+		TargetAddr:     cfg.Target.Addr,
+		PreviousStatus: snap.PipelineStatus,
+	}
+
+	source, err := redisx.Dial(ctx, redisx.Config{
+		Addr:        cfg.Source.Addr,
+		Password:    cfg.Source.Password,
+		TLS:         cfg.Source.TLS,
+		TLSCAFile:   cfg.Source.TLSCAFile,
+		TLSInsecure: cfg.Source.TLSInsecure,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("connect to source %s: %w", cfg.Source.Addr, err)
+	}
+	defer source.Close()
+
+	// 2. Stop writes on the source.
+	if opts.PauseWrites {
+		ms := opts.PauseTimeout.Milliseconds()
+		if _, err := source.Do("CLIENT", "PAUSE", ms, "WRITE"); err != nil {
+			return nil, fmt.Errorf("pause writes on source: %w", err)
+		}
+		marker.WritesPaused = true
+		marker.PausedUntil = time.Now().Add(opts.PauseTimeout)
+		log.Printf("✓ Source writes paused for %v", opts.PauseTimeout)
+		defer func() {
+			if err != nil {
+				if _, uerr := source.Do("CLIENT", "UNPAUSE"); uerr != nil {
+					log.Printf("⚠ Failed to unpause source writes: %v (pause expires at %s)", uerr, marker.PausedUntil.Format(time.RFC3339))
+				} else {
+					log.Printf("↩️ Source writes unpaused")
+				}
+			}
+		}()
+	}
+
+	// 3. Wait until the replicator has applied everything the source has sent.
+	lag, err := drainLag(ctx, source, store, opts.DrainTimeout)
+	if err != nil {
+		return nil, err
+	}
+	marker.ReplicaLag = lag
+	if snap, err := store.Load(); err == nil {
+		marker.FlowLSNs = flowLSNs(snap.Metrics)
+	}
+	log.Printf("✓ Replication lag is zero")
+
+	// 4. Final sampled consistency check.
+	if opts.SampleKeys > 0 {
+		log.Printf("→ Running final outline check on %d keys...", opts.SampleKeys)
+		c := checker.NewChecker(checker.Config{
+			SourceAddr:        cfg.Source.Addr,
+			SourcePassword:    cfg.Source.Password,
+			TargetAddr:        cfg.Target.Addr,
+			TargetPassword:    cfg.Target.Password,
+			SourceTLS:         cfg.Source.TLS,
+			SourceTLSCAFile:   cfg.Source.TLSCAFile,
+			SourceTLSInsecure: cfg.Source.TLSInsecure,
+			TargetTLS:         cfg.Target.TLS,
+			TargetTLSCAFile:   cfg.Target.TLSCAFile,
+			TargetTLSInsecure: cfg.Target.TLSInsecure,
+			Mode:              checker.ModeKeyOutline,
+			ResultDir:         filepath.Join(cfg.ResolveStateDir(), "cutover-check"),
+			MaxKeys:           opts.SampleKeys,
+			TaskName:          "cutover",
+		})
+		result, err := c.Run(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("final check: %w", err)
+		}
+		marker.SampledKeys = result.TotalKeys
+		marker.InconsistentKeys = result.InconsistentKeys
+		if result.InconsistentKeys > 0 {
+			return nil, fmt.Errorf("final check found %d inconsistent keys out of %d sampled", result.InconsistentKeys, result.TotalKeys)
+		}
+	}
+
+	// 5. Record the cutover.
+	marker.CutoverAt = time.Now()
+	if err := writeMarker(cfg.CutoverMarkerPath(), marker); err != nil {
+		return nil, err
+	}
+	msg := fmt.Sprintf("Cutover ready: lag=0, %d keys sampled", marker.SampledKeys)
+	if err := store.UpdateStage("cutover", "completed", msg); err != nil {
+		return nil, fmt.Errorf("update status: %w", err)
+	}
+	return marker, nil
+}
+
+// drainPollInterval is how often drainLag re-checks the source and the status file.
+var drainPollInterval = time.Second
+
+// drainLag polls until the source reports zero lag for its replicas and the
+// replicator has written everything it read: every FLOW's applied position
+// matches its received one and neither the retry queue nor the reorder buffer
+// holds entries. Only status snapshots written after the source reached zero
+// lag count, so metrics flushed before the last entries arrived can't pass.
+func drainLag(ctx context.Context, source *redisx.Client, store *state.Store, timeout time.Duration) (int64, error) {
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	var drainedAt time.Time // when the source last started reporting zero lag
+	for {
+		lag, err := replicaLag(source)
+		if err != nil {
+			return 0, err
+		}
+		pending := ""
+		if lag != 0 {
+			drainedAt = time.Time{}
+		} else {
+			if drainedAt.IsZero() {
+				drainedAt = time.Now()
+			}
+			pending = "waiting for a status update"
+			if snap, err := store.Load(); err == nil && snap.UpdatedAt.After(drainedAt) {
+				if pending = replicatorBacklog(snap.Metrics); pending == "" {
+					return 0, nil
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			if pending != "" {
+				return lag, fmt.Errorf("replicator did not catch up within %v: %s", timeout, pending)
+			}
+			return lag, fmt.Errorf("replication lag did not drain within %v (source lag=%d)", timeout, lag)
+		}
+		if pending != "" {
+			log.Printf("  … waiting for the replicator to catch up (%s)", pending)
+		} else {
+			log.Printf("  … waiting for lag to drain (source lag=%d)", lag)
+		}
+		select {
+		case <-ctx.Done():
+			return lag, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// replicatorBacklog describes journal entries the replicator read but has
+// not written to the target yet, or returns "" when there are none.
+func replicatorBacklog(metrics map[string]float64) string {
+	var behind []string
+	for _, id := range flowIDs(metrics, state.MetricFlowReceivedLSNFormat) {
+		received := metrics[fmt.Sprintf(state.MetricFlowReceivedLSNFormat, id)]
+		applied := metrics[fmt.Sprintf(state.MetricFlowAppliedLSNFormat, id)]
+		if applied < received {
+			behind = append(behind, fmt.Sprintf("FLOW-%d applied %.0f of %.0f", id, applied, received))
+		}
+	}
+	if n := metrics[state.MetricRetryQueueLen]; n > 0 {
+		behind = append(behind, fmt.Sprintf("%.0f writes in the retry queue", n))
+	}
+	if n := metrics[state.MetricReorderBufferLen]; n > 0 {
+		behind = append(behind, fmt.Sprintf("%.0f entries in the reorder buffer", n))
+	}
+	return strings.Join(behind, ", ")
+}
+
+// replicaLag sums the lag reported for every replica in the source's INFO
+// replication ("slave0:ip=...,port=...,state=stable_sync,lag=0").
+func replicaLag(source *redisx.Client) (int64, error) {
+	info, err := source.Info("replication")
+	if err != nil {
+		return 0, fmt.Errorf("INFO replication: %w", err)
+	}
+	var total int64
+	replicas := 0
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		// Replica entries are "slave<N>:..."; skip slave_read_only and friends
+		if !strings.HasPrefix(line, "slave") || len(line) < 6 || line[5] < '0' || line[5] > '9' {
+			continue
+		}
+		replicas++
+		for _, field := range strings.Split(line[strings.Index(line, ":")+1:], ",") {
+			if v, ok := strings.CutPrefix(field, "lag="); ok {
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					return 0, fmt.Errorf("unexpected replica lag %q", v)
+				}
+				total += n
+			}
+		}
+	}
+	if replicas == 0 {
+		return 0, errors.New("source reports no connected replicas; is df2redis replicating?")
+	}
+	return total, nil
+}
+
+// flowLSNs extracts the per-FLOW LSN metrics for the marker.
+func flowLSNs(metrics map[string]float64) map[string]float64 {
+	out := make(map[string]float64)
+	for _, id := range flowIDs(metrics, state.MetricFlowLSNFormat) {
+		out[fmt.Sprintf("flow%d", id)] = metrics[fmt.Sprintf(state.MetricFlowLSNFormat, id)]
+	}
+	return out
+}
+
+// flowIDs returns, in order, the FLOW ids of the metrics named by format.
+func flowIDs(metrics map[string]float64, format string) []int {
+	var ids []int
+	for name := range metrics {
+		var id int
+		// Sscanf ignores trailing input; "flow.0.lsn" must not match "flow.0.lsn.applied"
+		if _, err := fmt.Sscanf(name, format, &id); err == nil && name == fmt.Sprintf(format, id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func writeMarker(path string, marker *Marker) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(marker, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write cutover marker: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,167 @@
+package cutover
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"df2redis/internal/redisx"
+	"df2redis/internal/state"
+)
+
+// fakeSource answers PING and INFO; INFO returns whatever info holds.
+func fakeSource(t *testing.T, info *atomic.Value) *redisx.Client {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					req, err := redisx.DecodeReply(r)
+					if err != nil {
+						return
+					}
+					args, _ := req.([]interface{})
+					name, _ := redisx.ToString(args[0])
+					reply := "+PONG\r\n"
+					if strings.EqualFold(name, "INFO") {
+						body := info.Load().(string)
+						reply = fmt.Sprintf("$%d\r\n%s\r\n", len(body), body)
+					}
+					if _, err := conn.Write([]byte(reply)); err != nil {
+						return
+					}
+				}
+			}(conn)
+		}
+	}()
+	c, err := redisx.Dial(context.Background(), redisx.Config{Addr: ln.Addr().String()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestReplicaLag(t *testing.T) {
+	cases := []struct {
+		info    string
+		want    int64
+		wantErr bool
+	}{
+		{info: "# Replication\r\nrole:master\r\nconnected_slaves:1\r\nslave0:ip=10.0.0.2,port=16379,state=stable_sync,lag=0\r\n", want: 0},
+		{info: "slave_read_only:1\r\nslave0:ip=a,port=1,state=stable_sync,lag=2\r\nslave1:ip=b,port=2,state=stable_sync,lag=3\r\n", want: 5},
+		{info: "# Replication\r\nrole:master\r\nconnected_slaves:0\r\nslave_read_only:1\r\n", wantErr: true},
+		{info: "slave0:ip=a,port=1,state=stable_sync,lag=x\r\n", wantErr: true},
+	}
+	var info atomic.Value
+	source := fakeSource(t, &info)
+	for _, tc := range cases {
+		info.Store(tc.info)
+		got, err := replicaLag(source)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("replicaLag(%q) = %d, %v; want %d, error=%v", tc.info, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestReplicatorBacklog(t *testing.T) {
+	metrics := map[string]float64{
+		"flow.0.lsn":                 500,
+		"flow.0.lsn.received":        120,
+		"flow.0.lsn.applied":         120,
+		"flow.1.lsn.received":        80,
+		"flow.1.lsn.applied":         78,
+		state.MetricRetryQueueLen:    3,
+		state.MetricReorderBufferLen: 0,
+	}
+	want := "FLOW-1 applied 78 of 80, 3 writes in the retry queue"
+	if got := replicatorBacklog(metrics); got != want {
+		t.Fatalf("replicatorBacklog = %q, want %q", got, want)
+	}
+
+	metrics["flow.1.lsn.applied"] = 80
+	metrics[state.MetricRetryQueueLen] = 0
+	if got := replicatorBacklog(metrics); got != "" {
+		t.Fatalf("replicatorBacklog = %q for a caught-up replicator", got)
+	}
+	if got := flowLSNs(metrics); len(got) != 1 || got["flow0"] != 500 {
+		t.Fatalf("flowLSNs = %v, want only flow0=500", got)
+	}
+}
+
+func TestDrainLagWaitsForAppliedPositions(t *testing.T) {
+	defer func(d time.Duration) { drainPollInterval = d }(drainPollInterval)
+	drainPollInterval = 20 * time.Millisecond
+
+	var info atomic.Value
+	info.Store("slave0:ip=a,port=1,state=stable_sync,lag=0\r\n")
+	source := fakeSource(t, &info)
+	store := state.NewStore(filepath.Join(t.TempDir(), "status.json"))
+
+	// The source sees no lag, but FLOW-0 still has writes in flight
+	if err := store.UpdateMetrics(map[string]float64{"flow.0.lsn.received": 10, "flow.0.lsn.applied": 7}); err != nil {
+		t.Fatal(err)
+	}
+	// The replicator refreshes the status file while it runs
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+				store.UpdateMetrics(map[string]float64{state.MetricRetryQueueLen: 0})
+			}
+		}
+	}()
+	_, err := drainLag(context.Background(), source, store, 200*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "FLOW-0 applied 7 of 10") {
+		t.Fatalf("drainLag = %v, want a FLOW-0 backlog error", err)
+	}
+
+	// Once the replicator publishes the write, cutover may proceed
+	time.AfterFunc(50*time.Millisecond, func() {
+		store.UpdateMetrics(map[string]float64{"flow.0.lsn.applied": 10})
+	})
+	if lag, err := drainLag(context.Background(), source, store, 2*time.Second); err != nil || lag != 0 {
+		t.Fatalf("drainLag = %d, %v; want 0, nil", lag, err)
+	}
+}
+
+func TestDrainLagIgnoresStaleStatus(t *testing.T) {
+	defer func(d time.Duration) { drainPollInterval = d }(drainPollInterval)
+	drainPollInterval = 20 * time.Millisecond
+
+	var info atomic.Value
+	info.Store("slave0:ip=a,port=1,state=stable_sync,lag=0\r\n")
+	source := fakeSource(t, &info)
+	store := state.NewStore(filepath.Join(t.TempDir(), "status.json"))
+	// Written before the source drained and never refreshed
+	if err := store.UpdateMetrics(map[string]float64{"flow.0.lsn.received": 5, "flow.0.lsn.applied": 5}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := drainLag(context.Background(), source, store, 100*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "waiting for a status update") {
+		t.Fatalf("drainLag = %v, want it to wait for a fresh status file", err)
+	}
+}
@@ -121,13 +121,16 @@ func logToFile(level Level, format string, args ...interface{}) {
 	defaultLogger.mu.Lock()
 	defer defaultLogger.mu.Unlock()
 	message := formatMessage(level, format, args...)
+	if plain.Load() {
+		message = Plain(message)
+	}
 	defaultLogger.fileLogger.Println(message)
 }
 
 // logToConsole prints highlights to stdout
 func logToConsole(format string, args ...interface{}) {
 	if defaultLogger == nil {
-		fmt.Printf(format+"\n", args...)
+		fmt.Fprintf(PlainWriter(os.Stdout), format+"\n", args...)
 		return
 	}
 	if !defaultLogger.consoleOn {
@@ -139,6 +142,9 @@ func logToConsole(format string, args ...interface{}) {
 	// Preserve the original format/emojis on console output
 	timestamp := time.Now().Format("2006/01/02 15:04:05")
 	message := fmt.Sprintf(format, args...)
+	if plain.Load() {
+		message = Plain(message)
+	}
 
 	// Gracefully handle stdout write failures (e.g., when SSH session disconnects)
 	// If stdout is closed, the write will fail but won't crash the program
@@ -193,9 +199,9 @@ func Println(args ...interface{}) {
 // Writer returns an io.Writer compatible with the standard log package
 func Writer() io.Writer {
 	if defaultLogger != nil {
-		return defaultLogger.logFile
+		return PlainWriter(defaultLogger.logFile)
 	}
-	return os.Stdout
+	return PlainWriter(os.Stdout)
 }
 
 // NewStandaloneLogger creates a distinct file logger that does not interfere with the global logger.
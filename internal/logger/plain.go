@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"io"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// plain strips emoji and box-drawing decorations from every log line (log.plain / --no-emoji).
+var plain atomic.Bool
+
+// SetPlain enables or disables ASCII-only log decorations.
+func SetPlain(on bool) {
+	plain.Store(on)
+}
+
+// IsPlain reports whether plain log mode is enabled.
+func IsPlain() bool {
+	return plain.Load()
+}
+
+// plainReplacements maps status symbols to ASCII so meaning survives stripping.
+var plainReplacements = map[rune]string{
+	'✓': "[ok]", '✔': "[ok]", '✅': "[ok]",
+	'✗': "[x]", '✘': "[x]", '❌': "[x]",
+	'⚠': "[!]",
+	'→': "->", '←': "<-", '⇒': "=>",
+	'•': "*", '⊘': "-", '…': "...",
+	'━': "-", '─': "-", '═': "=",
+	'│': "|", '┃': "|", '║': "|",
+}
+
+// Plain rewrites s for terminals and log pipelines that can't render
+// decorations: known status symbols become ASCII, other emoji, dingbats and
+// box-drawing characters are dropped. Other text (e.g. non-ASCII key names)
+// is left untouched.
+func Plain(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	dropped := false
+	for _, r := range s {
+		if rep, ok := plainReplacements[r]; ok {
+			b.WriteString(rep)
+			dropped = false
+			continue
+		}
+		if isDecoration(r) {
+			dropped = true
+			continue
+		}
+		// Swallow the separator that followed a dropped emoji ("🚀 start" -> "start")
+		if dropped && r == ' ' {
+			dropped = false
+			continue
+		}
+		dropped = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// isDecoration reports emoji, pictographs, dingbats, box drawing and the
+// invisible joiners/selectors used to compose them.
+func isDecoration(r rune) bool {
+	switch {
+	case r >= 0x2500 && r <= 0x259F: // box drawing, block elements
+		return true
+	case r >= 0x2190 && r <= 0x21FF: // arrows
+		return true
+	case r >= 0x2300 && r <= 0x23FF: // misc technical (⏩ ⏸ ⏱)
+		return true
+	case r >= 0x2600 && r <= 0x27BF: // misc symbols, dingbats
+		return true
+	case r >= 0x2B00 && r <= 0x2BFF: // misc symbols and arrows
+		return true
+	case r >= 0x1F000 && r <= 0x1FAFF: // emoji and pictographs
+		return true
+	case r == 0x200D || (r >= 0xFE00 && r <= 0xFE0F): // ZWJ, variation selectors
+		return true
+	}
+	return false
+}
+
+type plainWriter struct {
+	w io.Writer
+}
+
+// PlainWriter wraps w so writes are passed through Plain while plain mode is on.
+func PlainWriter(w io.Writer) io.Writer {
+	if _, ok := w.(plainWriter); ok {
+		return w
+	}
+	return plainWriter{w: w}
+}
+
+func (p plainWriter) Write(b []byte) (int, error) {
+	if !plain.Load() {
+		return p.w.Write(b)
+	}
+	if _, err := io.WriteString(p.w, Plain(string(b))); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -2,11 +2,9 @@ package redisx
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net"
 	"os"
@@ -24,6 +22,15 @@ type Config struct {
 	Addr     string
 	Password string
 	TLS      bool
+
+	TLSCAFile   string // PEM bundle used to verify the server (default: system roots)
+	TLSInsecure bool   // skip certificate verification (self-signed certs)
+
+	SkipPing bool // don't verify the connection with PING after dialing
+
+	// Name labels the connection in CLIENT LIST (CLIENT SETNAME, plus
+	// CLIENT SETINFO LIB-NAME df2redis); must not contain spaces. Empty = unnamed.
+	Name string
 }
 
 // Client implements a lightweight Redis RESP client.
@@ -42,13 +49,16 @@ type Client struct {
 
 	mu     sync.Mutex
 	closed atomic.Int32 // 0 = open, 1 = closed
+
+	// Optional write audit log (see SetTracer)
+	tracer atomic.Pointer[CommandTracer]
+
+	// Last DNS re-check in unix nanoseconds (see ClusterOptions.DNSRefreshInterval)
+	dnsCheckedAt atomic.Int64
 }
 
 // Dial creates a new client connection.
 func Dial(ctx context.Context, cfg Config) (*Client, error) {
-	if cfg.TLS {
-		return nil, errors.New("redisx: TLS is not supported in this prototype")
-	}
 	if cfg.Addr == "" {
 		return nil, errors.New("redisx: addr is empty")
 	}
@@ -92,6 +102,15 @@ func Dial(ctx context.Context, cfg Config) (*Client, error) {
 		}
 	}
 
+	if cfg.TLS {
+		tlsConn, err := wrapTLS(ctx, conn, cfg)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	}
+
 	// Use 1MB bufio.Reader to match high-throughput RDB streaming
 	// Default 4KB buffer causes excessive system calls and may contribute to read timeout issues
 	const bufSize = 1024 * 1024 // 1MB
@@ -103,6 +122,7 @@ func Dial(ctx context.Context, cfg Config) (*Client, error) {
 		timeout:    defaultTimeout,
 		rdbTimeout: 60 * time.Second, // fixed 60s for snapshot/journal reads
 	}
+	client.dnsCheckedAt.Store(time.Now().UnixNano())
 
 	if cfg.Password != "" {
 		if _, err := client.Do("AUTH", cfg.Password); err != nil {
@@ -110,13 +130,28 @@ func Dial(ctx context.Context, cfg Config) (*Client, error) {
 			return nil, fmt.Errorf("redisx: auth failed: %w", err)
 		}
 	}
-	if err := client.Ping(); err != nil {
-		client.Close()
-		return nil, err
+	if cfg.Name != "" {
+		client.setName(cfg.Name)
+	}
+	if !cfg.SkipPing {
+		if err := client.Ping(); err != nil {
+			client.Close()
+			return nil, err
+		}
 	}
 	return client, nil
 }
 
+// setName labels the connection for CLIENT LIST / CLIENT KILL. Errors are
+// ignored: CLIENT SETINFO needs Redis 7.2 and some servers (or ACLs) reject
+// CLIENT altogether, which shouldn't fail the dial.
+func (c *Client) setName(name string) {
+	if _, err := c.Do("CLIENT", "SETNAME", name); err != nil {
+		return
+	}
+	_, _ = c.Do("CLIENT", "SETINFO", "LIB-NAME", "df2redis")
+}
+
 // Close terminates the connection.
 func (c *Client) Close() error {
 	// Atomic compare-and-swap to ensure we only close once
@@ -156,6 +191,27 @@ func (c *Client) CloseWrite() error {
 	return c.Close()
 }
 
+// Interrupt forces any in-flight read or write on the connection to fail
+// immediately by moving its deadlines into the past. It does not take c.mu,
+// so it can unblock a Do call that is waiting for a reply.
+func (c *Client) Interrupt() {
+	if c.closed.Load() != 0 {
+		return
+	}
+	_ = c.conn.SetDeadline(time.Now())
+}
+
+// IsClosed reports whether Close was called, e.g. by a ClusterClient that
+// dropped the connection; ClusterClient.GetNodeClient hands out a new one.
+func (c *Client) IsClosed() bool {
+	return c.closed.Load() != 0
+}
+
+// Addr returns the address the client is connected to.
+func (c *Client) Addr() string {
+	return c.addr
+}
+
 // Ping verifies connectivity.
 func (c *Client) Ping() error {
 	_, err := c.Do("PING")
@@ -180,6 +236,18 @@ func (c *Client) RawRead(buf []byte) (int, error) {
 	return c.conn.Read(buf)
 }
 
+// SetDeadline sets the read and write deadlines; a zero t clears both,
+// e.g. after Interrupt.
+func (c *Client) SetDeadline(t time.Time) error {
+	if c.closed.Load() == 1 {
+		return errors.New("redisx: client closed")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	return c.conn.SetDeadline(t)
+}
+
 // SetReadDeadline sets the read deadline for future Read calls.
 // If t is zero, the deadline is cleared (or falls back to default behavior).
 func (c *Client) SetReadDeadline(t time.Time) error {
@@ -282,7 +350,8 @@ func (c *Client) Do(cmd string, args ...interface{}) (interface{}, error) {
 	if err := c.writeCommand(cmd, args...); err != nil {
 		return nil, err
 	}
-	return c.readReply()
+	reply, err := c.readReply()
+	return reply, afterWrite(err)
 }
 
 // DoWithTimeout sends a command with a custom timeout and returns the parsed RESP reply.
@@ -298,19 +367,51 @@ func (c *Client) DoWithTimeout(timeout time.Duration, cmd string, args ...interf
 	if err := c.conn.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
 		return nil, err
 	}
-	var buf bytes.Buffer
-	count := 1 + len(args)
-	fmt.Fprintf(&buf, "*%d\r\n", count)
-	writeBulk(&buf, strings.ToUpper(cmd))
-	for _, arg := range args {
-		writeBulk(&buf, formatArg(arg))
-	}
-	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+	if err := EncodeCommand(c.conn, cmd, args...); err != nil {
 		return nil, err
 	}
 
 	// Set read deadline with custom timeout
 	if err := c.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, afterWrite(err)
+	}
+	reply, err := c.readReply()
+	return reply, afterWrite(err)
+}
+
+// Send writes a command without waiting for a reply, for commands the server
+// never answers (REPLCONF ACK).
+func (c *Client) Send(cmd string, args ...interface{}) error {
+	if c.closed.Load() == 1 {
+		return errors.New("redisx: client closed")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.writeCommand(cmd, args...)
+}
+
+// ReadPush waits up to wait for something the server sends on its own, such
+// as a command a replication master issues on the replica's connection. It
+// returns nil, nil when nothing arrived in time; Do calls block meanwhile.
+func (c *Client) ReadPush(wait time.Duration) (interface{}, error) {
+	if c.closed.Load() == 1 {
+		return nil, errors.New("redisx: client closed")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.conn.SetReadDeadline(time.Now().Add(wait)); err != nil {
+		return nil, err
+	}
+	// Peek consumes nothing on timeout, so the stream stays in sync
+	if _, err := c.reader.Peek(1); err != nil {
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := c.conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
 		return nil, err
 	}
 	return c.readReply()
@@ -391,89 +492,47 @@ func (c *Client) Info(section string) (string, error) {
 //	}
 //	results, err := client.Pipeline(cmds)
 //
-// Returns a slice of results corresponding to each command.
-// If any command fails, returns error immediately.
+// Returns a slice of results corresponding to each command. If any command
+// gets an error reply, the first one is returned; the remaining replies are
+// still read, so the connection stays usable. Use NewPipeline to get the
+// error of every command.
 func (c *Client) Pipeline(cmds [][]interface{}) ([]interface{}, error) {
-	if c.closed.Load() != 0 {
-		return nil, errors.New("redisx: client closed")
-	}
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if len(cmds) == 0 {
-		return []interface{}{}, nil
-	}
-
-	// CRITICAL FIX: Set generous timeout for pipeline operations
-	// Large pipelines (500+ commands) need more time than individual commands
-	// Use 60 seconds to prevent timeout on slow Redis Cluster nodes
-	pipelineTimeout := 60 * time.Second
-	writeDeadline := time.Now().Add(pipelineTimeout)
-	readDeadline := time.Now().Add(pipelineTimeout)
-
-	// Set write deadline once for all commands
-	if err := c.conn.SetWriteDeadline(writeDeadline); err != nil {
-		return nil, err
-	}
-
-	// Step 1: Send all commands without waiting for replies
+	p := c.NewPipeline()
 	for _, cmdArgs := range cmds {
 		if len(cmdArgs) == 0 {
 			return nil, errors.New("redisx: empty command in pipeline")
 		}
-
 		// First element is command name
 		cmd, ok := cmdArgs[0].(string)
 		if !ok {
 			return nil, fmt.Errorf("redisx: command name must be string, got %T", cmdArgs[0])
 		}
-
-		// Remaining elements are arguments
-		args := cmdArgs[1:]
-
-		// Write command without resetting deadline (already set above)
-		var buf bytes.Buffer
-		count := 1 + len(args)
-		fmt.Fprintf(&buf, "*%d\r\n", count)
-		writeBulk(&buf, strings.ToUpper(cmd))
-		for _, arg := range args {
-			writeBulk(&buf, formatArg(arg))
-		}
-		if _, err := c.conn.Write(buf.Bytes()); err != nil {
-			return nil, fmt.Errorf("redisx: failed to write command %s: %w", cmd, err)
-		}
+		p.Add(cmd, cmdArgs[1:]...)
 	}
 
-	// Set read deadline once for all replies
-	if err := c.conn.SetReadDeadline(readDeadline); err != nil {
+	results, errs, err := p.Exec()
+	if err != nil {
 		return nil, err
 	}
-
-	// Step 2: Read all replies
-	results := make([]interface{}, len(cmds))
-	for i := range cmds {
-		reply, err := c.readReply()
+	for i, err := range errs {
 		if err != nil {
 			return nil, fmt.Errorf("redisx: failed to read reply for command %d: %w", i, err)
 		}
-		results[i] = reply
 	}
-
 	return results, nil
 }
 
+// SetTracer records every command sent by this client to t (nil disables tracing).
+func (c *Client) SetTracer(t *CommandTracer) {
+	c.tracer.Store(t)
+}
+
 func (c *Client) writeCommand(cmd string, args ...interface{}) error {
+	c.tracer.Load().trace(c.addr, cmd, args)
 	if err := c.conn.SetWriteDeadline(time.Now().Add(c.timeout)); err != nil {
 		return err
 	}
-	var buf bytes.Buffer
-	count := 1 + len(args)
-	fmt.Fprintf(&buf, "*%d\r\n", count)
-	writeBulk(&buf, strings.ToUpper(cmd))
-	for _, arg := range args {
-		writeBulk(&buf, formatArg(arg))
-	}
-	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+	if err := EncodeCommand(c.conn, cmd, args...); err != nil {
 		return err
 	}
 	if err := c.conn.SetReadDeadline(time.Now().Add(c.timeout)); err != nil {
@@ -483,113 +542,7 @@ func (c *Client) writeCommand(cmd string, args ...interface{}) error {
 }
 
 func (c *Client) readReply() (interface{}, error) {
-	line, err := c.reader.ReadByte()
-	if err != nil {
-		return nil, err
-	}
-	switch line {
-	case '+':
-		str, err := readLine(c.reader)
-		return str, err
-	case '-':
-		msg, err := readLine(c.reader)
-		if err != nil {
-			return nil, err
-		}
-		return nil, errors.New("redis: " + msg)
-	case ':':
-		numStr, err := readLine(c.reader)
-		if err != nil {
-			return nil, err
-		}
-		val, err := strconv.ParseInt(numStr, 10, 64)
-		if err != nil {
-			return nil, err
-		}
-		return val, nil
-	case '$':
-		sizeStr, err := readLine(c.reader)
-		if err != nil {
-			return nil, err
-		}
-		size, err := strconv.Atoi(sizeStr)
-		if err != nil {
-			return nil, err
-		}
-		if size == -1 {
-			return nil, nil
-		}
-		data := make([]byte, size+2)
-		if _, err := io.ReadFull(c.reader, data); err != nil {
-			return nil, err
-		}
-		return string(data[:size]), nil
-	case '*':
-		countStr, err := readLine(c.reader)
-		if err != nil {
-			return nil, err
-		}
-		count, err := strconv.Atoi(countStr)
-		if err != nil {
-			return nil, err
-		}
-		if count == -1 {
-			return nil, nil
-		}
-		result := make([]interface{}, 0, count)
-		for i := 0; i < count; i++ {
-			item, err := c.readReply()
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, item)
-		}
-		return result, nil
-	default:
-		return nil, fmt.Errorf("redisx: unexpected RESP prefix %q", line)
-	}
-}
-
-func writeBulk(buf *bytes.Buffer, value string) {
-	fmt.Fprintf(buf, "$%d\r\n%s\r\n", len(value), value)
-}
-
-func formatArg(arg interface{}) string {
-	switch v := arg.(type) {
-	case string:
-		return v
-	case []byte:
-		return string(v)
-	case int:
-		return strconv.Itoa(v)
-	case int64:
-		return strconv.FormatInt(v, 10)
-	case uint64:
-		return strconv.FormatUint(v, 10)
-	case float64:
-		return strconv.FormatFloat(v, 'f', -1, 64)
-	case float32:
-		return strconv.FormatFloat(float64(v), 'f', -1, 32)
-	case bool:
-		if v {
-			return "1"
-		}
-		return "0"
-	case fmt.Stringer:
-		return v.String()
-	default:
-		return fmt.Sprint(arg)
-	}
-}
-
-func readLine(r *bufio.Reader) (string, error) {
-	line, err := r.ReadString('\n')
-	if err != nil {
-		return "", err
-	}
-	line = strings.TrimSuffix(line, "\n")
-	line = strings.TrimSuffix(line, "\r")
-	return line, nil
+	return DecodeReply(c.reader)
 }
 
 // ToString converts RESP reply to string.
@@ -0,0 +1,45 @@
+package redisx
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadPushAndSend(t *testing.T) {
+	release := make(chan struct{})
+	acks := make(chan interface{}, 1)
+	c := pipeClient(t, func(r *bufio.Reader, w net.Conn) {
+		<-release
+		w.Write([]byte("*3\r\n$8\r\nREPLCONF\r\n$6\r\nGETACK\r\n$1\r\n*\r\n"))
+		ack, err := DecodeReply(r)
+		if err != nil {
+			return
+		}
+		acks <- ack
+	})
+
+	// Nothing sent yet: the wait times out without consuming anything
+	reply, err := c.ReadPush(20 * time.Millisecond)
+	if err != nil || reply != nil {
+		t.Fatalf("idle ReadPush = %v, %v; want nil, nil", reply, err)
+	}
+
+	close(release)
+	reply, err = c.ReadPush(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{"REPLCONF", "GETACK", "*"}; !reflect.DeepEqual(reply, want) {
+		t.Fatalf("ReadPush = %v, want %v", reply, want)
+	}
+
+	if err := c.Send("REPLCONF", "ACK", "42"); err != nil {
+		t.Fatal(err)
+	}
+	if ack := <-acks; !reflect.DeepEqual(ack, []interface{}{"REPLCONF", "ACK", "42"}) {
+		t.Fatalf("server received %v", ack)
+	}
+}
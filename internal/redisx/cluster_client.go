@@ -8,7 +8,9 @@ import (
 	"math/rand"
 	"net"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 // ClusterClient manages corrections to a Redis Cluster.
@@ -21,10 +23,64 @@ type ClusterClient struct {
 	slots   [16384]string      // Mapping slot -> master address
 	clients map[string]*Client // Mapping address -> Client connection
 	closed  bool
+
+	// standalone targets have no topology to refresh on reconnect
+	standalone bool
+
+	// tracer is attached to every node client (see SetTracer)
+	tracer *CommandTracer
+
+	opts ClusterOptions
 }
 
+// ClusterOptions remaps node addresses announced by CLUSTER SLOTS, for
+// clusters behind Docker/NAT whose announced IPs are not reachable.
+type ClusterOptions struct {
+	// HostOverride replaces the host of every announced node, keeping its port.
+	HostOverride string
+	// AddrMap maps an announced host to a reachable "host" or "host:port".
+	// It takes precedence over HostOverride.
+	AddrMap map[string]string
+
+	// TLS settings applied to every node connection.
+	TLS         bool
+	TLSCAFile   string
+	TLSInsecure bool
+
+	// ClientName is set with CLIENT SETNAME on every node connection (see Config.Name).
+	ClientName string
+
+	// DNSRefreshInterval > 0 re-resolves the host name of a live node
+	// connection at most this often and redials when it points elsewhere.
+	DNSRefreshInterval time.Duration
+}
+
+// nodeConfig returns the dial settings for a node.
+func (cc *ClusterClient) nodeConfig(addr string) Config {
+	return Config{
+		Addr:        addr,
+		Password:    cc.password,
+		TLS:         cc.opts.TLS,
+		TLSCAFile:   cc.opts.TLSCAFile,
+		TLSInsecure: cc.opts.TLSInsecure,
+		Name:        cc.opts.ClientName,
+	}
+}
+
+const (
+	// doMaxAttempts bounds how often Do retries a command after a connection error
+	doMaxAttempts = 4
+	// doRetryBaseDelay is the base backoff; each retry doubles it and adds jitter
+	doRetryBaseDelay = 100 * time.Millisecond
+)
+
 // DialCluster connects to a Redis Cluster using the provided seeds.
 func DialCluster(ctx context.Context, seeds []string, password string) (*ClusterClient, error) {
+	return DialClusterWithOptions(ctx, seeds, password, ClusterOptions{})
+}
+
+// DialClusterWithOptions is DialCluster with node address remapping.
+func DialClusterWithOptions(ctx context.Context, seeds []string, password string, opts ClusterOptions) (*ClusterClient, error) {
 	if len(seeds) == 0 {
 		return nil, errors.New("redisx: no cluster seeds provided")
 	}
@@ -33,6 +89,7 @@ func DialCluster(ctx context.Context, seeds []string, password string) (*Cluster
 		seeds:    seeds,
 		password: password,
 		clients:  make(map[string]*Client),
+		opts:     opts,
 	}
 
 	// Initial topology discovery
@@ -47,18 +104,26 @@ func DialCluster(ctx context.Context, seeds []string, password string) (*Cluster
 // DialStandalone connects to a single Redis instance but returns a ClusterClient adapter.
 // This allows the replicator to treat standalone and cluster targets uniformly.
 func DialStandalone(ctx context.Context, addr string, password string) (*ClusterClient, error) {
+	return DialStandaloneWithOptions(ctx, addr, password, ClusterOptions{})
+}
+
+// DialStandaloneWithOptions is DialStandalone with TLS settings; address
+// remapping does not apply to a single node.
+func DialStandaloneWithOptions(ctx context.Context, addr string, password string, opts ClusterOptions) (*ClusterClient, error) {
 	if addr == "" {
 		return nil, errors.New("redisx: addr is empty")
 	}
 
 	cc := &ClusterClient{
-		seeds:    []string{addr},
-		password: password,
-		clients:  make(map[string]*Client),
+		seeds:      []string{addr},
+		password:   password,
+		clients:    make(map[string]*Client),
+		standalone: true,
+		opts:       opts,
 	}
 
 	// Connect to the single node
-	client, err := Dial(ctx, Config{Addr: addr, Password: password})
+	client, err := Dial(ctx, cc.nodeConfig(addr))
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +176,30 @@ func (cc *ClusterClient) MasterAddr(slot uint16) string {
 	return cc.slots[slot]
 }
 
+// SlotRange is a run of consecutive slots served by one master; Addr is
+// empty for slots no master claims.
+type SlotRange struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Addr  string `json:"master"`
+}
+
+// SlotRanges returns the slot map the client routes by, compressed to ranges
+// in slot order.
+func (cc *ClusterClient) SlotRanges() []SlotRange {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+	var ranges []SlotRange
+	for slot, addr := range cc.slots {
+		if n := len(ranges); n > 0 && ranges[n-1].Addr == addr {
+			ranges[n-1].End = slot
+			continue
+		}
+		ranges = append(ranges, SlotRange{Start: slot, End: slot, Addr: addr})
+	}
+	return ranges
+}
+
 // GetNodeClient returns a client for the specific node address.
 // If connection doesn't exist, it creates one.
 func (cc *ClusterClient) GetNodeClient(addr string) (*Client, error) {
@@ -122,9 +211,13 @@ func (cc *ClusterClient) GetNodeClient(addr string) (*Client, error) {
 	client, ok := cc.clients[addr]
 	if ok && client.closed.Load() == 0 {
 		cc.mu.RUnlock()
-		return client, nil
+		if !cc.dnsStale(client) {
+			return client, nil
+		}
+		cc.dropClient(addr, client)
+	} else {
+		cc.mu.RUnlock()
 	}
-	cc.mu.RUnlock()
 
 	// Need to create new connection
 	cc.mu.Lock()
@@ -140,19 +233,27 @@ func (cc *ClusterClient) GetNodeClient(addr string) (*Client, error) {
 	}
 
 	// Dial new connection
-	cfg := Config{
-		Addr:     addr,
-		Password: cc.password,
-	}
-	newClient, err := Dial(context.Background(), cfg)
+	newClient, err := Dial(context.Background(), cc.nodeConfig(addr))
 	if err != nil {
 		return nil, err
 	}
 
+	newClient.SetTracer(cc.tracer)
 	cc.clients[addr] = newClient
 	return newClient, nil
 }
 
+// SetTracer records every command sent to any node, including pipelines
+// issued through node clients, to t.
+func (cc *ClusterClient) SetTracer(t *CommandTracer) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	cc.tracer = t
+	for _, client := range cc.clients {
+		client.SetTracer(t)
+	}
+}
+
 // refreshSlots updates the slot mapping by running CLUSTER SLOTS on random seed/known node.
 func (cc *ClusterClient) refreshSlots(ctx context.Context) error {
 	// Try known clients first, then seeds
@@ -198,7 +299,7 @@ func (cc *ClusterClient) fetchSlots(ctx context.Context, addr string) ([]cluster
 	cc.mu.RUnlock()
 
 	if client == nil {
-		client, err = Dial(ctx, Config{Addr: addr, Password: cc.password})
+		client, err = Dial(ctx, cc.nodeConfig(addr))
 		if err != nil {
 			return nil, err
 		}
@@ -211,7 +312,34 @@ func (cc *ClusterClient) fetchSlots(ctx context.Context, addr string) ([]cluster
 		return nil, err
 	}
 
-	return parseClusterSlots(reply)
+	// Nodes may announce an empty IP meaning "the node you are talking to"
+	host, _, _ := net.SplitHostPort(addr)
+	nodes, err := parseClusterSlots(reply, host)
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes {
+		nodes[i].masterAddr = cc.opts.remap(nodes[i].masterAddr)
+	}
+	return nodes, nil
+}
+
+// remap applies AddrMap/HostOverride to an announced node address.
+func (o ClusterOptions) remap(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if mapped, ok := o.AddrMap[host]; ok && mapped != "" {
+		if _, _, err := net.SplitHostPort(mapped); err == nil {
+			return mapped
+		}
+		return net.JoinHostPort(mapped, port)
+	}
+	if o.HostOverride != "" {
+		return net.JoinHostPort(o.HostOverride, port)
+	}
+	return addr
 }
 
 type clusterSlotNode struct {
@@ -219,7 +347,9 @@ type clusterSlotNode struct {
 	masterAddr string
 }
 
-func parseClusterSlots(reply interface{}) ([]clusterSlotNode, error) {
+// parseClusterSlots extracts the master of every slot range. An empty
+// announced IP is replaced with fallbackHost, the host that was queried.
+func parseClusterSlots(reply interface{}, fallbackHost string) ([]clusterSlotNode, error) {
 	// Reply is array of arrays
 	// [[start, end, [ip, port, id], ...], ...]
 
@@ -247,8 +377,13 @@ func parseClusterSlots(reply interface{}) ([]clusterSlotNode, error) {
 		ip, _ := ToString(masterInfo[0])
 		port, _ := ToInt64(masterInfo[1])
 
-		// If IP is empty (some Redis versions), handle it? Usually it returns valid IP.
-		// If Docker, might be internal IP.
+		if ip == "" || ip == "?" {
+			if fallbackHost == "" {
+				log.Printf("[Cluster] ⚠ Node for slots %d-%d announced no IP and no fallback host is known, skipping", start, end)
+				continue
+			}
+			ip = fallbackHost
+		}
 
 		addr := net.JoinHostPort(ip, strconv.FormatInt(port, 10))
 		results = append(results, clusterSlotNode{
@@ -271,6 +406,11 @@ func (cc *ClusterClient) updateTopology(nodes []clusterSlotNode) {
 	}
 }
 
+// IsStandalone reports whether the client was created with DialStandalone.
+func (cc *ClusterClient) IsStandalone() bool {
+	return cc.standalone
+}
+
 // MasterCount returns the number of unique master nodes.
 func (cc *ClusterClient) MasterCount() int {
 	cc.mu.RLock()
@@ -281,32 +421,138 @@ func (cc *ClusterClient) MasterCount() int {
 // Do executes a command on the appropriate node.
 // It assumes the first argument in args is the Key.
 // If args is empty, it executes on a random node.
+//
+// On a connection error (e.g. the owning master failed over) the broken client
+// is dropped, the topology is refreshed and the command is retried on the
+// possibly new owner. Only failures before the command went out are retried
+// that way: if the connection broke while waiting for the reply the command
+// may already have run, so the error is returned (see IsUnknownOutcome)
+// unless the command is read-only. READONLY and CLUSTERDOWN replies from a
+// cluster are retried without dropping the connection. Retries use jittered
+// exponential backoff so many FLOWs hitting the same downed node don't
+// reconnect in lockstep. Other error replies are returned as-is.
 func (cc *ClusterClient) Do(cmd string, args ...interface{}) (interface{}, error) {
-	var client *Client
-	var err error
+	var lastErr error
+	for attempt := 0; attempt < doMaxAttempts; attempt++ {
+		if attempt > 0 {
+			if cc.isClosed() {
+				return nil, errors.New("redisx: cluster client closed")
+			}
+			time.Sleep(jitteredBackoff(attempt))
+			if !cc.standalone {
+				if err := cc.refreshSlots(context.Background()); err != nil {
+					log.Printf("[Cluster] Topology refresh before retry %d failed: %v", attempt, err)
+				}
+			}
+		}
 
-	if len(args) > 0 {
-		// Assume first arg is key
-		key := fmt.Sprint(args[0])
-		slot := Slot(key)
-		addr := cc.MasterAddr(slot)
-		if addr == "" {
-			return nil, fmt.Errorf("no master found for slot %d (key %s)", slot, key)
+		client, addr, err := cc.clientFor(args)
+		if err != nil {
+			lastErr = err
+			continue
 		}
-		client, err = cc.GetNodeClient(addr)
-	} else {
-		// Random node
-		client, err = cc.getRandomClient()
+
+		reply, err := client.Do(cmd, args...)
+		if err == nil {
+			return reply, nil
+		}
+		if !isConnectionError(err) {
+			if cc.standalone || !retryInCluster(err) {
+				return reply, err
+			}
+			lastErr = err
+			log.Printf("[Cluster] %s on %s rejected (attempt %d/%d), refreshing topology: %v",
+				cmd, addr, attempt+1, doMaxAttempts, err)
+			continue
+		}
+
+		cc.dropClient(addr, client)
+		if IsUnknownOutcome(err) && !readOnlyCommands[strings.ToUpper(cmd)] {
+			return nil, fmt.Errorf("redisx: %s on %s: %w", cmd, addr, err)
+		}
+		lastErr = err
+		log.Printf("[Cluster] %s on %s failed with connection error (attempt %d/%d): %v",
+			cmd, addr, attempt+1, doMaxAttempts, err)
 	}
+	return nil, fmt.Errorf("redisx: %s failed after %d attempts: %w", cmd, doMaxAttempts, lastErr)
+}
 
-	if err != nil {
-		return nil, err
+// clientFor resolves the node client for the key in args[0], or a random node.
+func (cc *ClusterClient) clientFor(args []interface{}) (*Client, string, error) {
+	if len(args) == 0 {
+		client, err := cc.getRandomClient()
+		if err != nil {
+			return nil, "", err
+		}
+		return client, client.addr, nil
+	}
+
+	// Assume first arg is key
+	key := fmt.Sprint(args[0])
+	slot := Slot(key)
+	addr := cc.MasterAddr(slot)
+	if addr == "" {
+		return nil, "", fmt.Errorf("no master found for slot %d (key %s)", slot, key)
 	}
+	client, err := cc.GetNodeClient(addr)
+	return client, addr, err
+}
+
+// dropClient closes a broken node client so the next GetNodeClient redials.
+func (cc *ClusterClient) dropClient(addr string, client *Client) {
+	cc.mu.Lock()
+	if current, ok := cc.clients[addr]; ok && current == client {
+		delete(cc.clients, addr)
+	}
+	cc.mu.Unlock()
+	client.Close()
+}
 
-	// Retry on MOVED?
-	// For now, simple execution.
-	// Improvements: Handle MOVED/ASK recursion.
-	return client.Do(cmd, args...)
+// isConnectionError distinguishes transport failures from Redis error replies.
+func isConnectionError(err error) bool {
+	return err != nil && !IsReplyError(err)
+}
+
+// readOnlyCommands are safe to resend after the reply was lost.
+var readOnlyCommands = map[string]bool{
+	"GET": true, "MGET": true, "EXISTS": true, "TYPE": true, "TTL": true, "PTTL": true,
+	"STRLEN": true, "HGETALL": true, "HLEN": true, "LRANGE": true, "LLEN": true,
+	"SMEMBERS": true, "SCARD": true, "ZRANGE": true, "ZCARD": true, "XLEN": true,
+	"DUMP": true, "SCAN": true, "DBSIZE": true, "INFO": true, "PING": true,
+	"MEMORY": true, "OBJECT": true,
+}
+
+// IsRetryableError reports failures that are expected to clear up on their
+// own: transport errors and cluster/loading/busy replies from the target. A
+// connection lost after the command was sent is not retryable: the write may
+// have been applied, and applying it again is not safe in general.
+func IsRetryableError(err error) bool {
+	if err == nil || IsUnknownOutcome(err) {
+		return false
+	}
+	if isConnectionError(err) {
+		return true
+	}
+	for _, class := range []error{ErrClusterDown, ErrTryAgain, ErrLoading, ErrMasterDown, ErrBusy, ErrReadOnly} {
+		if errors.Is(err, class) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryInCluster reports error replies that Do retries against a cluster:
+// READONLY means the node was demoted and the slot map is stale, CLUSTERDOWN
+// usually clears once failover completes.
+func retryInCluster(err error) bool {
+	return errors.Is(err, ErrReadOnly) || errors.Is(err, ErrClusterDown)
+}
+
+// jitteredBackoff returns a delay in [d/2, d) where d = base * 2^(attempt-1).
+func jitteredBackoff(attempt int) time.Duration {
+	d := doRetryBaseDelay << (attempt - 1)
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)))
 }
 
 func (cc *ClusterClient) getRandomClient() (*Client, error) {
@@ -321,17 +567,25 @@ func (cc *ClusterClient) getRandomClient() (*Client, error) {
 	return nil, errors.New("no available clients")
 }
 
-// ForEachMaster executes a function for every connected master node.
+// ForEachMaster executes a function for every master that owns slots,
+// connecting to the ones that haven't been used yet.
 func (cc *ClusterClient) ForEachMaster(fn func(client *Client) error) error {
 	cc.mu.RLock()
-	// Copy clients to avoid holding lock during callback
-	clients := make([]*Client, 0, len(cc.clients))
-	for _, c := range cc.clients {
-		clients = append(clients, c)
+	seen := make(map[string]bool)
+	var addrs []string
+	for _, addr := range cc.slots {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
 	}
 	cc.mu.RUnlock()
 
-	for _, c := range clients {
+	for _, addr := range addrs {
+		c, err := cc.GetNodeClient(addr)
+		if err != nil {
+			return err
+		}
 		if err := fn(c); err != nil {
 			return err
 		}
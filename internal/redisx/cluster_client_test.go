@@ -0,0 +1,100 @@
+package redisx
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSlotRanges(t *testing.T) {
+	cc := &ClusterClient{}
+	cc.updateTopology([]clusterSlotNode{
+		{start: 0, end: 5460, masterAddr: "a:7000"},
+		{start: 5461, end: 10922, masterAddr: "b:7001"},
+		{start: 10923, end: 16000, masterAddr: "a:7000"},
+	})
+	want := []SlotRange{
+		{Start: 0, End: 5460, Addr: "a:7000"},
+		{Start: 5461, End: 10922, Addr: "b:7001"},
+		{Start: 10923, End: 16000, Addr: "a:7000"},
+		{Start: 16001, End: 16383, Addr: ""},
+	}
+	if got := cc.SlotRanges(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("SlotRanges() = %+v, want %+v", got, want)
+	}
+}
+
+// dropAfterRead serves PING and hangs up on every other command after
+// reading it, counting how many it received.
+func dropAfterRead(t *testing.T) (addr string, received *atomic.Int32) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received = &atomic.Int32{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					req, err := DecodeReply(r)
+					if err != nil {
+						return
+					}
+					args, _ := req.([]interface{})
+					if len(args) > 0 {
+						if name, _ := ToString(args[0]); name == "PING" {
+							conn.Write([]byte("+PONG\r\n"))
+							continue
+						}
+					}
+					received.Add(1)
+					return
+				}
+			}(conn)
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestDoDoesNotResendAfterLostReply(t *testing.T) {
+	addr, received := dropAfterRead(t)
+	cc, err := DialStandalone(context.Background(), addr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	_, err = cc.Do("INCR", "counter")
+	if err == nil {
+		t.Fatal("INCR succeeded against a server that dropped the connection")
+	}
+	if !IsUnknownOutcome(err) {
+		t.Fatalf("err = %v, want an unknown-outcome error", err)
+	}
+	if IsRetryableError(err) {
+		t.Fatalf("IsRetryableError(%v) = true", err)
+	}
+	if n := received.Load(); n != 1 {
+		t.Fatalf("server received INCR %d times, want 1", n)
+	}
+
+	// Reads are safe to resend.
+	received.Store(0)
+	if _, err := cc.Do("GET", "counter"); err == nil {
+		t.Fatal("GET succeeded against a server that dropped the connection")
+	}
+	if n := received.Load(); n != doMaxAttempts {
+		t.Fatalf("server received GET %d times, want %d", n, doMaxAttempts)
+	}
+}
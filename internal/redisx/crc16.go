@@ -48,6 +48,15 @@ func CRC16(data []byte) uint16 {
 
 // Slot returns the Redis Cluster slot for a key.
 func Slot(key string) uint16 {
+	if tag, ok := HashTag(key); ok {
+		key = tag
+	}
+	return CRC16([]byte(key)) % 16384
+}
+
+// HashTag returns the non-empty {tag} that Redis Cluster hashes instead of the
+// whole key, if the key has one.
+func HashTag(key string) (string, bool) {
 	start := -1
 	end := -1
 	for i := 0; i < len(key); i++ {
@@ -59,7 +68,7 @@ func Slot(key string) uint16 {
 		}
 	}
 	if start != -1 && end > start+1 {
-		key = key[start+1 : end]
+		return key[start+1 : end], true
 	}
-	return CRC16([]byte(key)) % 16384
+	return "", false
 }
@@ -0,0 +1,61 @@
+package redisx
+
+import (
+	"context"
+	"log"
+	"net"
+	"time"
+)
+
+// dnsLookupTimeout bounds the lookup of a periodic DNS re-check.
+const dnsLookupTimeout = 2 * time.Second
+
+// peerMoved reports whether the host name c was dialed with no longer
+// resolves to the IP it is connected to (a k8s service or failover DNS name
+// that moved). Clients dialed by IP, and lookups that fail, never count as
+// moved: a DNS outage must not tear down working connections.
+func (c *Client) peerMoved() bool {
+	host, _, err := net.SplitHostPort(c.addr)
+	if err != nil || net.ParseIP(host) != nil {
+		return false
+	}
+	peer, _, err := net.SplitHostPort(c.conn.RemoteAddr().String())
+	if err != nil {
+		return false
+	}
+	peerIP := net.ParseIP(peer)
+
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return false
+	}
+	for _, a := range addrs {
+		if net.ParseIP(a).Equal(peerIP) {
+			return false
+		}
+	}
+	return true
+}
+
+// dnsStale re-resolves the name of a node client at most once per
+// ClusterOptions.DNSRefreshInterval and reports whether it moved to another
+// IP, in which case the caller redials. Redials always resolve the name
+// again, so this only matters for connections that are still alive.
+func (cc *ClusterClient) dnsStale(client *Client) bool {
+	interval := cc.opts.DNSRefreshInterval
+	if interval <= 0 {
+		return false
+	}
+	now := time.Now().UnixNano()
+	last := client.dnsCheckedAt.Load()
+	if now-last < int64(interval) || !client.dnsCheckedAt.CompareAndSwap(last, now) {
+		return false
+	}
+	if !client.peerMoved() {
+		return false
+	}
+	log.Printf("[Cluster] %s now resolves away from %s, reconnecting", client.addr, client.conn.RemoteAddr())
+	return true
+}
@@ -0,0 +1,38 @@
+package redisx
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDNSStaleIgnoresIPAddresses(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Client{addr: ln.Addr().String(), conn: conn}
+	defer c.Close()
+
+	if c.peerMoved() {
+		t.Fatal("client dialed by IP reported as moved")
+	}
+	cc := &ClusterClient{opts: ClusterOptions{DNSRefreshInterval: time.Millisecond}}
+	if cc.dnsStale(c) {
+		t.Fatal("dnsStale reported an IP-addressed client as stale")
+	}
+	if c.dnsCheckedAt.Load() == 0 {
+		t.Fatal("dnsStale did not record the check time")
+	}
+	cc.opts.DNSRefreshInterval = 0
+	before := c.dnsCheckedAt.Load()
+	cc.dnsStale(c)
+	if c.dnsCheckedAt.Load() != before {
+		t.Fatal("dnsStale checked with the refresh disabled")
+	}
+}
@@ -0,0 +1,86 @@
+package redisx
+
+import (
+	"errors"
+	"strings"
+)
+
+// ReplyError is an error reply ("-CODE message") from the server.
+type ReplyError struct {
+	Msg string // reply text without the leading '-'
+}
+
+func (e *ReplyError) Error() string {
+	return "redis: " + e.Msg
+}
+
+// Code returns the first word of the reply, e.g. "MOVED" or "CROSSSLOT".
+func (e *ReplyError) Code() string {
+	code, _, _ := strings.Cut(e.Msg, " ")
+	return code
+}
+
+// Is matches a ReplyError against the Err* classes below by error code, so
+// errors.Is(err, ErrCrossSlot) works through any wrapping.
+func (e *ReplyError) Is(target error) bool {
+	c, ok := target.(replyClass)
+	return ok && e.Code() == string(c)
+}
+
+// replyClass is an error code that a ReplyError can be matched against.
+type replyClass string
+
+func (c replyClass) Error() string {
+	return "redis: " + string(c)
+}
+
+// Error reply classes that callers handle differently from a generic failure.
+var (
+	ErrMoved       error = replyClass("MOVED")       // slot owned by another node
+	ErrAsk         error = replyClass("ASK")         // slot is migrating
+	ErrReadOnly    error = replyClass("READONLY")    // node is a replica, topology is stale
+	ErrClusterDown error = replyClass("CLUSTERDOWN") // cluster can't serve the slot right now
+	ErrTryAgain    error = replyClass("TRYAGAIN")    // multi-key command during resharding
+	ErrCrossSlot   error = replyClass("CROSSSLOT")   // keys hash to different slots; never succeeds
+	ErrLoading     error = replyClass("LOADING")     // dataset still loading
+	ErrMasterDown  error = replyClass("MASTERDOWN")  // replica lost its master
+	ErrBusy        error = replyClass("BUSY")        // script running
+)
+
+// IsReplyError reports whether err is (or wraps) an error reply from the
+// server rather than a transport or protocol failure.
+func IsReplyError(err error) bool {
+	var re *ReplyError
+	return errors.As(err, &re)
+}
+
+// unknownOutcomeError is a transport failure that hit after a command was
+// fully written: the server may or may not have executed it.
+type unknownOutcomeError struct {
+	err error
+}
+
+func (e *unknownOutcomeError) Error() string {
+	return "redisx: reply lost after the command was sent: " + e.err.Error()
+}
+
+func (e *unknownOutcomeError) Unwrap() error {
+	return e.err
+}
+
+// IsUnknownOutcome reports whether err means the command reached the server
+// but its reply was lost. Re-sending a non-idempotent command (INCR, LPUSH,
+// APPEND...) after such an error may apply it twice.
+func IsUnknownOutcome(err error) bool {
+	var u *unknownOutcomeError
+	return errors.As(err, &u)
+}
+
+// afterWrite classifies an error from reading the reply to a command that
+// was already written.
+func afterWrite(err error) error {
+	if err == nil || IsReplyError(err) {
+		return err
+	}
+	return &unknownOutcomeError{err: err}
+}
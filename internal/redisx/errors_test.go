@@ -0,0 +1,46 @@
+package redisx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestReplyErrorClasses(t *testing.T) {
+	cases := []struct {
+		reply     string
+		class     error
+		retryable bool
+	}{
+		{"-MOVED 3999 127.0.0.1:6381\r\n", ErrMoved, false},
+		{"-READONLY You can't write against a read only replica.\r\n", ErrReadOnly, true},
+		{"-CLUSTERDOWN The cluster is down\r\n", ErrClusterDown, true},
+		{"-CROSSSLOT Keys in request don't hash to the same slot\r\n", ErrCrossSlot, false},
+		{"-BUSY Redis is busy running a script\r\n", ErrBusy, true},
+		{"-BUSYKEY Target key name already exists.\r\n", nil, false},
+		{"-ERR unknown command\r\n", nil, false},
+	}
+	classes := []error{ErrMoved, ErrAsk, ErrReadOnly, ErrClusterDown, ErrTryAgain, ErrCrossSlot, ErrLoading, ErrMasterDown, ErrBusy}
+	for _, c := range cases {
+		_, err := DecodeReply(bufio.NewReader(strings.NewReader(c.reply)))
+		if !IsReplyError(err) {
+			t.Fatalf("%q: %v is not a reply error", c.reply, err)
+		}
+		// Classification must survive wrapping, as ClusterClient.Do wraps errors
+		wrapped := fmt.Errorf("SET failed: %w", err)
+		for _, class := range classes {
+			if got := errors.Is(wrapped, class); got != (class == c.class) {
+				t.Errorf("%q: errors.Is(%v) = %v", c.reply, class, got)
+			}
+		}
+		if got := IsRetryableError(wrapped); got != c.retryable {
+			t.Errorf("%q: IsRetryableError = %v, want %v", c.reply, got, c.retryable)
+		}
+	}
+
+	if IsReplyError(errors.New("i/o timeout")) || !IsRetryableError(errors.New("i/o timeout")) {
+		t.Error("transport errors should be retryable and not reply errors")
+	}
+}
@@ -0,0 +1,91 @@
+package redisx
+
+// MatchGlob reports whether key matches pattern using Redis KEYS/SCAN MATCH
+// syntax: '*', '?', '[abc]', '[^a-z]' and '\' escapes. Unlike path.Match,
+// '/' has no special meaning.
+func MatchGlob(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if MatchGlob(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+			p := pattern[1:]
+			negate := len(p) > 0 && p[0] == '^'
+			if negate {
+				p = p[1:]
+			}
+			matched := false
+			for len(p) > 0 && p[0] != ']' {
+				switch {
+				case p[0] == '\\' && len(p) > 1:
+					if p[1] == key[0] {
+						matched = true
+					}
+					p = p[2:]
+				case len(p) > 2 && p[1] == '-':
+					// Like Redis, "x-]" is a range ending at ']', not 'x' and '-'
+					lo, hi := p[0], p[2]
+					if lo > hi {
+						lo, hi = hi, lo
+					}
+					if key[0] >= lo && key[0] <= hi {
+						matched = true
+					}
+					p = p[3:]
+				default:
+					if p[0] == key[0] {
+						matched = true
+					}
+					p = p[1:]
+				}
+			}
+			if negate {
+				matched = !matched
+			}
+			if !matched {
+				return false
+			}
+			if len(p) > 0 {
+				p = p[1:] // skip ']'
+			}
+			pattern = p
+			key = key[1:]
+
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(key) == 0
+}
@@ -0,0 +1,68 @@
+package redisx
+
+import "testing"
+
+// TestMatchGlob follows Redis' stringmatchlen (util.c), the matcher behind
+// KEYS, SCAN MATCH and PSUBSCRIBE.
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, key string
+		want         bool
+	}{
+		// '*'
+		{"*", "anything", true},
+		{"*", "", true}, // stringmatchlen says no, but KEYS/SCAN MATCH special-case a lone '*'
+		{"user:*", "user:", true},
+		{"user:*", "user:42", true},
+		{"user:*", "users:42", false},
+		{"*:session", "a:b:session", true},
+		{"a**b", "axxb", true},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "acb", false},
+		{"a/*", "a/b/c", true}, // '/' is not special
+
+		// '?'
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"?", "", false},
+		{"??", "ab", true},
+
+		// classes and ranges
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"[a-z]", "m", true},
+		{"[a-z]", "M", false},
+		{"[z-a]", "m", true}, // reversed bounds are swapped
+		{"[a-]", "_", true},  // "a-]" is the range ']'..'a' and leaves the class unclosed
+		{"[a-]", "-", false},
+		{"[]", "a", false},
+		{"[^]", "a", true},
+
+		// negation
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"[^a-c]x", "dx", true},
+		{"[^a-c]x", "bx", false},
+
+		// escapes
+		{`\*`, "*", true},
+		{`\*`, "a", false},
+		{`a\?b`, "a?b", true},
+		{`a\?b`, "axb", false},
+		{`[\]]`, "]", true},
+		{`[\-a]`, "-", true},
+		{`[\-a]`, "b", false},
+		{`ab\`, `ab\`, true}, // trailing backslash is literal
+
+		// unclosed '['
+		{"[abc", "b", true},
+		{"[abc", "bc", false},
+		{"x[", "x", false},
+		{"[^a", "b", true},
+	}
+	for _, tc := range cases {
+		if got := MatchGlob(tc.pattern, tc.key); got != tc.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tc.pattern, tc.key, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package redisx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// pipelineTimeout bounds the write and the reads of one pipeline round trip;
+// large batches (500+ commands) need more than the per-command timeout.
+const pipelineTimeout = 60 * time.Second
+
+// Pipeline buffers commands and sends them to the server in one write, then
+// reads every reply in order:
+//
+//	p := client.NewPipeline()
+//	p.Add("SET", "k1", "v1")
+//	p.Add("HSET", "h", "f", "v")
+//	replies, errs, err := p.Exec()
+//
+// A Pipeline is not safe for concurrent use; the Client it came from is.
+type Pipeline struct {
+	c    *Client
+	buf  bytes.Buffer
+	cmds []pipelineCmd
+	err  error // first Add failure, reported by Exec
+}
+
+type pipelineCmd struct {
+	name string
+	args []interface{}
+}
+
+// NewPipeline starts an empty pipeline on c.
+func (c *Client) NewPipeline() *Pipeline {
+	return &Pipeline{c: c}
+}
+
+// Add queues a command. Encoding errors (e.g. an unsupported argument type)
+// are reported by Exec.
+func (p *Pipeline) Add(cmd string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	if err := EncodeCommand(&p.buf, cmd, args...); err != nil {
+		p.err = fmt.Errorf("redisx: failed to encode command %s: %w", cmd, err)
+		return
+	}
+	p.cmds = append(p.cmds, pipelineCmd{name: cmd, args: args})
+}
+
+// Len returns the number of queued commands.
+func (p *Pipeline) Len() int {
+	return len(p.cmds)
+}
+
+// Exec sends the queued commands and reads their replies. replies and errs
+// are parallel to the queued commands: an error reply (e.g. WRONGTYPE) is a
+// *ReplyError in errs with a nil reply, and doesn't stop the others. err is
+// only set when the round trip itself failed (closed client, encoding,
+// network or protocol error); the connection should not be reused then.
+// The pipeline is empty again after Exec.
+func (p *Pipeline) Exec() (replies []interface{}, errs []error, err error) {
+	defer p.reset()
+	if p.err != nil {
+		return nil, nil, p.err
+	}
+	c := p.c
+	if c.closed.Load() != 0 {
+		return nil, nil, errors.New("redisx: client closed")
+	}
+	if len(p.cmds) == 0 {
+		return []interface{}{}, []error{}, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tracer := c.tracer.Load()
+	for _, cmd := range p.cmds {
+		tracer.trace(c.addr, cmd.name, cmd.args)
+	}
+	if err := c.conn.SetWriteDeadline(time.Now().Add(pipelineTimeout)); err != nil {
+		return nil, nil, err
+	}
+	if _, err := c.conn.Write(p.buf.Bytes()); err != nil {
+		return nil, nil, fmt.Errorf("redisx: failed to write pipeline: %w", err)
+	}
+	if err := c.conn.SetReadDeadline(time.Now().Add(pipelineTimeout)); err != nil {
+		return nil, nil, err
+	}
+
+	replies = make([]interface{}, len(p.cmds))
+	errs = make([]error, len(p.cmds))
+	for i := range p.cmds {
+		reply, err := c.readReply()
+		if err != nil && !IsReplyError(err) {
+			return nil, nil, fmt.Errorf("redisx: failed to read reply for command %d: %w", i, afterWrite(err))
+		}
+		replies[i], errs[i] = reply, err
+	}
+	return replies, errs, nil
+}
+
+func (p *Pipeline) reset() {
+	p.buf.Reset()
+	p.cmds = p.cmds[:0]
+	p.err = nil
+}
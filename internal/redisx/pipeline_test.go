@@ -0,0 +1,89 @@
+package redisx
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// pipeClient returns a Client talking to serve over an in-memory connection.
+func pipeClient(t *testing.T, serve func(r *bufio.Reader, w net.Conn)) *Client {
+	t.Helper()
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		serve(bufio.NewReader(server), server)
+	}()
+	c := &Client{addr: "pipe", conn: client, reader: bufio.NewReader(client), timeout: time.Second}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestPipelineExecPerCommandErrors(t *testing.T) {
+	got := make(chan []interface{}, 1)
+	c := pipeClient(t, func(r *bufio.Reader, w net.Conn) {
+		var cmds []interface{}
+		for i := 0; i < 3; i++ {
+			cmd, err := DecodeReply(r)
+			if err != nil {
+				return
+			}
+			cmds = append(cmds, cmd)
+		}
+		got <- cmds
+		w.Write([]byte("+OK\r\n-WRONGTYPE Operation against a key holding the wrong kind of value\r\n:5\r\n"))
+	})
+
+	p := c.NewPipeline()
+	p.Add("SET", "k", "v")
+	p.Add("HSET", "k", "f", "v")
+	p.Add("INCRBY", "n", 5)
+	if p.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", p.Len())
+	}
+	replies, errs, err := p.Exec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Len() != 0 {
+		t.Errorf("pipeline not reset after Exec")
+	}
+
+	cmds := <-got
+	if len(cmds) != 3 || !reflect.DeepEqual(cmds[2], []interface{}{"INCRBY", "n", "5"}) {
+		t.Errorf("server received %v", cmds)
+	}
+	if replies[0] != "OK" || errs[0] != nil {
+		t.Errorf("command 0: reply=%v err=%v", replies[0], errs[0])
+	}
+	var re *ReplyError
+	if replies[1] != nil || !errors.As(errs[1], &re) || re.Code() != "WRONGTYPE" {
+		t.Errorf("command 1: reply=%v err=%v, want a WRONGTYPE reply error", replies[1], errs[1])
+	}
+	if replies[2] != int64(5) || errs[2] != nil {
+		t.Errorf("command 2: reply=%#v err=%v", replies[2], errs[2])
+	}
+}
+
+func TestPipelineKeepsConnectionUsableAfterErrorReply(t *testing.T) {
+	c := pipeClient(t, func(r *bufio.Reader, w net.Conn) {
+		for i := 0; i < 2; i++ {
+			DecodeReply(r)
+		}
+		w.Write([]byte("-ERR first\r\n+OK\r\n"))
+		DecodeReply(r)
+		w.Write([]byte("+PONG\r\n"))
+	})
+
+	if _, err := c.Pipeline([][]interface{}{{"BAD"}, {"SET", "k", "v"}}); err == nil {
+		t.Fatal("Pipeline must report the error reply")
+	}
+	// The +OK of the second command must not be mistaken for the PING reply
+	reply, err := c.Do("PING")
+	if err != nil || reply != "PONG" {
+		t.Fatalf("PING after pipeline = %v, %v", reply, err)
+	}
+}
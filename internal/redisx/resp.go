@@ -0,0 +1,143 @@
+package redisx
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// EncodeCommand writes cmd and args to w as a RESP array of bulk strings in
+// a single Write. The command name is upper-cased; arguments are formatted
+// with formatArg.
+func EncodeCommand(w io.Writer, cmd string, args ...interface{}) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", 1+len(args))
+	writeBulk(&buf, strings.ToUpper(cmd))
+	for _, arg := range args {
+		writeBulk(&buf, formatArg(arg))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// DecodeReply reads one RESP2 reply from r. Simple and bulk strings decode
+// to string, integers to int64, arrays to []interface{} and nil bulk strings
+// or arrays to nil. Error replies are returned as a *ReplyError.
+func DecodeReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch line {
+	case '+':
+		str, err := readLine(r)
+		return str, err
+	case '-':
+		msg, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &ReplyError{Msg: msg}
+	case ':':
+		numStr, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		val, err := strconv.ParseInt(numStr, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return val, nil
+	case '$':
+		sizeStr, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(sizeStr)
+		if err != nil {
+			return nil, err
+		}
+		if size == -1 {
+			return nil, nil
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("redisx: invalid bulk length %d", size)
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		countStr, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		count, err := strconv.Atoi(countStr)
+		if err != nil {
+			return nil, err
+		}
+		if count == -1 {
+			return nil, nil
+		}
+		if count < 0 {
+			return nil, fmt.Errorf("redisx: invalid array length %d", count)
+		}
+		result := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			item, err := DecodeReply(r)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, item)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("redisx: unexpected RESP prefix %q", line)
+	}
+}
+
+func writeBulk(buf *bytes.Buffer, value string) {
+	fmt.Fprintf(buf, "$%d\r\n%s\r\n", len(value), value)
+}
+
+func formatArg(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(arg)
+	}
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return line, nil
+}
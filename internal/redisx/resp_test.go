@@ -0,0 +1,112 @@
+package redisx
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeCommand(t *testing.T) {
+	cases := []struct {
+		cmd  string
+		args []interface{}
+		want string
+	}{
+		{"ping", nil, "*1\r\n$4\r\nPING\r\n"},
+		{"set", []interface{}{"k", "v"}, "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n"},
+		{"SET", []interface{}{"k", ""}, "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$0\r\n\r\n"},
+		{"SET", []interface{}{"k", []byte("a\r\nb")}, "*3\r\n$3\r\nSET\r\n$1\r\nk\r\n$4\r\na\r\nb\r\n"},
+		{"EXPIRE", []interface{}{"k", 10}, "*3\r\n$6\r\nEXPIRE\r\n$1\r\nk\r\n$2\r\n10\r\n"},
+		{"INCRBY", []interface{}{"k", int64(-5)}, "*3\r\n$6\r\nINCRBY\r\n$1\r\nk\r\n$2\r\n-5\r\n"},
+		{"INCRBYFLOAT", []interface{}{"k", 1.5}, "*3\r\n$11\r\nINCRBYFLOAT\r\n$1\r\nk\r\n$3\r\n1.5\r\n"},
+		{"X", []interface{}{true, false, uint64(7)}, "*4\r\n$1\r\nX\r\n$1\r\n1\r\n$1\r\n0\r\n$1\r\n7\r\n"},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := EncodeCommand(&buf, c.cmd, c.args...); err != nil {
+			t.Fatalf("%s: %v", c.cmd, err)
+		}
+		if buf.String() != c.want {
+			t.Errorf("EncodeCommand(%s %v) = %q, want %q", c.cmd, c.args, buf.String(), c.want)
+		}
+	}
+}
+
+func TestDecodeReply(t *testing.T) {
+	cases := []struct {
+		name    string
+		in      string
+		want    interface{}
+		wantErr string
+	}{
+		{"simple string", "+OK\r\n", "OK", ""},
+		{"empty simple string", "+\r\n", "", ""},
+		{"error", "-ERR unknown command\r\n", nil, "redis: ERR unknown command"},
+		{"integer", ":42\r\n", int64(42), ""},
+		{"negative integer", ":-1\r\n", int64(-1), ""},
+		{"bulk string", "$5\r\nhello\r\n", "hello", ""},
+		{"binary bulk string", "$4\r\na\r\nb\r\n", "a\r\nb", ""},
+		{"empty bulk string", "$0\r\n\r\n", "", ""},
+		{"nil bulk string", "$-1\r\n", nil, ""},
+		{"nil array", "*-1\r\n", nil, ""},
+		{"empty array", "*0\r\n", []interface{}{}, ""},
+		{"mixed array", "*3\r\n:1\r\n$1\r\na\r\n$-1\r\n", []interface{}{int64(1), "a", nil}, ""},
+		{"nested array", "*2\r\n$1\r\n0\r\n*2\r\n$1\r\nk\r\n*1\r\n+x\r\n",
+			[]interface{}{"0", []interface{}{"k", []interface{}{"x"}}}, ""},
+		{"error inside array", "*2\r\n+OK\r\n-WRONGTYPE bad\r\n", nil, "redis: WRONGTYPE bad"},
+		{"unknown prefix", "?x\r\n", nil, "unexpected RESP prefix"},
+		{"bad integer", ":abc\r\n", nil, "invalid syntax"},
+		{"bad bulk length", "$-2\r\n", nil, "invalid bulk length"},
+		{"bad array length", "*-5\r\n", nil, "invalid array length"},
+		{"truncated bulk", "$5\r\nhel", nil, "unexpected EOF"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := DecodeReply(bufio.NewReader(strings.NewReader(c.in)))
+			if c.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), c.wantErr) {
+					t.Fatalf("err = %v, want %q", err, c.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("got %#v, want %#v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestDecodeReplySequence(t *testing.T) {
+	// Pipelined replies are read back to back from the same reader
+	r := bufio.NewReader(strings.NewReader("+OK\r\n:1\r\n$1\r\nv\r\n"))
+	for _, want := range []interface{}{"OK", int64(1), "v"} {
+		got, err := DecodeReply(r)
+		if err != nil || got != want {
+			t.Fatalf("got %#v, %v; want %#v", got, err, want)
+		}
+	}
+	if _, err := DecodeReply(r); err != io.EOF {
+		t.Fatalf("err = %v, want io.EOF", err)
+	}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeCommand(&buf, "hset", "h", "f", 3); err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeReply(bufio.NewReader(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []interface{}{"HSET", "h", "f", "3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
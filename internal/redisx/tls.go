@@ -0,0 +1,48 @@
+package redisx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// tlsConfig builds the client TLS settings for cfg.Addr.
+func (cfg Config) tlsConfig() (*tls.Config, error) {
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		host = cfg.Addr
+	}
+	tc := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: cfg.TLSInsecure,
+	}
+	if cfg.TLSCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("redisx: read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("redisx: no certificates found in CA bundle " + cfg.TLSCAFile)
+		}
+		tc.RootCAs = pool
+	}
+	return tc, nil
+}
+
+// wrapTLS upgrades an established TCP connection and completes the handshake.
+func wrapTLS(ctx context.Context, conn net.Conn, cfg Config) (net.Conn, error) {
+	tc, err := cfg.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, tc)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("redisx: TLS handshake with %s failed: %w", cfg.Addr, err)
+	}
+	return tlsConn, nil
+}
@@ -0,0 +1,96 @@
+package redisx
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CommandTracer records every command a client sends, one line per command:
+//
+//	<unix-ms> <addr> <COMMAND> <quoted first arg> args=<count>
+//
+// Only the first argument (the key for data commands) is kept; values are
+// redacted so the file can be shared without exposing data. Lines are
+// buffered and flushed every traceFlushInterval, so `tail -f` follows a live
+// run and a crash loses at most that much.
+type CommandTracer struct {
+	mu     sync.Mutex
+	f      *os.File
+	w      *bufio.Writer
+	closed bool
+	done   chan struct{}
+}
+
+// traceFlushInterval is how often buffered trace lines are written out.
+var traceFlushInterval = time.Second
+
+// NewCommandTracer appends trace lines to path.
+func NewCommandTracer(path string) (*CommandTracer, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("redisx: open trace file: %w", err)
+	}
+	t := &CommandTracer{f: f, w: bufio.NewWriterSize(f, 64*1024), done: make(chan struct{})}
+	go t.flushLoop()
+	return t, nil
+}
+
+func (t *CommandTracer) flushLoop() {
+	ticker := time.NewTicker(traceFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.mu.Lock()
+			if !t.closed && t.w.Buffered() > 0 {
+				t.w.Flush()
+			}
+			t.mu.Unlock()
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func (t *CommandTracer) trace(addr, cmd string, args []interface{}) {
+	if t == nil {
+		return
+	}
+	key := "-"
+	if len(args) > 0 {
+		key = strconv.Quote(formatArg(args[0]))
+	}
+	line := strconv.FormatInt(time.Now().UnixMilli(), 10) + " " + addr + " " +
+		strings.ToUpper(cmd) + " " + key + " args=" + strconv.Itoa(len(args)) + "\n"
+
+	t.mu.Lock()
+	if !t.closed {
+		t.w.WriteString(line)
+	}
+	t.mu.Unlock()
+}
+
+// Close flushes buffered lines and closes the file. Commands traced after
+// Close are dropped.
+func (t *CommandTracer) Close() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	close(t.done)
+	if err := t.w.Flush(); err != nil {
+		t.f.Close()
+		return err
+	}
+	return t.f.Close()
+}
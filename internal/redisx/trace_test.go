@@ -0,0 +1,46 @@
+package redisx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandTracerFlushesWhileOpen(t *testing.T) {
+	defer func(d time.Duration) { traceFlushInterval = d }(traceFlushInterval)
+	traceFlushInterval = 10 * time.Millisecond
+
+	path := filepath.Join(t.TempDir(), "trace.log")
+	tracer, err := NewCommandTracer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer.trace("10.0.0.1:6379", "set", []interface{}{"user:1", "secret-value"})
+
+	// The line must reach the file without Close
+	deadline := time.Now().Add(2 * time.Second)
+	var data []byte
+	for time.Now().Before(deadline) {
+		if data, _ = os.ReadFile(path); len(data) > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	line := string(data)
+	if !strings.HasSuffix(line, ` 10.0.0.1:6379 SET "user:1" args=2`+"\n") {
+		t.Fatalf("trace file = %q", line)
+	}
+	if strings.Contains(line, "secret-value") {
+		t.Fatalf("trace file leaks the value: %q", line)
+	}
+
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracer.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+	tracer.trace("10.0.0.1:6379", "GET", []interface{}{"user:1"}) // dropped, must not panic
+}
@@ -0,0 +1,51 @@
+package replica
+
+import "strings"
+
+// commandFilter decides whether a journal command may be replayed on the target.
+// Entries are either a command name ("DEBUG") or a command plus subcommand
+// ("CONFIG SET"); matching is case-insensitive.
+type commandFilter struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+func newCommandFilter(allow, deny []string) *commandFilter {
+	return &commandFilter{
+		allow: commandSet(allow),
+		deny:  commandSet(deny),
+	}
+}
+
+func commandSet(cmds []string) map[string]bool {
+	if len(cmds) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(cmds))
+	for _, c := range cmds {
+		c = strings.Join(strings.Fields(strings.ToUpper(c)), " ")
+		if c != "" {
+			set[c] = true
+		}
+	}
+	return set
+}
+
+// Allowed reports whether cmd (upper-cased) with args may be replayed.
+// The deny list takes precedence over the allow list.
+func (f *commandFilter) Allowed(cmd string, args []string) bool {
+	if f == nil {
+		return true
+	}
+	sub := ""
+	if len(args) > 0 {
+		sub = cmd + " " + strings.ToUpper(args[0])
+	}
+	if f.deny[cmd] || (sub != "" && f.deny[sub]) {
+		return false
+	}
+	if f.allow == nil {
+		return true
+	}
+	return f.allow[cmd] || (sub != "" && f.allow[sub])
+}
@@ -0,0 +1,70 @@
+package replica
+
+import (
+	"fmt"
+	"log"
+
+	"df2redis/internal/redisx"
+)
+
+// filterSnapshotConflicts applies conflict.policy to a chunk of snapshot
+// entries before FlowWriter pipelines them to client, the node that owns
+// their keys. skip and panic check all keys with one pipelined EXISTS; skip
+// drops the keys that exist, panic aborts the run on the first one. It is a
+// no-op for overwrite and while skip acts as overwrite on an empty target.
+func (r *Replicator) filterSnapshotConflicts(client *redisx.Client, entries []*RDBEntry) ([]*RDBEntry, error) {
+	policy := r.cfg.Conflict.Policy
+	if (policy != "skip" && policy != "panic") || (policy == "skip" && r.skipAsOverwrite.Load()) {
+		return entries, nil
+	}
+
+	cmds := make([][]interface{}, len(entries))
+	for i, entry := range entries {
+		cmds[i] = []interface{}{"EXISTS", entry.Key}
+	}
+	replies, err := client.Pipeline(cmds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check key existence: %w", err)
+	}
+
+	kept := make([]*RDBEntry, 0, len(entries))
+	for i, entry := range entries {
+		// A nil reply means absent, as in checkKeyConflict
+		var exists int64
+		if replies[i] != nil {
+			if exists, err = redisx.ToInt64(replies[i]); err != nil {
+				return nil, fmt.Errorf("EXISTS command returned unexpected reply: %w", err)
+			}
+		}
+		if exists == 0 {
+			kept = append(kept, entry)
+			continue
+		}
+		if policy == "panic" {
+			return nil, r.abortOnConflict(entry.Key)
+		}
+		log.Printf("  ⚠️ Skipping duplicate key: %s (policy=skip)", quoteArg(entry.Key))
+	}
+	return kept, nil
+}
+
+// abortOnConflict stops the run on a duplicate key under conflict.policy=panic.
+// The first key found is reported by conflictErr.
+func (r *Replicator) abortOnConflict(key string) error {
+	err := fmt.Errorf("duplicate key detected: %s", key)
+	r.conflictMu.Lock()
+	defer r.conflictMu.Unlock()
+	if r.conflictAbort == nil {
+		r.conflictAbort = err
+		log.Printf("  ⚠️ Duplicate key detected: %s (policy=panic, aborting)", quoteArg(key))
+		r.cancel()
+	}
+	return err
+}
+
+// conflictErr returns the duplicate key that aborted the run, if any.
+func (r *Replicator) conflictErr() error {
+	r.conflictMu.Lock()
+	defer r.conflictMu.Unlock()
+	return r.conflictAbort
+}
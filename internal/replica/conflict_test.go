@@ -0,0 +1,87 @@
+package replica
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"df2redis/internal/config"
+	"df2redis/internal/redisx"
+)
+
+// existsTarget answers EXISTS with 1 for keys starting with "old:" and +OK
+// to everything else.
+func existsTarget(args []string) string {
+	if strings.EqualFold(args[0], "EXISTS") {
+		if strings.HasPrefix(args[1], "old:") {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	}
+	return "+OK\r\n"
+}
+
+// nodeClient returns the connection of a single-node ClusterClient.
+func nodeClient(cc *redisx.ClusterClient) *redisx.Client {
+	var client *redisx.Client
+	cc.ForEachMaster(func(c *redisx.Client) error {
+		client = c
+		return nil
+	})
+	return client
+}
+
+func TestFlowWriterAppliesSkipPolicy(t *testing.T) {
+	target, cc := newStubTarget(t, existsTarget)
+	client := nodeClient(cc)
+	cfg := &config.Config{}
+	cfg.Conflict.Policy = "skip"
+	r := &Replicator{cfg: cfg}
+	fw := &FlowWriter{targetType: "redis-standalone", pipelineClient: client, conflictFilter: r.filterSnapshotConflicts}
+
+	entries := []*RDBEntry{
+		{Key: "old:1", Type: RDB_TYPE_STRING, Value: &StringValue{Value: "v"}},
+		{Key: "new:1", Type: RDB_TYPE_STRING, Value: &StringValue{Value: "v"}},
+	}
+	res := fw.writeNodeBatch("", entries)
+	if res.success != 2 || res.failed != 0 {
+		t.Fatalf("result = %+v, want 2 successes (one skipped)", res)
+	}
+	var writes []string
+	for _, cmd := range target.received() {
+		if cmd[0] != "EXISTS" {
+			writes = append(writes, cmd[0]+" "+cmd[1])
+		}
+	}
+	if strings.Join(writes, ",") != "SET new:1" {
+		t.Fatalf("target received writes %q, want only SET new:1", writes)
+	}
+
+	// An empty target at start skips the EXISTS round-trips entirely
+	r.skipAsOverwrite.Store(true)
+	kept, err := r.filterSnapshotConflicts(client, entries)
+	if err != nil || len(kept) != 2 {
+		t.Fatalf("filter with skip-as-overwrite kept %d entries (err %v), want 2", len(kept), err)
+	}
+}
+
+func TestFlowWriterPanicPolicyAborts(t *testing.T) {
+	_, cc := newStubTarget(t, existsTarget)
+	client := nodeClient(cc)
+	cfg := &config.Config{}
+	cfg.Conflict.Policy = "panic"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &Replicator{cfg: cfg, ctx: ctx, cancel: cancel}
+
+	_, err := r.filterSnapshotConflicts(client, []*RDBEntry{
+		{Key: "new:1", Type: RDB_TYPE_STRING, Value: &StringValue{Value: "v"}},
+		{Key: "old:1", Type: RDB_TYPE_STRING, Value: &StringValue{Value: "v"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "old:1") {
+		t.Fatalf("filter error = %v, want the duplicate key", err)
+	}
+	if r.conflictErr() == nil || ctx.Err() == nil {
+		t.Fatal("duplicate key under policy=panic did not abort the run")
+	}
+}
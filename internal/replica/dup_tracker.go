@@ -0,0 +1,86 @@
+package replica
+
+import (
+	"hash/maphash"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	dupTrackerShards = 64
+	// dupTrackerMaxLogs bounds the per-key warnings; the total is logged at the end
+	dupTrackerMaxLogs = 100
+)
+
+// dupTracker remembers which FLOW imported each key during one full sync and
+// reports keys that show up on a second FLOW (migrate.duplicateKeyTracking).
+// Each key should live on exactly one Dragonfly shard, so a hit means a
+// later write silently replaced another shard's value.
+//
+// Keys are stored as 64-bit hashes (with the DB index) rather than strings,
+// so a report can in principle be a hash collision; the log says "possible".
+// Once max keys are tracked, new keys are no longer recorded, which keeps the
+// memory bound at roughly 40 bytes per tracked key.
+type dupTracker struct {
+	seed   maphash.Seed
+	max    int64
+	shards [dupTrackerShards]struct {
+		mu   sync.Mutex
+		seen map[uint64]int32 // key hash -> FLOW that imported it
+	}
+
+	tracked    atomic.Int64
+	duplicates atomic.Int64
+	full       atomic.Bool
+}
+
+func newDupTracker(max int) *dupTracker {
+	t := &dupTracker{seed: maphash.MakeSeed(), max: int64(max)}
+	for i := range t.shards {
+		t.shards[i].seen = make(map[uint64]int32)
+	}
+	return t
+}
+
+// observe records that flowID imported key and reports whether another FLOW
+// imported it before. It is safe for concurrent use by all FLOWs.
+func (t *dupTracker) observe(db int, key string, flowID int) bool {
+	var h maphash.Hash
+	h.SetSeed(t.seed)
+	h.WriteString(key)
+	h.WriteByte(byte(db))
+	sum := h.Sum64()
+
+	shard := &t.shards[sum%dupTrackerShards]
+	shard.mu.Lock()
+	prev, seen := shard.seen[sum]
+	if !seen {
+		if t.tracked.Load() < t.max {
+			shard.seen[sum] = int32(flowID)
+			t.tracked.Add(1)
+		} else if t.full.CompareAndSwap(false, true) {
+			log.Printf("  ⚠ Duplicate key tracking reached its limit of %d keys; later keys are not checked", t.max)
+		}
+	}
+	shard.mu.Unlock()
+
+	if !seen || int(prev) == flowID {
+		return false
+	}
+	if n := t.duplicates.Add(1); n <= dupTrackerMaxLogs {
+		log.Printf("  ⚠ Possible duplicate key across FLOWs: key=%s db=%d imported by FLOW-%d and FLOW-%d", key, db, prev, flowID)
+		if n == dupTrackerMaxLogs {
+			log.Printf("  ⚠ Further cross-FLOW duplicates are only counted")
+		}
+	}
+	return true
+}
+
+// Duplicates returns how many keys were imported by more than one FLOW.
+func (t *dupTracker) Duplicates() int64 {
+	if t == nil {
+		return 0
+	}
+	return t.duplicates.Load()
+}
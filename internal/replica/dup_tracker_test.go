@@ -0,0 +1,30 @@
+package replica
+
+import "testing"
+
+func TestDupTracker(t *testing.T) {
+	tr := newDupTracker(3)
+	if tr.observe(0, "a", 0) || tr.observe(0, "b", 1) {
+		t.Fatal("first sighting reported as duplicate")
+	}
+	if tr.observe(0, "a", 0) {
+		t.Error("same FLOW seeing a key again is not a cross-FLOW duplicate")
+	}
+	if !tr.observe(0, "a", 2) {
+		t.Error("key on a second FLOW not reported")
+	}
+	if tr.observe(1, "a", 2) {
+		t.Error("same key name in another DB is a different key")
+	}
+
+	// The limit is reached: new keys are no longer remembered
+	if tr.observe(0, "c", 0) || tr.observe(0, "c", 1) {
+		t.Error("keys past the limit must not be tracked")
+	}
+	if got := tr.Duplicates(); got != 1 {
+		t.Errorf("Duplicates() = %d, want 1", got)
+	}
+	if (*dupTracker)(nil).Duplicates() != 0 {
+		t.Error("nil tracker must report 0")
+	}
+}
@@ -0,0 +1,55 @@
+package replica
+
+import (
+	"log"
+
+	"df2redis/internal/redisx"
+	"df2redis/internal/state"
+)
+
+// targetKeyCount sums DBSIZE over every target master.
+func (r *Replicator) targetKeyCount() (int64, error) {
+	var total int64
+	err := r.clusterClient.ForEachMaster(func(client *redisx.Client) error {
+		reply, err := client.Do("DBSIZE")
+		if err != nil {
+			return err
+		}
+		count, err := redisx.ToInt64(reply)
+		if err != nil {
+			return err
+		}
+		total += count
+		return nil
+	})
+	return total, err
+}
+
+// beginFullSyncConflicts decides how conflict.policy=skip behaves for the
+// full sync. When every target master is empty no key can already exist, so
+// with conflict.emptyTargetOverwrite (default on) the per-key EXISTS check is
+// dropped and keys are written as with overwrite. endFullSyncConflicts
+// restores real skip semantics before the journal is replayed.
+func (r *Replicator) beginFullSyncConflicts() {
+	if r.cfg.Conflict.Policy != "skip" || !r.cfg.Conflict.EmptyTargetOverwriteValue() {
+		return
+	}
+	keys, err := r.targetKeyCount()
+	if err != nil {
+		log.Printf("  ⚠ conflict.policy=skip: cannot count target keys (%v), checking every key", err)
+		return
+	}
+	if keys > 0 {
+		return
+	}
+	r.skipAsOverwrite.Store(true)
+	log.Println("  ✓ Target is empty: conflict.policy=skip writes the full sync without EXISTS checks")
+	r.recordEvent(state.SeverityInfo, "conflict", "Target empty at start; skip policy acts as overwrite until the full sync ends")
+}
+
+// endFullSyncConflicts reverts the promotion made by beginFullSyncConflicts.
+func (r *Replicator) endFullSyncConflicts() {
+	if r.skipAsOverwrite.Swap(false) {
+		log.Println("  ✓ Full sync done: conflict.policy=skip checks keys again")
+	}
+}
@@ -0,0 +1,25 @@
+package replica
+
+import (
+	"testing"
+
+	"df2redis/internal/config"
+)
+
+func TestSkipActsAsOverwriteDuringEmptyFullSync(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Conflict.Policy = "skip"
+	r := &Replicator{cfg: cfg}
+
+	// No target client: any EXISTS check would panic
+	r.skipAsOverwrite.Store(true)
+	write, err := r.checkKeyConflict("k")
+	if err != nil || !write {
+		t.Fatalf("checkKeyConflict = %v, %v; want true, nil", write, err)
+	}
+
+	r.endFullSyncConflicts()
+	if r.skipAsOverwrite.Load() {
+		t.Fatal("promotion still active after the full sync")
+	}
+}
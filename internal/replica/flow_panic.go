@@ -0,0 +1,42 @@
+package replica
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"df2redis/internal/state"
+)
+
+// Phases named in FLOW panic reports.
+const (
+	flowPhaseSnapshot = "receiving the snapshot"
+	flowPhaseFile     = "loading the snapshot file"
+	flowPhaseJournal  = "reading the journal"
+)
+
+// recoverFlow turns a panic in a FLOW goroutine (typically a decoder bug on
+// one malformed value) into an error handed to report, so the run fails
+// like any other FLOW error instead of crashing the process. During the
+// journal phase the checkpoint is saved first: it only covers entries
+// already replayed, so a restart resumes where the target stands. It must
+// be deferred directly by the goroutine.
+func (r *Replicator) recoverFlow(flowID int, phase string, report func(error)) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	err := fmt.Errorf("FLOW-%d: panic while %s: %v", flowID, phase, p)
+	log.Printf("  [FLOW-%d] ✗ %v\n%s", flowID, err, debug.Stack())
+	r.recordFlowStage(flowID, "error", err.Error())
+	r.recordEvent(state.SeverityError, "flow-panic", err.Error())
+
+	if phase == flowPhaseJournal && r.cfg.Checkpoint.Enabled && r.checkpointMgr != nil {
+		if cpErr := r.saveCheckpoint(); cpErr != nil {
+			log.Printf("  [FLOW-%d] ⚠ Checkpoint save after panic failed: %v", flowID, cpErr)
+		} else {
+			log.Printf("  [FLOW-%d] ✓ Checkpoint saved after panic", flowID)
+		}
+	}
+	report(err)
+}
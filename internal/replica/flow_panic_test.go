@@ -0,0 +1,29 @@
+package replica
+
+import (
+	"strings"
+	"testing"
+
+	"df2redis/internal/config"
+)
+
+func TestRecoverFlowReportsPanic(t *testing.T) {
+	r := &Replicator{cfg: &config.Config{}}
+	var got error
+	func() {
+		defer r.recoverFlow(3, flowPhaseSnapshot, func(err error) { got = err })
+		var b []byte
+		_ = b[5] // index out of range, as in a broken listpack decoder
+	}()
+	if got == nil || !strings.Contains(got.Error(), "FLOW-3: panic while receiving the snapshot") {
+		t.Fatalf("reported error = %v", got)
+	}
+
+	got = nil
+	func() {
+		defer r.recoverFlow(3, flowPhaseSnapshot, func(err error) { got = err })
+	}()
+	if got != nil {
+		t.Fatalf("reported %v without a panic", got)
+	}
+}
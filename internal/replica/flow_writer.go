@@ -3,10 +3,13 @@ package replica
 import (
 	"context"
 	"df2redis/internal/redisx"
+	"df2redis/internal/tracing"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -23,11 +26,16 @@ type opsRecord struct {
 	timestamp time.Time
 }
 
+// defaultBatchBytes caps a batch by estimated payload so a run of big keys
+// doesn't turn into one multi-GB pipeline that stalls the target.
+const defaultBatchBytes = 16 * 1024 * 1024 // 16MB
+
 // FlowWriter handles async batched writes for a single flow
 type FlowWriter struct {
 	flowID        int
 	entryChan     chan *RDBEntry
 	batchSize     int
+	batchBytes    int // flush once the estimated payload reaches this many bytes (0 = count only)
 	flushInterval time.Duration
 	writeFn       func(*RDBEntry) error // Function to write an entry
 	opsReporter   func(int)             // Callback to report ops count to global metrics
@@ -48,12 +56,34 @@ type FlowWriter struct {
 	// Concurrency control
 	maxConcurrentWrites int           // Maximum concurrent write goroutines
 	writeSemaphore      chan struct{} // Semaphore to limit concurrency
+	writerPool          *WriterPool   // Optional worker pool shared by all FLOWs (nil = per-FLOW goroutines)
+
+	// Parent context for batch trace spans
+	traceCtx context.Context
+
+	// Optional callback receiving per-batch success/failure counts
+	resultReporter func(success, failed int)
+
+	// Optional callback receiving sets/zsets left half-written
+	partialHandler func(*partialWriteError)
+
+	// Optional callback receiving entries the target rejected as oversize
+	oversizeHandler func(*RDBEntry, error)
+
+	// Optional conflict.policy check run on each pipeline chunk before it is
+	// written; it returns the entries to write
+	conflictFilter func(*redisx.Client, []*RDBEntry) ([]*RDBEntry, error)
+
+	// absoluteTTL writes key TTLs with PEXPIREAT / RESTORE ABSTTL (conflict.ttlMode: absolute)
+	absoluteTTL bool
 
 	// Statistics
 	stats struct {
 		totalReceived int64
 		totalWritten  int64
 		totalBatches  int64
+		totalBytes    int64 // estimated payload bytes of attempted writes
+		writeTime     time.Duration
 		mu            sync.Mutex
 	}
 
@@ -81,7 +111,9 @@ type FlowWriter struct {
 	channelCapacity    int
 	lastMonitorTime    time.Time
 	monitorInterval    time.Duration
-	highWatermarkCount int64 // Count of times channel usage exceeded 80%
+	highWatermarkCount int64        // Count of times channel usage exceeded 80%
+	blockedEnqueues    atomic.Int64 // Enqueue calls that found the channel full
+	blockedNanos       atomic.Int64 // Total time Enqueue spent waiting for room
 
 	// Dynamic Throttling
 	limiter   *rate.Limiter
@@ -149,6 +181,7 @@ func NewFlowWriter(flowID int, writeFn func(*RDBEntry) error, numFlows int, targ
 		flowID:              flowID,
 		entryChan:           make(chan *RDBEntry, channelBuffer),
 		batchSize:           batchSize,
+		batchBytes:          defaultBatchBytes,
 		flushInterval:       time.Duration(flushInterval) * time.Millisecond,
 		writeFn:             writeFn,
 		opsReporter:         opsReporter,
@@ -189,6 +222,43 @@ func NewFlowWriter(flowID int, writeFn func(*RDBEntry) error, numFlows int, targ
 	return fw
 }
 
+// SetWriterPool routes this writer's pipelines through a pool shared by all
+// FlowWriters. Aggregate write concurrency is then bounded by the pool size
+// and idle workers pick up chunks from whichever FLOW is busiest. The per-flow
+// semaphore still bounds how many batches a FLOW holds in memory. Must be
+// called before Start.
+func (fw *FlowWriter) SetWriterPool(pool *WriterPool) {
+	fw.writerPool = pool
+}
+
+// SetTraceContext parents this writer's batch spans under ctx's span.
+func (fw *FlowWriter) SetTraceContext(ctx context.Context) {
+	fw.traceCtx = ctx
+}
+
+// SetResultReporter registers fn to receive the success/failure counts of every flushed batch.
+func (fw *FlowWriter) SetResultReporter(fn func(success, failed int)) {
+	fw.resultReporter = fn
+}
+
+// SetPartialWriteHandler registers fn to receive every set/zset whose member
+// writes failed after some chunks were applied.
+func (fw *FlowWriter) SetPartialWriteHandler(fn func(*partialWriteError)) {
+	fw.partialHandler = fn
+}
+
+// SetOversizeHandler registers fn to receive every entry the target rejected
+// for exceeding its proto-max-bulk-len.
+func (fw *FlowWriter) SetOversizeHandler(fn func(*RDBEntry, error)) {
+	fw.oversizeHandler = fn
+}
+
+// SetConflictFilter registers fn to decide, per pipeline chunk, which
+// entries may be written to the node behind the given client.
+func (fw *FlowWriter) SetConflictFilter(fn func(*redisx.Client, []*RDBEntry) ([]*RDBEntry, error)) {
+	fw.conflictFilter = fn
+}
+
 // Start launches the async write loop
 func (fw *FlowWriter) Start() {
 	fw.wg.Add(1)
@@ -213,17 +283,56 @@ func (fw *FlowWriter) Stop() {
 // With 2M buffer, blocking is acceptable as it provides sufficient backpressure protection
 // If channel somehow fills up (extreme case), we block Parser briefly
 func (fw *FlowWriter) Enqueue(entry *RDBEntry) error {
+	return fw.enqueue(entry, 0)
+}
+
+// errEnqueueTimeout is returned by EnqueueTimeout when the queue stayed full.
+var errEnqueueTimeout = errors.New("flow writer queue full")
+
+// EnqueueTimeout is Enqueue for callers that must not stall behind this
+// writer: it gives up with errEnqueueTimeout once the queue stayed full for wait.
+func (fw *FlowWriter) EnqueueTimeout(entry *RDBEntry, wait time.Duration) error {
+	return fw.enqueue(entry, wait)
+}
+
+// enqueue blocks on a full channel until there is room, the writer stops or,
+// with wait > 0, wait has passed.
+func (fw *FlowWriter) enqueue(entry *RDBEntry, wait time.Duration) error {
 	select {
 	case fw.entryChan <- entry:
-		// Successfully enqueued
-		fw.stats.mu.Lock()
-		fw.stats.totalReceived++
-		fw.stats.mu.Unlock()
-		return nil
-
-	case <-fw.ctx.Done():
-		return fmt.Errorf("flow writer stopped")
+		// Fast path: room in the channel
+	default:
+		// Channel full: the target can't keep up and the FLOW read stalls
+		fw.blockedEnqueues.Add(1)
+		start := time.Now()
+		var expired <-chan time.Time
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			expired = timer.C
+		}
+		select {
+		case fw.entryChan <- entry:
+			fw.blockedNanos.Add(int64(time.Since(start)))
+		case <-fw.ctx.Done():
+			fw.blockedNanos.Add(int64(time.Since(start)))
+			return fmt.Errorf("flow writer stopped")
+		case <-expired:
+			fw.blockedNanos.Add(int64(time.Since(start)))
+			return errEnqueueTimeout
+		}
 	}
+
+	fw.stats.mu.Lock()
+	fw.stats.totalReceived++
+	fw.stats.mu.Unlock()
+	return nil
+}
+
+// GetBackpressure returns the channel occupancy and how often and how long
+// Enqueue blocked because the channel was full.
+func (fw *FlowWriter) GetBackpressure() (queued, capacity int, blocked int64, blockedFor time.Duration) {
+	return len(fw.entryChan), fw.channelCapacity, fw.blockedEnqueues.Load(), time.Duration(fw.blockedNanos.Load())
 }
 
 // GetStats returns current statistics
@@ -234,6 +343,34 @@ func (fw *FlowWriter) GetStats() (received, written, batches int64) {
 
 }
 
+// SetAbsoluteTTL switches key TTLs to absolute timestamps. Must be called before Start.
+func (fw *FlowWriter) SetAbsoluteTTL(absolute bool) {
+	fw.absoluteTTL = absolute
+}
+
+// SetBatchBytes sets the byte threshold that flushes a batch early (0 = count only).
+// Must be called before Start.
+func (fw *FlowWriter) SetBatchBytes(n int) {
+	if n < 0 {
+		n = 0
+	}
+	fw.batchBytes = n
+}
+
+// GetBytesWritten returns the estimated payload bytes flushed so far.
+func (fw *FlowWriter) GetBytesWritten() int64 {
+	fw.stats.mu.Lock()
+	defer fw.stats.mu.Unlock()
+	return fw.stats.totalBytes
+}
+
+// GetWriteTime returns the wall time spent flushing batches to the target.
+func (fw *FlowWriter) GetWriteTime() time.Duration {
+	fw.stats.mu.Lock()
+	defer fw.stats.mu.Unlock()
+	return fw.stats.writeTime
+}
+
 // UpdateConfig updates dynamic parameters thread-safely
 func (fw *FlowWriter) UpdateConfig(qps int, batchSize int) {
 	// Update QPS (Rate Limiter)
@@ -261,11 +398,12 @@ func (fw *FlowWriter) batchWriteLoop() {
 	defer fw.wg.Done()
 
 	batch := make([]*RDBEntry, 0, fw.batchSize)
+	pendingBytes := 0
 	ticker := time.NewTicker(fw.flushInterval)
 	defer ticker.Stop()
 
-	log.Printf("  [FLOW-%d] [WRITER] Async batch writer started (batch=%d, interval=%v)",
-		fw.flowID, fw.batchSize, fw.flushInterval)
+	log.Printf("  [FLOW-%d] [WRITER] Async batch writer started (batch=%d, bytes=%d, interval=%v)",
+		fw.flowID, fw.batchSize, fw.batchBytes, fw.flushInterval)
 
 	// Helper for async flushing
 	fw.asyncFlush = func(batch []*RDBEntry) {
@@ -293,11 +431,15 @@ func (fw *FlowWriter) batchWriteLoop() {
 			}
 
 			batch = append(batch, entry)
+			if fw.batchBytes > 0 {
+				pendingBytes += entry.ApproxSize()
+			}
 
-			// Flush if batch size reached
-			if len(batch) >= fw.batchSize {
+			// Flush if batch size (entries or estimated bytes) reached
+			if len(batch) >= fw.batchSize || (fw.batchBytes > 0 && pendingBytes >= fw.batchBytes) {
 				fw.asyncFlush(batch)
 				batch = make([]*RDBEntry, 0, fw.batchSize) // New batch
+				pendingBytes = 0
 			}
 
 		case <-ticker.C:
@@ -305,6 +447,7 @@ func (fw *FlowWriter) batchWriteLoop() {
 			if len(batch) > 0 {
 				fw.asyncFlush(batch)
 				batch = make([]*RDBEntry, 0, fw.batchSize) // New batch
+				pendingBytes = 0
 			}
 
 		case <-fw.ctx.Done():
@@ -342,6 +485,13 @@ func (fw *FlowWriter) flushBatch(batch []*RDBEntry) {
 
 	start := time.Now()
 	batchSize := len(batch)
+	batchBytes := 0
+	for _, entry := range batch {
+		batchBytes += entry.ApproxSize()
+	}
+
+	_, span := tracing.Start(fw.traceCtx, "write.batch", "flow.id", fw.flowID, "batch.size", batchSize)
+	defer span.End()
 
 	// Log batch start
 	log.Printf("  [FLOW-%d] [WRITER] ⏩ Flushing batch: %d entries", fw.flowID, batchSize)
@@ -362,13 +512,33 @@ func (fw *FlowWriter) flushBatch(batch []*RDBEntry) {
 	var wg sync.WaitGroup
 	resultChan := make(chan writeResult, numGroups)
 
-	for addr, group := range groups {
-		wg.Add(1)
-		go func(nodeAddr string, entries []*RDBEntry) {
-			defer wg.Done()
-			result := fw.writeNodeBatch(nodeAddr, entries)
-			resultChan <- result
-		}(addr, group)
+	if fw.writerPool != nil {
+		// Shared pool: submit pipeline-sized chunks so any free worker can take them
+		numChunks := 0
+		for _, group := range groups {
+			numChunks += (len(group) + maxPipelineSize - 1) / maxPipelineSize
+		}
+		resultChan = make(chan writeResult, numChunks)
+		for addr, group := range groups {
+			for i := 0; i < len(group); i += maxPipelineSize {
+				chunk := group[i:min(i+maxPipelineSize, len(group))]
+				nodeAddr := addr
+				wg.Add(1)
+				fw.writerPool.Submit(func() {
+					defer wg.Done()
+					resultChan <- fw.writeNodeBatch(nodeAddr, chunk)
+				})
+			}
+		}
+	} else {
+		for addr, group := range groups {
+			wg.Add(1)
+			go func(nodeAddr string, entries []*RDBEntry) {
+				defer wg.Done()
+				result := fw.writeNodeBatch(nodeAddr, entries)
+				resultChan <- result
+			}(addr, group)
+		}
 	}
 
 	// Wait for all groups to complete
@@ -388,14 +558,23 @@ func (fw *FlowWriter) flushBatch(batch []*RDBEntry) {
 	fw.stats.mu.Lock()
 	fw.stats.totalWritten += int64(successCount)
 	fw.stats.totalBatches++
+	fw.stats.totalBytes += int64(batchBytes)
+	fw.stats.writeTime += duration
 	fw.stats.mu.Unlock()
 
 	DebugTotalFlushed.Add(int64(successCount))
 
+	if fw.resultReporter != nil {
+		fw.resultReporter(successCount, failCount)
+	}
+
+	span.SetAttributes("nodes", numGroups, "success", successCount, "failed", failCount, "duration_ms", duration)
+
 	// Log performance
 	opsPerSec := float64(batchSize) / duration.Seconds()
-	log.Printf("  [FLOW-%d] [WRITER] ✓ Batch complete: %d entries in %v (%.0f ops/sec, nodes=%d, success=%d, fail=%d)",
-		fw.flowID, batchSize, duration, opsPerSec, numGroups, successCount, failCount)
+	batchMB := float64(batchBytes) / (1024 * 1024)
+	log.Printf("  [FLOW-%d] [WRITER] ✓ Batch complete: %d entries (~%.1f MB) in %v (%.0f ops/sec, %.1f MB/s, nodes=%d, success=%d, fail=%d)",
+		fw.flowID, batchSize, batchMB, duration, opsPerSec, batchMB/duration.Seconds(), numGroups, successCount, failCount)
 
 	// Update performance metrics for dashboard (use real op count, not instantaneous rate)
 	fw.updatePerfMetrics(batchSize, duration.Milliseconds())
@@ -406,6 +585,9 @@ func (fw *FlowWriter) flushBatch(batch []*RDBEntry) {
 	}
 }
 
+// maxPipelineSize caps the number of entries sent in a single pipeline
+const maxPipelineSize = 500
+
 // writeResult holds the result of writing a batch
 type writeResult struct {
 	success int
@@ -440,6 +622,13 @@ func (fw *FlowWriter) writeNodeBatch(addr string, entries []*RDBEntry) writeResu
 
 	if fw.targetType == "redis-standalone" {
 		client = fw.pipelineClient
+		// Look the node up each batch so connections the shared client
+		// replaced (connection errors, target.dnsRefreshSeconds) are picked up
+		if client != nil && fw.clusterClient != nil {
+			if current, err := fw.clusterClient.GetNodeClient(client.Addr()); err == nil {
+				client = current
+			}
+		}
 	} else {
 		// Cluster mode: get client for node
 		client, err = fw.clusterClient.GetNodeClient(addr)
@@ -457,7 +646,6 @@ func (fw *FlowWriter) writeNodeBatch(addr string, entries []*RDBEntry) writeResu
 	// ----------------------------------------------------------------------
 	// Recursively split large batches (same logic as before)
 	// ----------------------------------------------------------------------
-	const maxPipelineSize = 500
 	if len(entries) > maxPipelineSize {
 		for i := 0; i < len(entries); i += maxPipelineSize {
 			end := i + maxPipelineSize
@@ -472,6 +660,20 @@ func (fw *FlowWriter) writeNodeBatch(addr string, entries []*RDBEntry) writeResu
 		return writeResult{success: successCount, failed: failCount}
 	}
 
+	// ----------------------------------------------------------------------
+	// Apply conflict.policy (skipped keys count as written)
+	// ----------------------------------------------------------------------
+	skipped := 0
+	if fw.conflictFilter != nil {
+		kept, err := fw.conflictFilter(client, entries)
+		if err != nil {
+			log.Printf("  [FLOW-%d] [WRITER] ✗ Conflict check failed on %s: %v", fw.flowID, addr, err)
+			return writeResult{failed: len(entries)}
+		}
+		skipped = len(entries) - len(kept)
+		entries = kept
+	}
+
 	// ----------------------------------------------------------------------
 	// Build Pipeline
 	// ----------------------------------------------------------------------
@@ -484,7 +686,7 @@ func (fw *FlowWriter) writeNodeBatch(addr string, entries []*RDBEntry) writeResu
 	}
 
 	if len(cmds) == 0 {
-		return writeResult{success: 0, failed: 0}
+		return writeResult{success: skipped}
 	}
 
 	// Execute Pipeline
@@ -497,7 +699,9 @@ func (fw *FlowWriter) writeNodeBatch(addr string, entries []*RDBEntry) writeResu
 		// Given we want speed, maybe just fail?
 		// Or try individual?
 		// Use sequential fallback just in case.
-		return fw.writeSequential(client, entries)
+		result := fw.writeSequential(client, entries)
+		result.success += skipped
+		return result
 	}
 
 	// Check results
@@ -530,7 +734,7 @@ func (fw *FlowWriter) writeNodeBatch(addr string, entries []*RDBEntry) writeResu
 		}
 	}
 
-	return writeResult{success: successCount, failed: failCount}
+	return writeResult{success: successCount + skipped, failed: failCount}
 }
 
 // writeSequential falls back to writing entries one by one
@@ -538,6 +742,13 @@ func (fw *FlowWriter) writeSequential(client *redisx.Client, entries []*RDBEntry
 	var success, failed int
 	for _, entry := range entries {
 		if err := fw.writeEntryWithClient(client, entry); err != nil {
+			var pe *partialWriteError
+			if errors.As(err, &pe) && fw.partialHandler != nil {
+				fw.partialHandler(pe)
+			}
+			if isOversizeError(err) && fw.oversizeHandler != nil {
+				fw.oversizeHandler(entry, err)
+			}
 			failed++
 		} else {
 			success++
@@ -548,12 +759,27 @@ func (fw *FlowWriter) writeSequential(client *redisx.Client, entries []*RDBEntry
 
 // writeEntryWithClient writes a single entry using specific client
 func (fw *FlowWriter) writeEntryWithClient(client *redisx.Client, entry *RDBEntry) error {
+	if entry.Dump != nil {
+		args := restoreArgs(entry, fw.absoluteTTL)
+		if _, err := client.Do(args[0].(string), args[1:]...); err == nil {
+			return nil
+		} else {
+			// The target rejected the payload; rebuild the value with commands instead
+			log.Printf("  [FLOW-%d] [WRITER] ⚠ RESTORE failed for key=%s (%v), falling back to commands", fw.flowID, entry.Key, err)
+			entry.Dump = nil
+		}
+	}
+
 	cmds := fw.buildCommands(entry)
 	if len(cmds) == 0 {
 		return nil
 	}
 
 	// Execute all commands for this entry (e.g. SET + PEXPIREAT)
+	written, intended := 0, 0
+	for _, cmd := range cmds {
+		intended += commandMembers(cmd)
+	}
 	for _, cmd := range cmds {
 		if len(cmd) == 0 {
 			continue
@@ -561,8 +787,12 @@ func (fw *FlowWriter) writeEntryWithClient(client *redisx.Client, entry *RDBEntr
 		cmdName := fmt.Sprint(cmd[0])
 		args := cmd[1:]
 		if _, err := client.Do(cmdName, args...); err != nil {
+			if written > 0 && written < intended {
+				return &partialWriteError{Key: entry.Key, Written: written, Intended: intended, Err: err}
+			}
 			return err
 		}
+		written += commandMembers(cmd)
 	}
 	return nil
 }
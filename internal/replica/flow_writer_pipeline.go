@@ -1,13 +1,17 @@
 package replica
 
 import (
-	"fmt"
 	"strconv"
 )
 
 // buildCommands constructs Redis commands from an RDB entry for pipeline execution
 // Returns nil if the entry type is not supported for pipeline batching
 func (fw *FlowWriter) buildCommands(entry *RDBEntry) [][]interface{} {
+	// RESTORE carries the TTL itself (migrate.writeMode: auto)
+	if entry.Dump != nil {
+		return [][]interface{}{restoreArgs(entry, fw.absoluteTTL)}
+	}
+
 	var commands [][]interface{}
 
 	// Build main command based on type
@@ -20,7 +24,8 @@ func (fw *FlowWriter) buildCommands(entry *RDBEntry) [][]interface{} {
 			mainCmd = []interface{}{"SET", entry.Key, strVal.Value}
 		}
 
-	case RDB_TYPE_HASH, RDB_TYPE_HASH_ZIPLIST, RDB_TYPE_HASH_LISTPACK:
+	case RDB_TYPE_HASH, RDB_TYPE_HASH_ZIPLIST, RDB_TYPE_HASH_LISTPACK,
+		RDB_TYPE_HASH_LISTPACK_EX, RDB_TYPE_HASH_LISTPACK_EX_PRE_GA, RDB_TYPE_HASH_METADATA, RDB_TYPE_HASH_METADATA_PRE_GA:
 		// HSET key field1 value1 ...
 		if hashVal, ok := entry.Value.(*HashValue); ok && hashVal != nil {
 			if len(hashVal.Fields) > 0 {
@@ -33,7 +38,7 @@ func (fw *FlowWriter) buildCommands(entry *RDBEntry) [][]interface{} {
 			}
 		}
 
-	case RDB_TYPE_LIST_QUICKLIST, RDB_TYPE_LIST_QUICKLIST_2:
+	case RDB_TYPE_LIST_ZIPLIST, RDB_TYPE_LIST_QUICKLIST, RDB_TYPE_LIST_QUICKLIST_2:
 		// RPUSH key element1 element2 ...
 		if listVal, ok := entry.Value.(*ListValue); ok && listVal != nil {
 			if len(listVal.Elements) > 0 {
@@ -47,55 +52,46 @@ func (fw *FlowWriter) buildCommands(entry *RDBEntry) [][]interface{} {
 		}
 
 	case RDB_TYPE_SET, RDB_TYPE_SET_INTSET, RDB_TYPE_SET_LISTPACK:
-		// SADD key member1 member2 ...
+		// SADD key member1 member2 ..., chunked for big sets
 		if setVal, ok := entry.Value.(*SetValue); ok && setVal != nil {
-			if len(setVal.Members) > 0 {
-				args := make([]interface{}, 0, 2+len(setVal.Members))
-				args = append(args, "SADD", entry.Key)
-				for _, member := range setVal.Members {
-					args = append(args, member)
-				}
-				mainCmd = args
-			}
+			commands = setMemberCommands(entry.Key, setVal.Members)
 		}
 
 	case RDB_TYPE_ZSET_2, RDB_TYPE_ZSET_ZIPLIST, RDB_TYPE_ZSET_LISTPACK:
-		// ZADD key score member ...
+		// ZADD key score member ..., chunked for big sorted sets
 		if zsetVal, ok := entry.Value.(*ZSetValue); ok && zsetVal != nil {
-			if len(zsetVal.Members) > 0 {
-				args := make([]interface{}, 0, 2+len(zsetVal.Members)*2)
-				args = append(args, "ZADD", entry.Key)
-				for _, zm := range zsetVal.Members {
-					args = append(args, fmt.Sprintf("%f", zm.Score), zm.Member)
-				}
-				mainCmd = args
-			}
+			commands = zsetMemberCommands(entry.Key, zsetVal.Members)
 		}
 
 	case RDB_TYPE_STREAM_LISTPACKS, RDB_TYPE_STREAM_LISTPACKS_2, RDB_TYPE_STREAM_LISTPACKS_3:
 		return nil // Fallback to sequential for streams
 
+	case RDB_TYPE_SBF:
+		// Only enqueued when migrate.restoreBloomFilters is on and the target has RedisBloom
+		if sbfVal, ok := entry.Value.(*SBFValue); ok && sbfVal != nil {
+			mainCmd = sbfReserveArgs(entry.Key, sbfVal)
+		}
+
 	default:
 		return nil
 	}
 
 	if mainCmd != nil {
 		commands = append(commands, mainCmd)
-		// Append expiration if needed
+	}
+
+	if len(commands) > 0 {
+
+		// Per-field TTLs (Redis 7.4+ hash field expiration)
+		if hashVal, ok := entry.Value.(*HashValue); ok && hashVal != nil {
+			for field, expireAt := range hashVal.FieldExpiry {
+				commands = append(commands, []interface{}{"HPEXPIREAT", entry.Key, strconv.FormatInt(expireAt, 10), "FIELDS", "1", field})
+			}
+		}
+
+		// Append expiration if needed (entry.ExpireMs is an absolute timestamp)
 		if entry.ExpireMs > 0 {
-			// Calculate remaining TTL
-			// entry.ExpireMs is absolute timestamp in ms?
-			// RDB usually stores absolute expiry.
-			// PEXPIREAT takes absolute timestamp.
-			// Wait, replicator.go was using PEXPIRE with (expiry - now).
-			// If entry.ExpireMs is timestamp, we should use PEXPIREAT.
-			// If entry.ExpireMs is TTL, we use PEXPIRE.
-			// RDB parser usually returns ExpiryTime (absolute).
-			// replicator.go line 1555: `remainingMs := entry.ExpireMs - getCurrentTimeMillis()`.
-			// So `ExpireMs` IS absolute timestamp.
-			// Therefore `PEXPIREAT` is correct and safer (idempotent).
-			expireCmd := []interface{}{"PEXPIREAT", entry.Key, strconv.FormatInt(entry.ExpireMs, 10)}
-			commands = append(commands, expireCmd)
+			commands = append(commands, expireArgs(entry.Key, entry.ExpireMs, fw.absoluteTTL))
 		}
 	}
 
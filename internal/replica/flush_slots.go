@@ -0,0 +1,155 @@
+package replica
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"df2redis/internal/redisx"
+)
+
+// flushSlotsBatch is the number of keys fetched per CLUSTER GETKEYSINSLOT / SCAN call.
+const flushSlotsBatch = 1000
+
+// slotRange is an inclusive range of hash slots.
+type slotRange struct {
+	start, end uint16
+}
+
+// isFlushSlots reports whether a journal command is DFLYCLUSTER FLUSHSLOTS.
+func isFlushSlots(entry *JournalEntry) bool {
+	return strings.EqualFold(entry.Command, "DFLYCLUSTER") &&
+		len(entry.Args) > 0 && strings.EqualFold(entry.Args[0], "FLUSHSLOTS")
+}
+
+// parseSlotRanges reads the "start end [start end ...]" pairs of FLUSHSLOTS.
+func parseSlotRanges(args []string) ([]slotRange, error) {
+	if len(args) == 0 || len(args)%2 != 0 {
+		return nil, fmt.Errorf("FLUSHSLOTS expects start/end slot pairs, got %d args", len(args))
+	}
+	ranges := make([]slotRange, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		start, err := strconv.ParseUint(args[i], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start slot %q", args[i])
+		}
+		end, err := strconv.ParseUint(args[i+1], 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end slot %q", args[i+1])
+		}
+		if start > end || end >= 16384 {
+			return nil, fmt.Errorf("invalid slot range %d-%d", start, end)
+		}
+		ranges = append(ranges, slotRange{start: uint16(start), end: uint16(end)})
+	}
+	return ranges, nil
+}
+
+// flushSlots deletes every target key that hashes into the flushed slots.
+// Cluster targets are emptied slot by slot on the owning node; a standalone
+// target has no slot index, so its keyspace is scanned once and filtered.
+func (r *Replicator) flushSlots(entry *JournalEntry) (int64, error) {
+	ranges, err := parseSlotRanges(entry.Args[1:])
+	if err != nil {
+		return 0, err
+	}
+	if r.clusterClient.IsStandalone() {
+		return r.flushSlotsStandalone(ranges)
+	}
+
+	var deleted int64
+	for _, rng := range ranges {
+		for slot := int(rng.start); slot <= int(rng.end); slot++ {
+			n, err := r.flushSlot(uint16(slot))
+			deleted += n
+			if err != nil {
+				return deleted, fmt.Errorf("slot %d: %w", slot, err)
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// flushSlot removes all keys of one slot from the node that owns it.
+func (r *Replicator) flushSlot(slot uint16) (int64, error) {
+	addr := r.clusterClient.MasterAddr(slot)
+	client, err := r.clusterClient.GetNodeClient(addr)
+	if err != nil {
+		return 0, err
+	}
+	var deleted int64
+	for {
+		reply, err := client.Do("CLUSTER", "GETKEYSINSLOT", slot, flushSlotsBatch)
+		if err != nil {
+			return deleted, err
+		}
+		keys, err := redisx.ToStringSlice(reply)
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) == 0 {
+			return deleted, nil
+		}
+		// All keys share the slot, so one UNLINK is valid in cluster mode
+		args := make([]interface{}, len(keys))
+		for i, k := range keys {
+			args[i] = k
+		}
+		n, err := client.Do("UNLINK", args...)
+		if err != nil {
+			return deleted, err
+		}
+		if c, convErr := redisx.ToInt64(n); convErr == nil {
+			deleted += c
+		}
+	}
+}
+
+func (r *Replicator) flushSlotsStandalone(ranges []slotRange) (int64, error) {
+	var flushed [16384]bool
+	for _, rng := range ranges {
+		for slot := int(rng.start); slot <= int(rng.end); slot++ {
+			flushed[slot] = true
+		}
+	}
+
+	var deleted int64
+	cursor := "0"
+	for {
+		reply, err := r.clusterClient.Do("SCAN", cursor, "COUNT", flushSlotsBatch)
+		if err != nil {
+			return deleted, err
+		}
+		arr, ok := reply.([]interface{})
+		if !ok || len(arr) != 2 {
+			return deleted, fmt.Errorf("unexpected SCAN reply %T", reply)
+		}
+		next, err := redisx.ToString(arr[0])
+		if err != nil {
+			return deleted, err
+		}
+		keys, err := redisx.ToStringSlice(arr[1])
+		if err != nil {
+			return deleted, err
+		}
+		var victims []interface{}
+		for _, key := range keys {
+			if flushed[redisx.Slot(key)] {
+				victims = append(victims, key)
+			}
+		}
+		if len(victims) > 0 {
+			n, err := r.clusterClient.Do("UNLINK", victims...)
+			if err != nil {
+				return deleted, err
+			}
+			if c, convErr := redisx.ToInt64(n); convErr == nil {
+				deleted += c
+			}
+		}
+		if next == "0" {
+			return deleted, nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,97 @@
+package replica
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"df2redis/internal/redisx"
+)
+
+func TestParseSlotRanges(t *testing.T) {
+	cases := []struct {
+		args    []string
+		want    []slotRange
+		wantErr string
+	}{
+		{args: []string{"0", "100"}, want: []slotRange{{0, 100}}},
+		{args: []string{"5", "5", "16000", "16383"}, want: []slotRange{{5, 5}, {16000, 16383}}},
+		{args: nil, wantErr: "got 0 args"},
+		{args: []string{"0", "100", "200"}, wantErr: "got 3 args"},
+		{args: []string{"100", "99"}, wantErr: "invalid slot range 100-99"},
+		{args: []string{"0", "16384"}, wantErr: "invalid slot range 0-16384"},
+		{args: []string{"16384", "16384"}, wantErr: "invalid slot range"},
+		{args: []string{"-1", "10"}, wantErr: `invalid start slot "-1"`},
+		{args: []string{"0", "x"}, wantErr: `invalid end slot "x"`},
+		{args: []string{"0", "70000"}, wantErr: `invalid end slot "70000"`},
+	}
+	for _, tc := range cases {
+		got, err := parseSlotRanges(tc.args)
+		if tc.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("parseSlotRanges(%q) error = %v, want %q", tc.args, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil || fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("parseSlotRanges(%q) = %v, %v; want %v", tc.args, got, err, tc.want)
+		}
+	}
+}
+
+// scanReply encodes a SCAN reply: the next cursor and a page of keys.
+func scanReply(next string, keys ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*2\r\n$%d\r\n%s\r\n*%d\r\n", len(next), next, len(keys))
+	for _, k := range keys {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(k), k)
+	}
+	return b.String()
+}
+
+func TestFlushSlotsStandaloneFiltersScan(t *testing.T) {
+	inSlot := []string{"{tenant1}:a", "{tenant1}:b", "{tenant1}:c"}
+	others := []string{"{tenant2}:a", "plain", "{tenant3}:x"}
+	slot := redisx.Slot("tenant1")
+	for _, k := range others {
+		if redisx.Slot(k) == slot {
+			t.Fatalf("test key %q shares slot %d", k, slot)
+		}
+	}
+
+	target, cc := newStubTarget(t, func(args []string) string {
+		switch strings.ToUpper(args[0]) {
+		case "SCAN":
+			if args[1] == "0" {
+				return scanReply("17", inSlot[0], others[0], inSlot[1])
+			}
+			return scanReply("0", others[1], inSlot[2], others[2])
+		case "UNLINK":
+			return fmt.Sprintf(":%d\r\n", len(args)-1)
+		}
+		return "-ERR unexpected command\r\n"
+	})
+	r := &Replicator{clusterClient: cc}
+
+	entry := &JournalEntry{Opcode: OpCommand, Command: "DFLYCLUSTER",
+		Args: []string{"FLUSHSLOTS", fmt.Sprint(slot), fmt.Sprint(slot)}}
+	deleted, err := r.flushSlots(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != int64(len(inSlot)) {
+		t.Fatalf("deleted %d keys, want %d", deleted, len(inSlot))
+	}
+
+	var unlinked []string
+	for _, cmd := range target.received() {
+		if cmd[0] == "UNLINK" {
+			unlinked = append(unlinked, cmd[1:]...)
+		}
+	}
+	sort.Strings(unlinked)
+	if fmt.Sprint(unlinked) != fmt.Sprint(inSlot) {
+		t.Fatalf("UNLINK got %v, want %v", unlinked, inSlot)
+	}
+}
@@ -3,7 +3,9 @@ package replica
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // JournalOpcode enumerates Dragonfly journal opcodes
@@ -47,11 +49,55 @@ type JournalEntry struct {
 	TxID     uint64   // transaction ID
 	ShardCnt uint64   // shard count
 	LSN      uint64   // log sequence number
+	AckPos   uint64   // REPLCONF ACK value once this entry is consumed (source.ackApplied)
 	Command  string   // command name
 	Args     []string // command arguments
 	RawData  []byte   // raw payload (for debugging)
 }
 
+// maxLoggedArgLen caps how many bytes of each argument are logged.
+const maxLoggedArgLen = 50
+
+// quoteArg renders a command argument for logs: Go-quoted so control
+// characters and invalid UTF-8 become escapes, truncated on a rune boundary.
+func quoteArg(arg string) string {
+	if len(arg) <= maxLoggedArgLen {
+		return strconv.Quote(arg)
+	}
+	cut := maxLoggedArgLen
+	for cut > 0 && !utf8.RuneStart(arg[cut]) {
+		cut--
+	}
+	return strconv.Quote(arg[:cut]) + "..."
+}
+
+// formatArgs joins quoted arguments. With hideValues only the first argument
+// (the key) is shown; the rest are replaced by their size.
+func formatArgs(args []string, hideValues bool, sep string) string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		if hideValues && i > 0 {
+			out[i] = fmt.Sprintf("<%d bytes>", len(arg))
+		} else {
+			out[i] = quoteArg(arg)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+// formatValueArgs formats the arguments after a command's key, which are
+// all values: with hideValues each one is replaced by its size.
+func formatValueArgs(args []string, hideValues bool) string {
+	if !hideValues {
+		return formatArgs(args, false, " ")
+	}
+	out := make([]string, len(args))
+	for i, arg := range args {
+		out[i] = fmt.Sprintf("<%d bytes>", len(arg))
+	}
+	return strings.Join(out, " ")
+}
+
 // String pretty-prints the entry
 func (e *JournalEntry) String() string {
 	switch e.Opcode {
@@ -62,16 +108,8 @@ func (e *JournalEntry) String() string {
 	case OpPing:
 		return "PING"
 	case OpCommand, OpExpired:
-		args := make([]string, len(e.Args))
-		for i, arg := range e.Args {
-			if len(arg) > 50 {
-				args[i] = fmt.Sprintf("\"%s...\"", arg[:50])
-			} else {
-				args[i] = fmt.Sprintf("\"%s\"", arg)
-			}
-		}
 		return fmt.Sprintf("%s txid=%d cmd=%s args=[%s]",
-			e.Opcode, e.TxID, e.Command, strings.Join(args, ", "))
+			e.Opcode, e.TxID, e.Command, formatArgs(e.Args, false, ", "))
 	case OpFin:
 		return "FIN (stream ended)"
 	default:
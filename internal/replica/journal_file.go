@@ -0,0 +1,145 @@
+package replica
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"df2redis/internal/config"
+)
+
+// journalCapture copies the bytes the journal reader consumes from a FLOW
+// into a file (observability.captureJournal), so the stream can be replayed
+// later with ReplayJournalFile.
+type journalCapture struct {
+	r io.Reader
+	f *os.File
+	w *bufio.Writer
+}
+
+// captureJournalPath names the capture file of one FLOW.
+func captureJournalPath(base string, flowID int) string {
+	return fmt.Sprintf("%s.flow-%d", base, flowID)
+}
+
+func newJournalCapture(r io.Reader, path string) (*journalCapture, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create journal capture: %w", err)
+	}
+	return &journalCapture{r: r, f: f, w: bufio.NewWriter(f)}, nil
+}
+
+func (c *journalCapture) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		// A failing capture must not stop replication; Flush reports it
+		c.w.Write(p[:n])
+	}
+	return n, err
+}
+
+// Flush writes out everything captured so far; called after each entry so a
+// crash loses at most the entry being read.
+func (c *journalCapture) Flush() error {
+	return c.w.Flush()
+}
+
+func (c *journalCapture) Close() error {
+	flushErr := c.w.Flush()
+	if err := c.f.Close(); err != nil {
+		return err
+	}
+	return flushErr
+}
+
+// ReplayJournalFile applies a journal stream captured with
+// observability.captureJournal to the configured target, through the same
+// replay path (filters, key rewrites, retries, mirrors) as live stable sync.
+// flowID only labels the entries. Checkpoints are never written: the file
+// has no source to resume against.
+func ReplayJournalFile(ctx context.Context, cfg *config.Config, path string, flowID int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	replayCfg := *cfg
+	replayCfg.Checkpoint.Enabled = false
+	r := NewReplicator(&replayCfg)
+	defer r.cancel()
+	stop := context.AfterFunc(ctx, r.cancel)
+	defer stop()
+
+	closeTrace, err := r.connectTarget()
+	if err != nil {
+		return err
+	}
+	defer closeTrace()
+	defer r.clusterClient.Close()
+	if err := r.connectMirrors(); err != nil {
+		return err
+	}
+	defer r.closeMirrors()
+
+	if size := replayCfg.Advanced.RetryQueueSize; size > 0 {
+		r.retryQ = newRetryQueue(size)
+		for _, m := range r.mirrors {
+			m.r.retryQ = newRetryQueue(size)
+		}
+	}
+	r.state = StateStableSync
+
+	log.Println("")
+	log.Printf("📼 Replaying journal file %s as FLOW-%d...", path, flowID)
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	reader := NewJournalReader(bufio.NewReaderSize(f, flowBufSize))
+	pos := &FlowACKState{}
+	count := 0
+	for {
+		if err := r.ctx.Err(); err != nil {
+			if budgetErr := r.writeBudget.Err(); budgetErr != nil {
+				return budgetErr
+			}
+			return ctx.Err()
+		}
+		entry, err := reader.ReadEntry()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%s: read failed after %d entries: %w", path, count, err)
+		}
+		count++
+		pos.advance(entry)
+		r.displayFlowEntry(flowID, entry, entry.DbIndex, count)
+
+		r.replayStats.mu.Lock()
+		r.replayStats.TotalCommands++
+		r.replayStats.mu.Unlock()
+		if err := r.replayCommand(flowID, entry); errors.Is(err, errQueuedForRetry) {
+			// counted once the retry queue applies or drops it
+		} else if err != nil {
+			log.Printf("  ✗ Replay failed: %v", err)
+			r.recordWriteResults(0, 1)
+		} else {
+			r.recordWriteResults(1, 0)
+		}
+		r.mirrorJournalEntry(flowID, entry)
+		r.drainRetryQueue(r.retryQ.Full())
+	}
+
+	r.drainRetryQueue(false)
+	if n := r.retryQ.Len(); n > 0 {
+		log.Printf("  ⚠ %d journal entries could not be applied before the end of the file", n)
+	}
+	r.logReplayStats(map[int]int{flowID: count})
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	return r.writeBudget.Err()
+}
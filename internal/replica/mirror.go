@@ -0,0 +1,262 @@
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"df2redis/internal/config"
+	"df2redis/internal/redisx"
+)
+
+// mirrorTarget is an extra target (config mirrorTargets, or a target list
+// after its first entry) that receives a copy of every snapshot entry and
+// journal command. It is driven by its own source-less Replicator, so it has
+// separate writers, replay stats, retry queue and failure budget, and replays
+// the journal from its own queue. A mirror that fails or falls behind is
+// dropped from the fan-out; the primary target keeps going.
+type mirrorTarget struct {
+	name   string
+	r      *Replicator
+	failed atomic.Bool
+
+	journal     chan *FlowEntry // commands waiting for replayJournal
+	journalDone chan struct{}   // closed when replayJournal returns
+}
+
+var (
+	// mirrorEnqueueWait bounds how long a mirror whose snapshot writer or
+	// journal queue is full may hold up the primary; after that the mirror
+	// is dropped.
+	mirrorEnqueueWait = 5 * time.Second
+	// mirrorJournalQueueSize is how many journal commands a mirror may lag
+	// behind the primary before the primary waits for it.
+	mirrorJournalQueueSize = 10000
+	// mirrorCloseWait bounds how long shutdown waits for a mirror to replay
+	// its queued journal commands.
+	mirrorCloseWait = 10 * time.Second
+)
+
+// DialTarget connects to a target, auto-detecting the cluster topology for
+// cluster types and forcing a single node otherwise. Node connections are
+// named clientName in CLIENT LIST.
+func DialTarget(ctx context.Context, tc config.TargetConfig, clientName string) (*redisx.ClusterClient, error) {
+	seeds := tc.Cluster.Seeds
+	if len(seeds) == 0 {
+		seeds = []string{tc.Addr}
+	}
+	if err := checkTargetMode(ctx, tc, seeds[0], clientName); err != nil {
+		return nil, err
+	}
+	opts := tc.ClusterOptions()
+	opts.ClientName = clientName
+	if strings.Contains(strings.ToLower(tc.Type), "cluster") {
+		return redisx.DialClusterWithOptions(ctx, seeds, tc.Password, opts)
+	}
+	return redisx.DialStandaloneWithOptions(ctx, seeds[0], tc.Password, opts)
+}
+
+// connectMirrors dials every mirror target. A mirror that can't be reached
+// at startup fails the run, since it would otherwise miss the snapshot.
+func (r *Replicator) connectMirrors() error {
+	for i, tc := range r.cfg.MirrorTargets {
+		name := fmt.Sprintf("mirror-%d %s", i, tc.Endpoint())
+		client, err := DialTarget(r.ctx, tc, fmt.Sprintf("df2redis:mirror:%d", i))
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", name, err)
+		}
+		if err := r.checkSameEndpoint(name, client); err != nil {
+			client.Close()
+			return err
+		}
+
+		cfg := *r.cfg
+		cfg.Target = tc
+		cfg.MirrorTargets = nil
+		cfg.Checkpoint.Enabled = false // the checkpoint follows the primary target
+		m := NewReplicator(&cfg)
+		m.clusterClient = client
+		m.transformer = nil // entries are already rewritten by the primary
+		if cfg.Migrate.WriteMode == "auto" || cfg.Migrate.RestoreThresholdElements > 0 {
+			if version, err := detectTargetRDBVersion(client); err == nil {
+				m.dumpTargetVersion = version
+			}
+		}
+		if cfg.Migrate.RestoreBloomFilters {
+			m.restoreBloom = m.detectBloomSupport()
+		}
+		// The mirror's journal worker owns its retry queue from the start, so
+		// inline snapshot journal writes get retried there too
+		if size := cfg.Advanced.RetryQueueSize; size > 0 {
+			m.retryQ = newRetryQueue(size)
+		}
+
+		mt := &mirrorTarget{name: name, r: m}
+		mt.startJournal()
+		r.mirrors = append(r.mirrors, mt)
+		log.Printf("  ✓ Connected to %s (%d masters)", name, client.MasterCount())
+	}
+	return nil
+}
+
+// active reports whether the mirror still receives writes, dropping it the
+// first time its failure budget trips, conflict.policy=panic finds a
+// duplicate key on it or its retry queue overflows.
+func (m *mirrorTarget) active() bool {
+	if m.failed.Load() {
+		return false
+	}
+	reason := ""
+	if err := m.r.writeBudget.Err(); err != nil {
+		reason = err.Error()
+	} else if err := m.r.conflictErr(); err != nil {
+		reason = err.Error()
+	} else if m.r.retryQ.Full() {
+		reason = fmt.Sprintf("%d writes are waiting for it to recover", m.r.retryQ.Len())
+	}
+	if reason == "" {
+		return true
+	}
+	m.drop(reason)
+	return false
+}
+
+// drop stops sending writes to the mirror, logging reason the first time.
+func (m *mirrorTarget) drop(reason string) {
+	if m.failed.CompareAndSwap(false, true) {
+		log.Printf("  ✗ Dropping %s from replication: %s (the primary target is unaffected)", m.name, reason)
+	}
+}
+
+// mirrorSnapshotEntry queues a copy of a snapshot entry on every active
+// mirror. It runs in the primary's parse loop, so a mirror that can't take
+// the entry within mirrorEnqueueWait is dropped rather than waited for.
+func (r *Replicator) mirrorSnapshotEntry(flowID int, entry *RDBEntry) {
+	for _, m := range r.mirrors {
+		if !m.active() {
+			continue
+		}
+		cp := *entry
+		err := m.r.flowWriters[flowID].EnqueueTimeout(&cp, mirrorEnqueueWait)
+		if errors.Is(err, errEnqueueTimeout) {
+			m.drop(fmt.Sprintf("its FLOW-%d writer stayed full for %v", flowID, mirrorEnqueueWait))
+			continue
+		}
+		if err != nil {
+			log.Printf("  [FLOW-%d] ⚠ %s: write failed (key=%s): %v", flowID, m.name, entry.Key, err)
+			m.r.recordWriteResults(0, 1)
+		}
+	}
+}
+
+// mirrorJournalEntry queues a copy of a journal entry, already applied to
+// the primary target, on every active mirror. It runs in the primary's replay
+// loop, so a mirror whose queue stays full for mirrorEnqueueWait is dropped
+// rather than waited for.
+func (r *Replicator) mirrorJournalEntry(flowID int, entry *JournalEntry) {
+	for _, m := range r.mirrors {
+		if !m.active() {
+			continue
+		}
+		cp := *entry
+		cp.Args = append([]string(nil), entry.Args...)
+		fe := &FlowEntry{FlowID: flowID, Entry: &cp}
+		select {
+		case m.journal <- fe:
+			continue
+		default:
+		}
+		timer := time.NewTimer(mirrorEnqueueWait)
+		select {
+		case m.journal <- fe:
+		case <-timer.C:
+			m.drop(fmt.Sprintf("its journal queue stayed full (%d commands) for %v", cap(m.journal), mirrorEnqueueWait))
+		}
+		timer.Stop()
+	}
+}
+
+// startJournal starts the goroutine that replays the mirror's journal queue.
+func (m *mirrorTarget) startJournal() {
+	m.journal = make(chan *FlowEntry, mirrorJournalQueueSize)
+	m.journalDone = make(chan struct{})
+	go m.replayJournal()
+}
+
+// replayJournal applies queued journal commands to the mirror in order and
+// keeps draining its retry queue while the journal is idle. Commands queued
+// after the mirror was dropped are discarded.
+func (m *mirrorTarget) replayJournal() {
+	defer close(m.journalDone)
+	ticker := time.NewTicker(retryBaseDelay)
+	defer ticker.Stop()
+	for {
+		select {
+		case fe, ok := <-m.journal:
+			if !ok {
+				return
+			}
+			if !m.active() {
+				continue
+			}
+			err := m.r.replayCommand(fe.FlowID, fe.Entry)
+			switch {
+			case err == nil:
+				m.r.recordWriteResults(1, 0)
+			case errors.Is(err, errQueuedForRetry):
+			default:
+				m.r.recordWriteResults(0, 1)
+			}
+			m.r.drainRetryQueue(false)
+		case <-ticker.C:
+			if m.active() {
+				m.r.drainRetryQueue(false)
+			}
+		}
+	}
+}
+
+// stopJournal waits up to mirrorCloseWait for the queued journal commands to
+// be replayed, dropping the mirror if they aren't.
+func (m *mirrorTarget) stopJournal() {
+	close(m.journal)
+	select {
+	case <-m.journalDone:
+	case <-time.After(mirrorCloseWait):
+		m.drop(fmt.Sprintf("%d journal commands still queued at shutdown", len(m.journal)))
+	}
+}
+
+// stopMirrorWriters flushes the mirrors' snapshot writers and logs per-target totals.
+func (r *Replicator) stopMirrorWriters() {
+	for _, m := range r.mirrors {
+		var written int64
+		for _, fw := range m.r.flowWriters {
+			fw.Stop()
+			_, w, _ := fw.GetStats()
+			written += w
+		}
+		status := "ok"
+		if m.failed.Load() {
+			status = "dropped"
+		}
+		log.Printf("  [%s] Snapshot writes: written=%d status=%s", m.name, written, status)
+	}
+}
+
+// closeMirrors logs the replay totals of every mirror and closes its connections.
+func (r *Replicator) closeMirrors() {
+	for _, m := range r.mirrors {
+		m.stopJournal()
+		m.r.replayStats.mu.Lock()
+		ok, failed := m.r.replayStats.ReplayedOK, m.r.replayStats.Failed
+		m.r.replayStats.mu.Unlock()
+		log.Printf("  [%s] Journal replay: applied=%d failed=%d queued=%d", m.name, ok, failed, m.r.retryQ.Len())
+		m.r.cancel()
+		m.r.clusterClient.Close()
+	}
+}
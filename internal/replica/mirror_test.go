@@ -0,0 +1,113 @@
+package replica
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"df2redis/internal/config"
+)
+
+// queuedMirror is a mirror whose FLOW-0 snapshot writer isn't running, so
+// whatever is enqueued stays in its channel of the given size.
+func queuedMirror(name string, size int) *mirrorTarget {
+	fw := &FlowWriter{entryChan: make(chan *RDBEntry, size), ctx: context.Background()}
+	return &mirrorTarget{name: name, r: &Replicator{flowWriters: []*FlowWriter{fw}}}
+}
+
+func TestMirrorSnapshotFanOut(t *testing.T) {
+	a, b := queuedMirror("a", 4), queuedMirror("b", 4)
+	r := &Replicator{mirrors: []*mirrorTarget{a, b}}
+
+	entry := &RDBEntry{Key: "k", Type: RDB_TYPE_STRING, Value: "v"}
+	r.mirrorSnapshotEntry(0, entry)
+
+	for _, m := range r.mirrors {
+		ch := m.r.flowWriters[0].entryChan
+		if len(ch) != 1 {
+			t.Fatalf("mirror %s has %d queued entries, want 1", m.name, len(ch))
+		}
+		got := <-ch
+		if got == entry {
+			t.Fatalf("mirror %s got the primary's entry, want a copy", m.name)
+		}
+		if got.Key != "k" || got.Value != "v" {
+			t.Fatalf("mirror %s got %+v", m.name, got)
+		}
+	}
+}
+
+func TestMirrorSnapshotDropsSlowMirror(t *testing.T) {
+	defer func(d time.Duration) { mirrorEnqueueWait = d }(mirrorEnqueueWait)
+	mirrorEnqueueWait = 20 * time.Millisecond
+
+	slow, fast := queuedMirror("slow", 1), queuedMirror("fast", 4)
+	r := &Replicator{mirrors: []*mirrorTarget{slow, fast}}
+
+	start := time.Now()
+	for _, key := range []string{"a", "b", "c"} {
+		r.mirrorSnapshotEntry(0, &RDBEntry{Key: key, Type: RDB_TYPE_STRING, Value: "v"})
+	}
+	// Only the first full enqueue waits; after that the slow mirror is skipped
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("mirroring took %v; the slow mirror held up the primary", elapsed)
+	}
+	if slow.active() {
+		t.Fatal("slow mirror still active after its writer stayed full")
+	}
+	if n := len(slow.r.flowWriters[0].entryChan); n != 1 {
+		t.Fatalf("slow mirror has %d queued entries, want 1", n)
+	}
+	if !fast.active() || len(fast.r.flowWriters[0].entryChan) != 3 {
+		t.Fatalf("fast mirror active=%v with %d entries, want active with 3",
+			fast.active(), len(fast.r.flowWriters[0].entryChan))
+	}
+}
+
+func TestMirrorJournalReplaysInOrder(t *testing.T) {
+	target, cc := newStubTarget(t, nil)
+	m := &mirrorTarget{name: "m", r: &Replicator{cfg: &config.Config{}, clusterClient: cc}}
+	m.startJournal()
+	r := &Replicator{mirrors: []*mirrorTarget{m}}
+
+	entry := &JournalEntry{Opcode: OpCommand, Command: "SET", Args: []string{"a", "1"}, LSN: 1}
+	r.mirrorJournalEntry(0, entry)
+	entry.Args[1] = "changed" // the primary may reuse its entry
+	r.mirrorJournalEntry(0, &JournalEntry{Opcode: OpCommand, Command: "SET", Args: []string{"b", "2"}, LSN: 2})
+	m.stopJournal()
+
+	var got []string
+	for _, cmd := range target.received() {
+		got = append(got, strings.Join(cmd, " "))
+	}
+	if strings.Join(got, ",") != "SET a 1,SET b 2" {
+		t.Fatalf("mirror received %q", got)
+	}
+	if !m.active() {
+		t.Fatal("healthy mirror was dropped")
+	}
+}
+
+func TestMirrorJournalDropsSlowMirror(t *testing.T) {
+	defer func(d time.Duration) { mirrorEnqueueWait = d }(mirrorEnqueueWait)
+	mirrorEnqueueWait = 20 * time.Millisecond
+
+	// No worker: the queue fills after one command, like a hung mirror
+	slow := &mirrorTarget{name: "slow", r: &Replicator{}, journal: make(chan *FlowEntry, 1)}
+	r := &Replicator{mirrors: []*mirrorTarget{slow}}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		r.mirrorJournalEntry(0, &JournalEntry{Opcode: OpCommand, Command: "INCR", Args: []string{"n"}})
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("mirroring took %v; the slow mirror held up the primary", elapsed)
+	}
+	if slow.active() {
+		t.Fatal("slow mirror still active after its journal queue stayed full")
+	}
+	if n := len(slow.journal); n != 1 {
+		t.Fatalf("slow mirror has %d queued commands, want 1", n)
+	}
+}
@@ -0,0 +1,98 @@
+package replica
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"df2redis/internal/redisx"
+)
+
+// defaultProtoMaxBulkLen is Redis' default proto-max-bulk-len (512MB), used
+// when the target doesn't answer CONFIG GET.
+const defaultProtoMaxBulkLen = 512 * 1024 * 1024
+
+// detectProtoMaxBulkLen returns the smallest proto-max-bulk-len across the
+// target masters.
+func detectProtoMaxBulkLen(cc *redisx.ClusterClient) (int64, error) {
+	var limit int64
+	err := cc.ForEachMaster(func(c *redisx.Client) error {
+		reply, err := c.Do("CONFIG", "GET", "proto-max-bulk-len")
+		if err != nil {
+			return err
+		}
+		fields, err := redisx.ToStringSlice(reply)
+		if err != nil {
+			return err
+		}
+		if len(fields) < 2 {
+			return fmt.Errorf("proto-max-bulk-len not reported")
+		}
+		n, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("unexpected proto-max-bulk-len %q", fields[1])
+		}
+		if limit == 0 || n < limit {
+			limit = n
+		}
+		return nil
+	})
+	return limit, err
+}
+
+// oversizeAction names the effective migrate.oversizeValueAction.
+func oversizeAction(action string) string {
+	if action == "" {
+		return "fail"
+	}
+	return action
+}
+
+// isOversizeError reports whether err is the target rejecting a bulk string
+// longer than its proto-max-bulk-len.
+func isOversizeError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "invalid bulk length") || strings.Contains(msg, "protocol error")
+}
+
+// checkOversize applies migrate.oversizeValueAction to a snapshot entry
+// before it is queued. It reports whether the entry should be written; with
+// "abort" it returns the error that stops the run.
+func (r *Replicator) checkOversize(flowID int, entry *RDBEntry) (bool, error) {
+	if r.protoMaxBulkLen <= 0 {
+		return true, nil
+	}
+	size := entry.LargestElement()
+	if int64(size) <= r.protoMaxBulkLen {
+		return true, nil
+	}
+	r.rdbStats.mu.Lock()
+	r.rdbStats.OversizeKeys++
+	r.rdbStats.mu.Unlock()
+
+	switch r.cfg.Migrate.OversizeValueAction {
+	case "skip":
+		log.Printf("  [FLOW-%d] ⊘ Skipped key=%s: element of %d bytes exceeds the target proto-max-bulk-len (%d)",
+			flowID, entry.Key, size, r.protoMaxBulkLen)
+		r.skippedKeys.Record(entry, skipReasonOversize)
+		return false, nil
+	case "abort":
+		return false, fmt.Errorf("key %s has an element of %d bytes, over the target proto-max-bulk-len (%d) (migrate.oversizeValueAction=abort)",
+			entry.Key, size, r.protoMaxBulkLen)
+	default:
+		log.Printf("  [FLOW-%d] ⚠ key=%s has an element of %d bytes, over the target proto-max-bulk-len (%d); the write will likely be rejected",
+			flowID, entry.Key, size, r.protoMaxBulkLen)
+		return true, nil
+	}
+}
+
+// handleOversizeWrite logs a write the target rejected for its bulk length,
+// naming the key and the size that tripped the limit.
+func (r *Replicator) handleOversizeWrite(entry *RDBEntry, err error) {
+	log.Printf("  ✗ Target rejected key=%s as oversize (largest element %d bytes, ~%d bytes total): %v",
+		entry.Key, entry.LargestElement(), entry.ApproxSize(), err)
+}
@@ -0,0 +1,68 @@
+package replica
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"df2redis/internal/config"
+)
+
+func TestLargestElement(t *testing.T) {
+	cases := []struct {
+		name  string
+		entry *RDBEntry
+		want  int
+	}{
+		{"string", &RDBEntry{Key: "k", Value: &StringValue{Value: "abcde"}}, 5},
+		{"hash", &RDBEntry{Key: "k", Value: &HashValue{Fields: map[string]string{"f": "xy", "longfield": "z"}}}, 9},
+		{"zset", &RDBEntry{Key: "k", Value: &ZSetValue{Members: []ZSetMember{{Member: "abc"}, {Member: "a"}}}}, 3},
+		{"dump", &RDBEntry{Key: "k", Value: &StringValue{Value: "ab"}, Dump: make([]byte, 40)}, 40},
+	}
+	for _, tc := range cases {
+		if got := tc.entry.LargestElement(); got != tc.want {
+			t.Errorf("%s: LargestElement() = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIsOversizeError(t *testing.T) {
+	if !isOversizeError(errors.New("ERR Protocol error: invalid bulk length")) {
+		t.Error("invalid bulk length not recognised")
+	}
+	if isOversizeError(errors.New("OOM command not allowed")) {
+		t.Error("OOM reported as oversize")
+	}
+}
+
+func TestCheckOversizeActions(t *testing.T) {
+	big := &RDBEntry{Key: "big", Value: &StringValue{Value: strings.Repeat("x", 11)}}
+	small := &RDBEntry{Key: "small", Value: &StringValue{Value: "x"}}
+
+	for _, action := range []string{"", "skip", "abort"} {
+		r := &Replicator{cfg: &config.Config{}, protoMaxBulkLen: 10}
+		r.cfg.Migrate.OversizeValueAction = action
+
+		if write, err := r.checkOversize(0, small); !write || err != nil {
+			t.Fatalf("%q: small value write=%v err=%v", action, write, err)
+		}
+		write, err := r.checkOversize(0, big)
+		switch action {
+		case "":
+			if !write || err != nil {
+				t.Errorf("fail: write=%v err=%v, want the write attempted", write, err)
+			}
+		case "skip":
+			if write || err != nil {
+				t.Errorf("skip: write=%v err=%v, want the key skipped", write, err)
+			}
+		case "abort":
+			if write || err == nil {
+				t.Errorf("abort: write=%v err=%v, want an error", write, err)
+			}
+		}
+		if r.rdbStats.OversizeKeys != 1 {
+			t.Errorf("%q: OversizeKeys = %d, want 1", action, r.rdbStats.OversizeKeys)
+		}
+	}
+}
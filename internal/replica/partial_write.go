@@ -0,0 +1,94 @@
+package replica
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// collectionChunkMembers caps the members sent in one SADD/ZADD, so a huge
+// set or sorted set is written as several commands instead of one request
+// that can trip the target's proto-max-bulk-len or client buffer limits.
+const collectionChunkMembers = 1000
+
+// partialWriteError reports a set/zset whose member writes failed after some
+// chunks had already been applied, leaving the key half-written on the target.
+type partialWriteError struct {
+	Key      string
+	Written  int // members applied before the failure
+	Intended int
+	Err      error
+}
+
+func (e *partialWriteError) Error() string {
+	return fmt.Sprintf("key %s partially written (%d of %d members): %v", e.Key, e.Written, e.Intended, e.Err)
+}
+
+func (e *partialWriteError) Unwrap() error { return e.Err }
+
+// setMemberCommands builds the SADD commands of a set, collectionChunkMembers
+// members each.
+func setMemberCommands(key string, members []string) [][]interface{} {
+	var cmds [][]interface{}
+	for i := 0; i < len(members); i += collectionChunkMembers {
+		chunk := members[i:min(i+collectionChunkMembers, len(members))]
+		args := make([]interface{}, 0, 2+len(chunk))
+		args = append(args, "SADD", key)
+		for _, member := range chunk {
+			args = append(args, member)
+		}
+		cmds = append(cmds, args)
+	}
+	return cmds
+}
+
+// zsetMemberCommands builds the ZADD commands of a sorted set,
+// collectionChunkMembers members each.
+func zsetMemberCommands(key string, members []ZSetMember) [][]interface{} {
+	var cmds [][]interface{}
+	for i := 0; i < len(members); i += collectionChunkMembers {
+		chunk := members[i:min(i+collectionChunkMembers, len(members))]
+		args := make([]interface{}, 0, 2+len(chunk)*2)
+		args = append(args, "ZADD", key)
+		for _, zm := range chunk {
+			args = append(args, fmt.Sprintf("%f", zm.Score), zm.Member)
+		}
+		cmds = append(cmds, args)
+	}
+	return cmds
+}
+
+// commandMembers returns how many set/zset members an SADD or ZADD command
+// carries, and 0 for any other command.
+func commandMembers(cmd []interface{}) int {
+	if len(cmd) < 2 {
+		return 0
+	}
+	switch strings.ToUpper(fmt.Sprint(cmd[0])) {
+	case "SADD":
+		return len(cmd) - 2
+	case "ZADD":
+		return (len(cmd) - 2) / 2
+	}
+	return 0
+}
+
+// handlePartialWrite counts a half-written key and, with
+// migrate.deletePartialKeys, removes it so the target holds no silently
+// incomplete value: a later check reports it missing and a re-run of the
+// full sync writes it from scratch.
+func (r *Replicator) handlePartialWrite(pe *partialWriteError) {
+	r.rdbStats.mu.Lock()
+	r.rdbStats.PartialKeys++
+	r.rdbStats.mu.Unlock()
+
+	if !r.cfg.Migrate.DeletePartialKeys {
+		log.Printf("  ✗ %v", pe)
+		return
+	}
+	if _, err := r.clusterClient.Do("DEL", pe.Key); err != nil {
+		log.Printf("  ✗ %v; DEL of the partial key failed: %v", pe, err)
+		return
+	}
+	log.Printf("  ✗ %v; deleted it", pe)
+}
@@ -0,0 +1,56 @@
+package replica
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSetMemberCommandsChunked(t *testing.T) {
+	members := make([]string, collectionChunkMembers*2+5)
+	for i := range members {
+		members[i] = fmt.Sprint(i)
+	}
+	cmds := setMemberCommands("s", members)
+	if len(cmds) != 3 {
+		t.Fatalf("got %d commands, want 3", len(cmds))
+	}
+	total := 0
+	for _, cmd := range cmds {
+		if cmd[0] != "SADD" || cmd[1] != "s" {
+			t.Fatalf("unexpected command head %v", cmd[:2])
+		}
+		total += commandMembers(cmd)
+	}
+	if total != len(members) {
+		t.Fatalf("commands carry %d members, want %d", total, len(members))
+	}
+}
+
+func TestZSetMemberCommandsChunked(t *testing.T) {
+	members := make([]ZSetMember, collectionChunkMembers+1)
+	for i := range members {
+		members[i] = ZSetMember{Member: fmt.Sprint(i), Score: float64(i)}
+	}
+	cmds := zsetMemberCommands("z", members)
+	if len(cmds) != 2 {
+		t.Fatalf("got %d commands, want 2", len(cmds))
+	}
+	if n := commandMembers(cmds[1]); n != 1 {
+		t.Fatalf("last chunk carries %d members, want 1", n)
+	}
+	if cmds[1][2] != "1000.000000" || cmds[1][3] != "1000" {
+		t.Fatalf("last chunk = %v", cmds[1])
+	}
+}
+
+func TestPartialWriteErrorUnwraps(t *testing.T) {
+	cause := errors.New("OOM")
+	var err error = &partialWriteError{Key: "k", Written: 1000, Intended: 2500, Err: cause}
+	if !errors.Is(err, cause) {
+		t.Fatal("partialWriteError does not unwrap to its cause")
+	}
+	if got := err.Error(); got != "key k partially written (1000 of 2500 members): OOM" {
+		t.Fatalf("Error() = %q", got)
+	}
+}
@@ -20,6 +20,10 @@ func (p *RDBParser) parseHash(typeByte byte) (*HashValue, error) {
 		return p.parseHashZiplist()
 	case RDB_TYPE_HASH_LISTPACK:
 		return p.parseHashListpack()
+	case RDB_TYPE_HASH_LISTPACK_EX, RDB_TYPE_HASH_LISTPACK_EX_PRE_GA:
+		return p.parseHashListpackEx(typeByte)
+	case RDB_TYPE_HASH_METADATA, RDB_TYPE_HASH_METADATA_PRE_GA:
+		return p.parseHashMetadata(typeByte)
 	default:
 		return nil, fmt.Errorf("unsupported hash encoding type: %d", typeByte)
 	}
@@ -87,19 +91,149 @@ func (p *RDBParser) parseHashListpack() (*HashValue, error) {
 	return &HashValue{Fields: fields}, nil
 }
 
+// parseHashListpackEx decodes a listpack hash with per-field TTLs
+// (RDB_TYPE_HASH_LISTPACK_EX = 25, or 23 for the 7.4 pre-GA format).
+// The GA format starts with the minimum field expiry (8-byte ms); the listpack
+// then holds (field, value, ttl) triples where ttl is an absolute ms timestamp
+// and 0 means the field has no TTL.
+func (p *RDBParser) parseHashListpackEx(typeByte byte) (*HashValue, error) {
+	if typeByte == RDB_TYPE_HASH_LISTPACK_EX {
+		// Min expiry is only an optimisation hint for Redis; per-field TTLs are authoritative
+		if _, err := p.readInt64(); err != nil {
+			return nil, fmt.Errorf("failed to read hash min expiry: %w", err)
+		}
+	}
+
+	listpackBytes, err := p.readStringFull()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseListpack([]byte(listpackBytes))
+	if err != nil {
+		return nil, err
+	}
+	if len(entries)%3 != 0 {
+		return nil, fmt.Errorf("listpack-ex hash has %d entries, expected (field, value, ttl) triples", len(entries))
+	}
+
+	hv := &HashValue{Fields: make(map[string]string, len(entries)/3)}
+	now := getCurrentTimeMillis()
+	for i := 0; i < len(entries); i += 3 {
+		ttl, err := strconv.ParseInt(entries[i+2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field TTL %q: %w", entries[i+2], err)
+		}
+		hv.addField(entries[i], entries[i+1], ttl, now)
+	}
+	return hv, nil
+}
+
+// parseHashMetadata decodes the non-listpack hash with per-field TTLs
+// (RDB_TYPE_HASH_METADATA = 24, or 22 for the 7.4 pre-GA format).
+// GA stores each TTL relative to the min-expiry header as ttl-minExpire+1 (0 = no TTL);
+// pre-GA stores absolute ms timestamps and has no header.
+func (p *RDBParser) parseHashMetadata(typeByte byte) (*HashValue, error) {
+	var minExpire int64
+	if typeByte == RDB_TYPE_HASH_METADATA {
+		v, err := p.readInt64()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hash min expiry: %w", err)
+		}
+		minExpire = v
+	}
+
+	size, _, err := p.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	hv := &HashValue{Fields: make(map[string]string, size)}
+	now := getCurrentTimeMillis()
+	for i := uint64(0); i < size; i++ {
+		rawTTL, _, err := p.readLength()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read field TTL: %w", err)
+		}
+		ttl := int64(rawTTL)
+		if typeByte == RDB_TYPE_HASH_METADATA && ttl != 0 {
+			ttl += minExpire - 1
+		}
+
+		field, err := p.readStringFull()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.readStringFull()
+		if err != nil {
+			return nil, err
+		}
+		hv.addField(field, value, ttl, now)
+	}
+	return hv, nil
+}
+
+// addField stores a hash field, dropping fields whose TTL already passed.
+func (hv *HashValue) addField(field, value string, expireMs, now int64) {
+	if expireMs > 0 {
+		if expireMs <= now {
+			return
+		}
+		if hv.FieldExpiry == nil {
+			hv.FieldExpiry = make(map[string]int64)
+		}
+		hv.FieldExpiry[field] = expireMs
+	}
+	hv.Fields[field] = value
+}
+
 // ============ List parsing ============
 
 // parseList decodes list values depending on encoding
 func (p *RDBParser) parseList(typeByte byte) (*ListValue, error) {
 	switch typeByte {
-	case RDB_TYPE_LIST_QUICKLIST, RDB_TYPE_LIST_QUICKLIST_2:
+	case RDB_TYPE_LIST_ZIPLIST:
+		return p.parseListZiplist()
+	case RDB_TYPE_LIST_QUICKLIST:
+		return p.parseListQuicklist()
+	case RDB_TYPE_LIST_QUICKLIST_2:
 		return p.parseListQuicklist2()
 	default:
 		return nil, fmt.Errorf("unsupported list encoding type: %d", typeByte)
 	}
 }
 
-// parseListQuicklist2 handles Quicklist 2.0 (RDB_TYPE_LIST_QUICKLIST_2 = 17)
+// parseListZiplist decodes the pre-3.2 ziplist-encoded list
+// (RDB_TYPE_LIST_ZIPLIST = 10), still found in RDBs loaded from old Redis
+func (p *RDBParser) parseListZiplist() (*ListValue, error) {
+	entries, err := parseZiplist([]byte(p.readString()))
+	if err != nil {
+		return nil, err
+	}
+	return &ListValue{Elements: entries}, nil
+}
+
+// parseListQuicklist handles Quicklist 1.0 (RDB_TYPE_LIST_QUICKLIST = 14):
+// a node count followed by one ziplist string per node, without the
+// container byte Quicklist 2.0 added
+func (p *RDBParser) parseListQuicklist() (*ListValue, error) {
+	size, _, err := p.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	var elements []string
+	for i := uint64(0); i < size; i++ {
+		entries, err := parseZiplist([]byte(p.readString()))
+		if err != nil {
+			return nil, fmt.Errorf("quicklist node %d/%d: %w", i, size, err)
+		}
+		elements = append(elements, entries...)
+	}
+
+	return &ListValue{Elements: elements}, nil
+}
+
+// parseListQuicklist2 handles Quicklist 2.0 (RDB_TYPE_LIST_QUICKLIST_2 = 18)
 func (p *RDBParser) parseListQuicklist2() (*ListValue, error) {
 	// Number of quicklist nodes
 	size, _, err := p.readLength()
@@ -109,24 +243,27 @@ func (p *RDBParser) parseListQuicklist2() (*ListValue, error) {
 
 	var elements []string
 	for i := uint64(0); i < size; i++ {
-		// Container type (1=plain, 2=packed/listpack)
+		// Container type (1=plain, 2=packed/listpack). Redis and Dragonfly
+		// define no other codes: a plain node holds one large element as a
+		// raw string, everything else is packed into a listpack.
 		container, _, err := p.readLength()
 		if err != nil {
 			return nil, err
 		}
 
-		if container == QUICKLIST_NODE_CONTAINER_PACKED {
-			// Packed container (listpack)
+		switch container {
+		case QUICKLIST_NODE_CONTAINER_PACKED:
 			listpackBytes := p.readString()
 			entries, err := parseListpack([]byte(listpackBytes))
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("quicklist node %d/%d: %w", i, size, err)
 			}
 			elements = append(elements, entries...)
-		} else {
-			// Plain container
+		case QUICKLIST_NODE_CONTAINER_PLAIN:
 			value := p.readString()
 			elements = append(elements, value)
+		default:
+			return nil, fmt.Errorf("quicklist node %d/%d: invalid container type %d (0x%02X)", i, size, container, container)
 		}
 	}
 
@@ -327,6 +464,13 @@ func readZiplistEntry(data []byte) (string, int, error) {
 	}
 
 	offset := 0
+	// need checks that n more bytes follow offset
+	need := func(n int, what string) error {
+		if n < 0 || offset+n > len(data) {
+			return fmt.Errorf("ziplist %s lacks enough data: need %d bytes at offset %d, have %d", what, n, offset, len(data))
+		}
+		return nil
+	}
 
 	// 1. Skip prevlen (1 or 5 bytes)
 	if data[offset] < 254 {
@@ -347,46 +491,76 @@ func readZiplistEntry(data []byte) (string, int, error) {
 	if (encoding & 0xC0) == 0 {
 		// |00pppppp| - 6-bit length string
 		length := int(encoding & 0x3F)
+		if err := need(length, "6-bit string"); err != nil {
+			return "", 0, err
+		}
 		value := string(data[offset : offset+length])
 		return value, offset + length, nil
 	} else if (encoding & 0xC0) == 0x40 {
 		// |01pppppp|qqqqqqqq| - 14-bit length string
+		if err := need(1, "14-bit string length"); err != nil {
+			return "", 0, err
+		}
 		length := int((int(encoding&0x3F) << 8) | int(data[offset]))
 		offset++
+		if err := need(length, "14-bit string"); err != nil {
+			return "", 0, err
+		}
 		value := string(data[offset : offset+length])
 		return value, offset + length, nil
 	} else if (encoding & 0xC0) == 0x80 {
 		// |10______ qqqqqqqq rrrrrrrr ssssssss tttttttt| - 32-bit length string
+		if err := need(4, "32-bit string length"); err != nil {
+			return "", 0, err
+		}
 		length := int(binary.BigEndian.Uint32(data[offset : offset+4]))
 		offset += 4
+		if err := need(length, "32-bit string"); err != nil {
+			return "", 0, err
+		}
 		value := string(data[offset : offset+length])
 		return value, offset + length, nil
 	} else if (encoding & 0xF0) == 0xC0 {
 		// |1100____| - int16
+		if err := need(2, "int16"); err != nil {
+			return "", 0, err
+		}
 		val := int16(binary.LittleEndian.Uint16(data[offset : offset+2]))
-		return strconv.Itoa(int(val)), offset + 2, nil
+		return strconv.FormatInt(int64(val), 10), offset + 2, nil
 	} else if (encoding & 0xF0) == 0xD0 {
 		// |1101____| - int32
+		if err := need(4, "int32"); err != nil {
+			return "", 0, err
+		}
 		val := int32(binary.LittleEndian.Uint32(data[offset : offset+4]))
-		return strconv.Itoa(int(val)), offset + 4, nil
+		return strconv.FormatInt(int64(val), 10), offset + 4, nil
 	} else if (encoding & 0xF0) == 0xE0 {
 		// |1110____| - int64
+		if err := need(8, "int64"); err != nil {
+			return "", 0, err
+		}
 		val := int64(binary.LittleEndian.Uint64(data[offset : offset+8]))
-		return strconv.Itoa(int(val)), offset + 8, nil
-	} else if (encoding & 0xFE) == 0xF0 {
+		return strconv.FormatInt(val, 10), offset + 8, nil
+	} else if encoding == 0xF0 {
 		// |11110000| - 3-byte int
-		val := int(data[offset]) | int(data[offset+1])<<8 | int(data[offset+2])<<16
+		if err := need(3, "int24"); err != nil {
+			return "", 0, err
+		}
+		val := int64(data[offset]) | int64(data[offset+1])<<8 | int64(data[offset+2])<<16
 		if val&0x800000 != 0 {
 			val |= -1 << 24 // sign extension
 		}
-		return strconv.Itoa(val), offset + 3, nil
+		return strconv.FormatInt(val, 10), offset + 3, nil
 	} else if encoding == 0xFE {
 		// |11111110| - 1-byte int
-		return strconv.Itoa(int(int8(data[offset]))), offset + 1, nil
+		if err := need(1, "int8"); err != nil {
+			return "", 0, err
+		}
+		return strconv.FormatInt(int64(int8(data[offset])), 10), offset + 1, nil
 	} else if (encoding & 0xF0) == 0xF0 {
 		// |1111xxxx| - 4-bit int (0-12)
-		val := int(encoding & 0x0F)
-		return strconv.Itoa(val - 1), offset, nil
+		val := int64(encoding & 0x0F)
+		return strconv.FormatInt(val-1, 10), offset, nil
 	}
 
 	return "", 0, fmt.Errorf("unsupported ziplist encoding: 0x%02X", encoding)
@@ -465,7 +639,7 @@ func readListpackEntry(data []byte) (string, int, error) {
 	// Dispatch on encoding
 	if (encoding & 0x80) == 0 {
 		// 0xxxxxxx - 7-bit unsigned integer (0-127)
-		value = strconv.Itoa(int(encoding))
+		value = strconv.FormatInt(int64(encoding), 10)
 		dataSize = 1
 	} else if (encoding & 0xC0) == 0x80 {
 		// 10xxxxxx - 6-bit string length (0-63 bytes)
@@ -517,7 +691,7 @@ func readListpackEntry(data []byte) (string, int, error) {
 			return "", 0, fmt.Errorf("16-bit integer lacks enough data")
 		}
 		val := int16(binary.LittleEndian.Uint16(data[1:3]))
-		value = strconv.Itoa(int(val))
+		value = strconv.FormatInt(int64(val), 10)
 		dataSize = 3
 	} else if encoding == 0xF2 {
 		// 24-bit signed integer
@@ -539,7 +713,7 @@ func readListpackEntry(data []byte) (string, int, error) {
 			return "", 0, fmt.Errorf("32-bit integer lacks enough data")
 		}
 		val := int32(binary.LittleEndian.Uint32(data[1:5]))
-		value = strconv.Itoa(int(val))
+		value = strconv.FormatInt(int64(val), 10)
 		dataSize = 5
 	} else if encoding == 0xF4 {
 		// 64-bit signed integer
@@ -588,8 +762,16 @@ func parseIntset(data []byte) ([]string, error) {
 
 	encoding := binary.LittleEndian.Uint32(data[0:4])
 	length := binary.LittleEndian.Uint32(data[4:8])
+	switch encoding {
+	case 2, 4, 8:
+	default:
+		return nil, fmt.Errorf("unsupported intset encoding: %d", encoding)
+	}
+	if need := 8 + uint64(length)*uint64(encoding); need > uint64(len(data)) {
+		return nil, fmt.Errorf("intset payload truncated: %d members of %d bytes need %d bytes, have %d", length, encoding, need, len(data))
+	}
 
-	var members []string
+	members := make([]string, 0, length)
 	offset := 8
 
 	for i := uint32(0); i < length; i++ {
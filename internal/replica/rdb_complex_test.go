@@ -0,0 +1,259 @@
+package replica
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// encodeIntset builds a Redis intset blob with the given member width (2, 4 or 8 bytes).
+func encodeIntset(width int, vals ...int64) []byte {
+	buf := make([]byte, 8, 8+width*len(vals))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(width))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(len(vals)))
+	for _, v := range vals {
+		switch width {
+		case 2:
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(int16(v)))
+		case 4:
+			buf = binary.LittleEndian.AppendUint32(buf, uint32(int32(v)))
+		case 8:
+			buf = binary.LittleEndian.AppendUint64(buf, uint64(v))
+		}
+	}
+	return buf
+}
+
+// rdbString wraps b in an RDB length-prefixed string (32-bit length form).
+func rdbString(b []byte) []byte {
+	out := []byte{0x80, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(out[1:], uint32(len(b)))
+	return append(out, b...)
+}
+
+func TestParseIntsetBoundaries(t *testing.T) {
+	cases := []struct {
+		name  string
+		width int
+		vals  []int64
+	}{
+		{"int16", 2, []int64{math.MinInt16, -1, 0, 1, math.MaxInt16}},
+		{"int32", 4, []int64{math.MinInt32, math.MinInt16 - 1, math.MaxInt16 + 1, math.MaxInt32}},
+		{"int64", 8, []int64{math.MinInt64, math.MinInt64 + 1, math.MinInt32 - 1, math.MaxInt32 + 1, math.MaxInt64 - 1, math.MaxInt64}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			members, err := parseIntset(encodeIntset(tc.width, tc.vals...))
+			if err != nil {
+				t.Fatalf("parseIntset: %v", err)
+			}
+			if len(members) != len(tc.vals) {
+				t.Fatalf("got %d members, want %d", len(members), len(tc.vals))
+			}
+			for i, want := range tc.vals {
+				if members[i] != strconv.FormatInt(want, 10) {
+					t.Errorf("member %d = %q, want %d", i, members[i], want)
+				}
+				// The SADD payload must parse back to the exact original integer
+				got, err := strconv.ParseInt(members[i], 10, 64)
+				if err != nil || got != want {
+					t.Errorf("member %d round-trip = %d (%v), want %d", i, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSetIntsetFromStream(t *testing.T) {
+	vals := []int64{math.MinInt64, 0, math.MaxInt64}
+	p := NewRDBParser(bytes.NewReader(rdbString(encodeIntset(8, vals...))), 0)
+
+	set, err := p.parseSetIntset()
+	if err != nil {
+		t.Fatalf("parseSetIntset: %v", err)
+	}
+	want := []string{"-9223372036854775808", "0", "9223372036854775807"}
+	if len(set.Members) != len(want) {
+		t.Fatalf("got %v, want %v", set.Members, want)
+	}
+	for i := range want {
+		if set.Members[i] != want[i] {
+			t.Errorf("member %d = %q, want %q", i, set.Members[i], want[i])
+		}
+	}
+}
+
+func TestParseIntsetRejectsMalformed(t *testing.T) {
+	truncated := encodeIntset(8, math.MaxInt64, 1)
+	if _, err := parseIntset(truncated[:len(truncated)-3]); err == nil {
+		t.Error("expected error for truncated intset")
+	}
+
+	badEncoding := encodeIntset(8, 1)
+	binary.LittleEndian.PutUint32(badEncoding[0:4], 16)
+	if _, err := parseIntset(badEncoding); err == nil {
+		t.Error("expected error for unsupported encoding")
+	}
+}
+
+func TestReadZiplistEntryIntegers(t *testing.T) {
+	le16 := func(v int16) []byte { return binary.LittleEndian.AppendUint16(nil, uint16(v)) }
+	le32 := func(v int32) []byte { return binary.LittleEndian.AppendUint32(nil, uint32(v)) }
+	le64 := func(v int64) []byte { return binary.LittleEndian.AppendUint64(nil, uint64(v)) }
+
+	cases := []struct {
+		name    string
+		entry   []byte // prevlen + encoding + payload
+		want    string
+		wantLen int
+	}{
+		{"int64 max", append([]byte{0x00, 0xE0}, le64(math.MaxInt64)...), "9223372036854775807", 10},
+		{"int64 min", append([]byte{0x00, 0xE0}, le64(math.MinInt64)...), "-9223372036854775808", 10},
+		{"int64 above int32", append([]byte{0x00, 0xE0}, le64(math.MaxInt32+1)...), "2147483648", 10},
+		{"int32 min", append([]byte{0x00, 0xD0}, le32(math.MinInt32)...), "-2147483648", 6},
+		{"int16 min", append([]byte{0x00, 0xC0}, le16(math.MinInt16)...), "-32768", 4},
+		{"int24 negative", []byte{0x00, 0xF0, 0xFF, 0xFF, 0xFF}, "-1", 5},
+		{"int8", []byte{0x00, 0xFE, 0x80}, "-128", 3},
+		{"4-bit immediate", []byte{0x00, 0xF1}, "0", 2},
+		{"5-byte prevlen", append([]byte{0xFE, 1, 0, 0, 0, 0xE0}, le64(-42)...), "-42", 14},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, n, err := readZiplistEntry(tc.entry)
+			if err != nil {
+				t.Fatalf("readZiplistEntry: %v", err)
+			}
+			if got != tc.want || n != tc.wantLen {
+				t.Errorf("got (%q, %d), want (%q, %d)", got, n, tc.want, tc.wantLen)
+			}
+		})
+	}
+}
+
+func TestReadListpackEntryIntegers(t *testing.T) {
+	le16 := func(v int16) []byte { return binary.LittleEndian.AppendUint16(nil, uint16(v)) }
+	le32 := func(v int32) []byte { return binary.LittleEndian.AppendUint32(nil, uint32(v)) }
+	le64 := func(v int64) []byte { return binary.LittleEndian.AppendUint64(nil, uint64(v)) }
+	// Every entry is followed by a 1-byte backlen for these small encodings.
+	withBacklen := func(b []byte) []byte { return append(b, byte(len(b))) }
+
+	cases := []struct {
+		name  string
+		entry []byte
+		want  string
+	}{
+		{"int64 max", withBacklen(append([]byte{0xF4}, le64(math.MaxInt64)...)), "9223372036854775807"},
+		{"int64 min", withBacklen(append([]byte{0xF4}, le64(math.MinInt64)...)), "-9223372036854775808"},
+		{"int32 min", withBacklen(append([]byte{0xF3}, le32(math.MinInt32)...)), "-2147483648"},
+		{"int24 min", withBacklen([]byte{0xF2, 0x00, 0x00, 0x80}), "-8388608"},
+		{"int16 min", withBacklen(append([]byte{0xF1}, le16(math.MinInt16)...)), "-32768"},
+		{"int13 negative", withBacklen([]byte{0xDF, 0xFF}), "-1"},
+		{"uint7", withBacklen([]byte{0x7F}), "127"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, n, err := readListpackEntry(tc.entry)
+			if err != nil {
+				t.Fatalf("readListpackEntry: %v", err)
+			}
+			if got != tc.want || n != len(tc.entry) {
+				t.Errorf("got (%q, %d), want (%q, %d)", got, n, tc.want, len(tc.entry))
+			}
+		})
+	}
+}
+
+// encodeListpack builds a listpack of short (6-bit length) string entries.
+func encodeListpack(vals ...string) []byte {
+	buf := make([]byte, 6)
+	for _, v := range vals {
+		entry := append([]byte{0x80 | byte(len(v))}, v...)
+		buf = append(buf, entry...)
+		buf = append(buf, byte(len(entry)))
+	}
+	buf = append(buf, 0xFF)
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.LittleEndian.PutUint16(buf[4:6], uint16(len(vals)))
+	return buf
+}
+
+func TestParseQuicklist2Containers(t *testing.T) {
+	var stream bytes.Buffer
+	stream.WriteByte(2) // two nodes
+	stream.WriteByte(QUICKLIST_NODE_CONTAINER_PACKED)
+	stream.Write(rdbString(encodeListpack("a", "b")))
+	stream.WriteByte(QUICKLIST_NODE_CONTAINER_PLAIN)
+	stream.Write(rdbString([]byte("large element")))
+
+	p := NewRDBParser(bytes.NewReader(stream.Bytes()), 0)
+	list, err := p.parseListQuicklist2()
+	if err != nil {
+		t.Fatalf("parseListQuicklist2: %v", err)
+	}
+	want := []string{"a", "b", "large element"}
+	if len(list.Elements) != len(want) {
+		t.Fatalf("got %q, want %q", list.Elements, want)
+	}
+	for i := range want {
+		if list.Elements[i] != want[i] {
+			t.Errorf("element %d = %q, want %q", i, list.Elements[i], want[i])
+		}
+	}
+
+	// An unknown container code must fail loudly instead of being read as plain
+	bad := []byte{1, 3}
+	bad = append(bad, rdbString([]byte("x"))...)
+	p = NewRDBParser(bytes.NewReader(bad), 0)
+	if _, err := p.parseListQuicklist2(); err == nil || !strings.Contains(err.Error(), "node 0/1: invalid container type 3") {
+		t.Errorf("unexpected error for container 3: %v", err)
+	}
+}
+
+// encodeZiplist builds a ziplist of short strings (6-bit length encoding).
+func encodeZiplist(vals ...string) []byte {
+	buf := make([]byte, 10)
+	prev := 0
+	for _, v := range vals {
+		entry := append([]byte{byte(prev), byte(len(v))}, v...)
+		buf = append(buf, entry...)
+		prev = len(entry)
+	}
+	buf = append(buf, 0xFF)
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(buf)))
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(len(buf)-1-prev))
+	binary.LittleEndian.PutUint16(buf[8:10], uint16(len(vals)))
+	return buf
+}
+
+func TestParseLegacyListEncodings(t *testing.T) {
+	var quicklist bytes.Buffer
+	quicklist.WriteByte(2) // two ziplist nodes, no container byte
+	quicklist.Write(rdbString(encodeZiplist("a", "b")))
+	quicklist.Write(rdbString(encodeZiplist("c")))
+
+	cases := []struct {
+		name     string
+		typeByte byte
+		stream   []byte
+		want     []string
+	}{
+		{"ziplist", RDB_TYPE_LIST_ZIPLIST, rdbString(encodeZiplist("x", "", "yz")), []string{"x", "", "yz"}},
+		{"quicklist", RDB_TYPE_LIST_QUICKLIST, quicklist.Bytes(), []string{"a", "b", "c"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewRDBParser(bytes.NewReader(tc.stream), 0)
+			list, err := p.parseList(tc.typeByte)
+			if err != nil {
+				t.Fatalf("parseList(%d): %v", tc.typeByte, err)
+			}
+			if strings.Join(list.Elements, ",") != strings.Join(tc.want, ",") || len(list.Elements) != len(tc.want) {
+				t.Errorf("got %q, want %q", list.Elements, tc.want)
+			}
+		})
+	}
+}
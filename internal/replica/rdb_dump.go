@@ -0,0 +1,157 @@
+package replica
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"df2redis/internal/redisx"
+)
+
+// captureReader records the bytes consumed through it while active, so the
+// serialized form of a value can be replayed with RESTORE.
+type captureReader struct {
+	*bufio.Reader
+	active bool
+	buf    []byte
+}
+
+func newCaptureReader(r *bufio.Reader) *captureReader {
+	return &captureReader{Reader: r}
+}
+
+func (c *captureReader) Read(b []byte) (int, error) {
+	n, err := c.Reader.Read(b)
+	if c.active && n > 0 {
+		c.buf = append(c.buf, b[:n]...)
+	}
+	return n, err
+}
+
+// dumpMinVersion is the RDB version a target must speak to load each type
+// from a DUMP payload. Streams and Dragonfly-specific types (hash field TTL
+// variants, SBF) are always rebuilt from commands.
+var dumpMinVersion = map[byte]int{
+	RDB_TYPE_STRING:           1,
+	RDB_TYPE_SET:              1,
+	RDB_TYPE_HASH:             1,
+	RDB_TYPE_ZSET_2:           8,
+	RDB_TYPE_LIST_ZIPLIST:     1,
+	RDB_TYPE_SET_INTSET:       1,
+	RDB_TYPE_ZSET_ZIPLIST:     1,
+	RDB_TYPE_HASH_ZIPLIST:     1,
+	RDB_TYPE_LIST_QUICKLIST:   7,
+	RDB_TYPE_HASH_LISTPACK:    10,
+	RDB_TYPE_ZSET_LISTPACK:    10,
+	RDB_TYPE_LIST_QUICKLIST_2: 10,
+	RDB_TYPE_SET_LISTPACK:     11,
+}
+
+// canDump reports whether a value of typeByte serialized at sourceVersion
+// can be RESTOREd on a target with targetVersion.
+func canDump(typeByte byte, sourceVersion, targetVersion int) bool {
+	min, ok := dumpMinVersion[typeByte]
+	return ok && targetVersion > 0 && min <= targetVersion && sourceVersion <= targetVersion
+}
+
+// buildDumpPayload wraps a serialized value in the DUMP format:
+// type byte, value, 2-byte RDB version, CRC64 of everything before it.
+func buildDumpPayload(typeByte byte, value []byte, version int) []byte {
+	payload := make([]byte, 0, 1+len(value)+10)
+	payload = append(payload, typeByte)
+	payload = append(payload, value...)
+	payload = binary.LittleEndian.AppendUint16(payload, uint16(version))
+	return binary.LittleEndian.AppendUint64(payload, crc64Jones(0, payload))
+}
+
+// restoreArgs builds RESTORE for an entry carrying a DUMP payload. REPLACE
+// matches the overwrite semantics of the command path; absolute selects
+// ABSTTL over the remaining TTL, like expireArgs.
+func restoreArgs(entry *RDBEntry, absolute bool) []interface{} {
+	args := []interface{}{"RESTORE", entry.Key, "0", entry.Dump, "REPLACE"}
+	if entry.ExpireMs > 0 && absolute {
+		args[2] = strconv.FormatInt(entry.ExpireMs, 10)
+		args = append(args, "ABSTTL")
+	} else if entry.ExpireMs > 0 {
+		args[2] = strconv.FormatInt(remainingTTL(entry.ExpireMs), 10)
+	}
+	// Keep the source's eviction state so LRU/LFU targets don't see every key as hot
+	if entry.HasFreq {
+		args = append(args, "FREQ", strconv.Itoa(int(entry.Freq)))
+	} else if entry.IdleSec > 0 {
+		args = append(args, "IDLETIME", strconv.FormatInt(entry.IdleSec, 10))
+	}
+	return args
+}
+
+// detectTargetRDBVersion maps the target's redis_version to the RDB version
+// it writes (and therefore the newest DUMP payload it accepts).
+func detectTargetRDBVersion(cc *redisx.ClusterClient) (int, error) {
+	var info string
+	err := cc.ForEachMaster(func(c *redisx.Client) error {
+		var err error
+		info, err = c.Info("server")
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(info, "\n") {
+		v, ok := strings.CutPrefix(strings.TrimSpace(line), "redis_version:")
+		if !ok {
+			continue
+		}
+		parts := strings.SplitN(v, ".", 3)
+		major, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return 0, fmt.Errorf("unexpected redis_version %q", v)
+		}
+		minor := 0
+		if len(parts) > 1 {
+			minor, _ = strconv.Atoi(parts[1])
+		}
+		switch {
+		case major > 7 || (major == 7 && minor >= 4):
+			return 12, nil
+		case major == 7 && minor >= 2:
+			return 11, nil
+		case major == 7:
+			return 10, nil
+		case major >= 5:
+			return 9, nil
+		case major == 4:
+			return 8, nil
+		default:
+			return 7, nil
+		}
+	}
+	return 0, fmt.Errorf("redis_version not found in INFO server")
+}
+
+// crc64Jones is the CRC-64/Jones checksum Redis uses for DUMP payloads
+// (reflected, no initial or final XOR, unlike hash/crc64).
+func crc64Jones(crc uint64, data []byte) uint64 {
+	for _, b := range data {
+		crc = crc64JonesTable[byte(crc)^b] ^ (crc >> 8)
+	}
+	return crc
+}
+
+var crc64JonesTable = func() [256]uint64 {
+	const poly = 0x95ac9329ac4bc9b5 // 0xad93d23594c935a9 reflected
+	var t [256]uint64
+	for i := range t {
+		crc := uint64(i)
+		for j := 0; j < 8; j++ {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ poly
+			} else {
+				crc >>= 1
+			}
+		}
+		t[i] = crc
+	}
+	return t
+}()
@@ -0,0 +1,86 @@
+package replica
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestCRC64Jones(t *testing.T) {
+	// Check value from Redis' crc64.c self test
+	if got := crc64Jones(0, []byte("123456789")); got != 0xe9c6d914c4b8d9ca {
+		t.Fatalf("crc64Jones = %#x, want 0xe9c6d914c4b8d9ca", got)
+	}
+}
+
+func TestParseKeyValueCapturesDump(t *testing.T) {
+	value := rdbString(encodeIntset(2, 1, 2, 3))
+	var stream bytes.Buffer
+	stream.Write(rdbString([]byte("ids")))
+	stream.Write(value)
+
+	p := NewRDBParser(bytes.NewReader(stream.Bytes()), 0)
+	p.rdbVersion = 9
+	p.dumpTargetVersion = 11
+	entry, err := p.parseKeyValue(RDB_TYPE_SET_INTSET)
+	if err != nil {
+		t.Fatalf("parseKeyValue: %v", err)
+	}
+
+	want := append([]byte{RDB_TYPE_SET_INTSET}, value...)
+	want = binary.LittleEndian.AppendUint16(want, 9)
+	want = binary.LittleEndian.AppendUint64(want, crc64Jones(0, want))
+	if !bytes.Equal(entry.Dump, want) {
+		t.Fatalf("dump payload = %x, want %x", entry.Dump, want)
+	}
+	if args := restoreArgs(entry, false); args[0] != "RESTORE" || args[2] != "0" {
+		t.Errorf("restore args = %v", args)
+	}
+
+	// Targets older than the encoding, and Dragonfly-only types, get commands
+	if canDump(RDB_TYPE_SET_LISTPACK, 9, 10) {
+		t.Error("set listpack needs RDB 11")
+	}
+	if canDump(RDB_TYPE_SBF, 9, 12) || canDump(RDB_TYPE_HASH_LISTPACK_EX, 9, 12) {
+		t.Error("Dragonfly-specific types must be rebuilt from commands")
+	}
+}
+
+func TestParseKeyValueRestoreThreshold(t *testing.T) {
+	parse := func(minElements int, members ...int64) *RDBEntry {
+		t.Helper()
+		var stream bytes.Buffer
+		stream.Write(rdbString([]byte("ids")))
+		stream.Write(rdbString(encodeIntset(2, members...)))
+		p := NewRDBParser(bytes.NewReader(stream.Bytes()), 0)
+		p.rdbVersion = 9
+		p.dumpTargetVersion = 11
+		p.dumpMinElements = minElements
+		entry, err := p.parseKeyValue(RDB_TYPE_SET_INTSET)
+		if err != nil {
+			t.Fatalf("parseKeyValue: %v", err)
+		}
+		return entry
+	}
+
+	if entry := parse(3, 1, 2, 3); entry.Dump != nil {
+		t.Error("set at the threshold should be written with commands")
+	}
+	if entry := parse(3, 1, 2, 3, 4); entry.Dump == nil || entry.ElementCount() != 4 {
+		t.Errorf("set above the threshold should carry a dump payload (count=%d)", entry.ElementCount())
+	}
+}
+
+func TestRestoreArgsEvictionMetadata(t *testing.T) {
+	entry := &RDBEntry{Key: "k", Dump: []byte{0}, ExpireMs: 1700000000000, HasFreq: true, Freq: 42}
+	args := restoreArgs(entry, true)
+	if len(args) != 8 || args[5] != "ABSTTL" || args[6] != "FREQ" || args[7] != "42" {
+		t.Errorf("restore args = %v", args)
+	}
+
+	entry = &RDBEntry{Key: "k", Dump: []byte{0}, IdleSec: 300}
+	args = restoreArgs(entry, true)
+	if len(args) != 7 || args[5] != "IDLETIME" || args[6] != "300" {
+		t.Errorf("restore args = %v", args)
+	}
+}
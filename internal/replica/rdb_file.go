@@ -0,0 +1,145 @@
+package replica
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// SnapshotCompression identifies how an on-disk RDB snapshot is compressed.
+type SnapshotCompression string
+
+const (
+	SnapshotPlain SnapshotCompression = "none"
+	SnapshotGzip  SnapshotCompression = "gzip"
+	SnapshotZstd  SnapshotCompression = "zstd"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// DetectSnapshotCompression sniffs the leading magic bytes and falls back to
+// the file extension (.gz / .zst / .zstd) when the header is inconclusive.
+func DetectSnapshotCompression(path string, header []byte) SnapshotCompression {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return SnapshotGzip
+	case bytes.HasPrefix(header, zstdMagic):
+		return SnapshotZstd
+	case bytes.HasPrefix(header, []byte("REDIS")):
+		return SnapshotPlain
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz", ".gzip":
+		return SnapshotGzip
+	case ".zst", ".zstd":
+		return SnapshotZstd
+	}
+	return SnapshotPlain
+}
+
+// snapshotFile closes the decoder together with the underlying file.
+type snapshotFile struct {
+	io.Reader
+	closeFn func() error
+}
+
+func (s *snapshotFile) Close() error {
+	return s.closeFn()
+}
+
+// OpenSnapshotFile opens an RDB snapshot on disk and transparently
+// decompresses gzip/zstd input, so multi-GB backups don't need to be
+// unpacked by hand first. The returned reader yields the raw RDB stream.
+func OpenSnapshotFile(path string) (io.ReadCloser, SnapshotCompression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("open snapshot %s: %w", path, err)
+	}
+
+	br := bufio.NewReaderSize(f, 1024*1024)
+	header, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		f.Close()
+		return nil, "", fmt.Errorf("read snapshot header %s: %w", path, err)
+	}
+
+	compression := DetectSnapshotCompression(path, header)
+	switch compression {
+	case SnapshotGzip:
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, "", fmt.Errorf("open gzip snapshot %s: %w", path, err)
+		}
+		return &snapshotFile{Reader: gz, closeFn: func() error {
+			gz.Close()
+			return f.Close()
+		}}, compression, nil
+
+	case SnapshotZstd:
+		dec, err := zstd.NewReader(br)
+		if err != nil {
+			f.Close()
+			return nil, "", fmt.Errorf("open zstd snapshot %s: %w", path, err)
+		}
+		return &snapshotFile{Reader: dec, closeFn: func() error {
+			dec.Close()
+			return f.Close()
+		}}, compression, nil
+	}
+
+	return &snapshotFile{Reader: br, closeFn: f.Close}, SnapshotPlain, nil
+}
+
+// ExpandSnapshotPaths turns a snapshot location into the files to load: a
+// single file, every regular non-hidden file of a directory (e.g. the
+// per-shard files of a Dragonfly backup), or the matches of a glob such as
+// "backup/dump-*.rdb". The result is sorted so shard files keep their order.
+func ExpandSnapshotPaths(path string) ([]string, error) {
+	var paths []string
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot pattern %s: %w", path, err)
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil && info.Mode().IsRegular() {
+				paths = append(paths, m)
+			}
+		}
+	} else {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("open snapshot %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			return []string{path}, nil
+		}
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("read snapshot directory %s: %w", path, err)
+		}
+		for _, e := range entries {
+			if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+				paths = append(paths, filepath.Join(path, e.Name()))
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no snapshot files found at %s", path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
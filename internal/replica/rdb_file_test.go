@@ -0,0 +1,48 @@
+package replica
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandSnapshotPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"dump-0002.dfs", "dump-0001.dfs", "dump-summary.dfs", ".hidden"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("REDIS"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	join := func(names ...string) []string {
+		var paths []string
+		for _, n := range names {
+			paths = append(paths, filepath.Join(dir, n))
+		}
+		return paths
+	}
+
+	cases := []struct {
+		path string
+		want []string
+	}{
+		{filepath.Join(dir, "dump-0001.dfs"), join("dump-0001.dfs")},
+		{dir, join("dump-0001.dfs", "dump-0002.dfs", "dump-summary.dfs")},
+		{filepath.Join(dir, "dump-000?.dfs"), join("dump-0001.dfs", "dump-0002.dfs")},
+	}
+	for _, tc := range cases {
+		got, err := ExpandSnapshotPaths(tc.path)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.path, err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ExpandSnapshotPaths(%s) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+	if _, err := ExpandSnapshotPaths(filepath.Join(dir, "*.rdb")); err == nil {
+		t.Error("a glob without matches must fail")
+	}
+}
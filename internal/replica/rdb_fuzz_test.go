@@ -0,0 +1,61 @@
+package replica
+
+import (
+	"testing"
+)
+
+// The decoders below slice into buffers using length fields taken from the
+// payload. Corrupt or adversarial RDB data must produce an error, never a
+// panic or an out-of-bounds read.
+
+func FuzzReadListpackEntry(f *testing.F) {
+	lp := encodeListpack("a", "hello")
+	f.Add(lp[6:])
+	f.Add([]byte{0x05, 0x01})                          // 7-bit uint
+	f.Add([]byte{0xC1, 0x02, 0x02})                    // 13-bit int
+	f.Add([]byte{0xE0, 0x03, 'a', 'b', 'c', 0x05})     // 12-bit string
+	f.Add([]byte{0xF0, 0x02, 0, 0, 0, 'h', 'i', 0x07}) // 32-bit string
+	f.Add([]byte{0xF4, 1, 2, 3, 4, 5, 6, 7, 8, 0x09})  // int64
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, n, err := readListpackEntry(data)
+		if err == nil && (n <= 0 || n > len(data)) {
+			t.Fatalf("size %d outside (0, %d]", n, len(data))
+		}
+		_, _ = parseListpack(data)
+	})
+}
+
+func FuzzReadZiplistEntry(f *testing.F) {
+	f.Add([]byte{0x00, 0x02, 'h', 'i'})                           // 6-bit string
+	f.Add([]byte{0x00, 0x40, 0x03, 'a', 'b', 'c'})                // 14-bit string
+	f.Add([]byte{0x00, 0x80, 0, 0, 0, 1, 'x'})                    // 32-bit string
+	f.Add([]byte{0x00, 0xC0, 0x34, 0x12})                         // int16
+	f.Add([]byte{0x00, 0xF0, 1, 2, 3})                            // 24-bit int
+	f.Add([]byte{0x00, 0xFE, 0x80})                               // 8-bit int
+	f.Add([]byte{0x00, 0xF5})                                     // 4-bit immediate
+	f.Add([]byte{0xFE, 0, 0, 0, 0, 0xE0, 1, 2, 3, 4, 5, 6, 7, 8}) // 5-byte prevlen, int64
+	// Truncated payloads that used to slice past the end
+	f.Add([]byte{0x00, 0x05, 'a'})
+	f.Add([]byte{0x00, 0x80, 0xFF, 0xFF, 0xFF, 0xFF})
+	f.Add([]byte{0x00, 0xE0, 1})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, n, err := readZiplistEntry(data)
+		if err == nil && (n <= 0 || n > len(data)) {
+			t.Fatalf("size %d outside (0, %d]", n, len(data))
+		}
+		_, _ = parseZiplist(data)
+	})
+}
+
+func FuzzParseIntset(f *testing.F) {
+	f.Add(encodeIntset(2, -3, 7))
+	f.Add(encodeIntset(4, 1<<20))
+	f.Add(encodeIntset(8, -1<<40, 1<<40))
+	f.Add([]byte{2, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		members, err := parseIntset(data)
+		if err == nil && 8+len(members)*2 > len(data) {
+			t.Fatalf("%d members decoded from %d bytes", len(members), len(data))
+		}
+	})
+}
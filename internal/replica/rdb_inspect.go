@@ -0,0 +1,230 @@
+package replica
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"df2redis/internal/redisx"
+)
+
+// RDBKeyInfo describes one key seen while inspecting an RDB file.
+type RDBKeyInfo struct {
+	Key      string
+	Type     string
+	Size     int64 // approximate payload bytes (key + members/values)
+	Elements int
+	DbIndex  int
+}
+
+// RDBInspectReport summarises an offline pass over an RDB stream.
+type RDBInspectReport struct {
+	Keys     int64
+	Expiring int64 // keys with a TTL
+	Expired  int64 // keys whose TTL is already in the past
+	Bytes    int64 // approximate payload bytes across all keys
+
+	TypeCounts map[string]int64
+	TypeBytes  map[string]int64
+	Largest    []RDBKeyInfo // sorted by Size, descending
+
+	// Only filled when slot statistics are requested
+	SlotKeys []int64          // keys per Redis Cluster slot (16384 entries)
+	HashTags map[string]int64 // keys per {hash tag}
+
+	// Err is the first parse error (e.g. an unsupported RDB type). Parsing
+	// stops there because the stream can't be realigned past an unknown value.
+	Err     error
+	LastKey string // last key parsed before Err
+}
+
+// InspectRDB runs the RDB parser over r (a local RDB file rather than a
+// replication stream) and collects a type histogram and the topN largest keys.
+// With slotStats it also counts keys per cluster slot and per hash tag.
+func InspectRDB(r io.Reader, topN int, slotStats bool) *RDBInspectReport {
+	report := &RDBInspectReport{
+		TypeCounts: make(map[string]int64),
+		TypeBytes:  make(map[string]int64),
+	}
+	if slotStats {
+		report.SlotKeys = make([]int64, 16384)
+		report.HashTags = make(map[string]int64)
+	}
+
+	parser := NewRDBParser(r, 0)
+	// Inline journal blobs only exist on live streams; count nothing for them here
+	parser.onJournalEntry = func(*JournalEntry) error { return nil }
+
+	if err := parser.ParseHeader(); err != nil {
+		report.Err = err
+		return report
+	}
+
+	for {
+		entry, err := parser.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			report.Err = err
+			break
+		}
+		if entry.Type == RDB_TYPE_FULLSYNC_END_MARKER {
+			continue
+		}
+
+		typeName := RDBTypeName(entry.Type)
+		size, elements := int64(entry.ApproxSize()), inspectElements(entry)
+
+		report.Keys++
+		report.Bytes += size
+		report.TypeCounts[typeName]++
+		report.TypeBytes[typeName] += size
+		report.LastKey = entry.Key
+		if slotStats {
+			report.SlotKeys[redisx.Slot(entry.Key)]++
+			if tag, ok := redisx.HashTag(entry.Key); ok {
+				report.HashTags[tag]++
+			}
+		}
+		if entry.ExpireMs > 0 {
+			report.Expiring++
+			if entry.IsExpired() {
+				report.Expired++
+			}
+		}
+
+		if topN > 0 {
+			report.Largest = append(report.Largest, RDBKeyInfo{
+				Key:      entry.Key,
+				Type:     typeName,
+				Size:     size,
+				Elements: elements,
+				DbIndex:  entry.DbIndex,
+			})
+			// Trim lazily so a large file doesn't re-sort on every key
+			if len(report.Largest) >= topN*4 {
+				report.trimLargest(topN)
+			}
+		}
+	}
+
+	report.trimLargest(topN)
+	return report
+}
+
+func (r *RDBInspectReport) trimLargest(topN int) {
+	sort.SliceStable(r.Largest, func(i, j int) bool {
+		return r.Largest[i].Size > r.Largest[j].Size
+	})
+	if len(r.Largest) > topN {
+		r.Largest = r.Largest[:topN]
+	}
+}
+
+// Merge adds the counts of o (another file of the same snapshot) to r and
+// keeps the topN largest keys of both. r keeps its own Err if it has one.
+func (r *RDBInspectReport) Merge(o *RDBInspectReport, topN int) {
+	r.Keys += o.Keys
+	r.Expiring += o.Expiring
+	r.Expired += o.Expired
+	r.Bytes += o.Bytes
+	for t, n := range o.TypeCounts {
+		r.TypeCounts[t] += n
+	}
+	for t, n := range o.TypeBytes {
+		r.TypeBytes[t] += n
+	}
+	r.Largest = append(r.Largest, o.Largest...)
+	r.trimLargest(topN)
+	if o.SlotKeys != nil {
+		if r.SlotKeys == nil {
+			r.SlotKeys = make([]int64, len(o.SlotKeys))
+		}
+		for slot, n := range o.SlotKeys {
+			r.SlotKeys[slot] += n
+		}
+	}
+	if o.HashTags != nil {
+		if r.HashTags == nil {
+			r.HashTags = make(map[string]int64)
+		}
+		for tag, n := range o.HashTags {
+			r.HashTags[tag] += n
+		}
+	}
+	if r.Err == nil && o.Err != nil {
+		r.Err, r.LastKey = o.Err, o.LastKey
+	}
+}
+
+// inspectElements is ElementCount extended to the types only the report
+// counts: one for a string, messages for a stream, items for a Bloom filter.
+func inspectElements(entry *RDBEntry) int {
+	switch v := entry.Value.(type) {
+	case *StringValue:
+		return 1
+	case *StreamValue:
+		if v != nil {
+			return len(v.Messages)
+		}
+	case *SBFValue:
+		if v != nil {
+			return int(v.Items())
+		}
+	}
+	return entry.ElementCount()
+}
+
+// RDBTypeName returns a readable "type (encoding)" label for an RDB type byte.
+func RDBTypeName(t byte) string {
+	switch t {
+	case RDB_TYPE_STRING:
+		return "string"
+	case RDB_TYPE_LIST:
+		return "list (linkedlist)"
+	case RDB_TYPE_LIST_ZIPLIST:
+		return "list (ziplist)"
+	case RDB_TYPE_LIST_QUICKLIST:
+		return "list (quicklist)"
+	case RDB_TYPE_LIST_QUICKLIST_2:
+		return "list (quicklist2)"
+	case RDB_TYPE_SET:
+		return "set (hashtable)"
+	case RDB_TYPE_SET_INTSET:
+		return "set (intset)"
+	case RDB_TYPE_SET_LISTPACK:
+		return "set (listpack)"
+	case RDB_TYPE_ZSET, RDB_TYPE_ZSET_2:
+		return "zset (skiplist)"
+	case RDB_TYPE_ZSET_ZIPLIST:
+		return "zset (ziplist)"
+	case RDB_TYPE_ZSET_LISTPACK:
+		return "zset (listpack)"
+	case RDB_TYPE_HASH:
+		return "hash (hashtable)"
+	case RDB_TYPE_HASH_ZIPMAP:
+		return "hash (zipmap)"
+	case RDB_TYPE_HASH_ZIPLIST:
+		return "hash (ziplist)"
+	case RDB_TYPE_HASH_LISTPACK:
+		return "hash (listpack)"
+	case RDB_TYPE_HASH_METADATA, RDB_TYPE_HASH_METADATA_PRE_GA:
+		return "hash (metadata+ttl)"
+	case RDB_TYPE_HASH_LISTPACK_EX, RDB_TYPE_HASH_LISTPACK_EX_PRE_GA:
+		return "hash (listpack+ttl)"
+	case RDB_TYPE_STREAM_LISTPACKS, RDB_TYPE_STREAM_LISTPACKS_2, RDB_TYPE_STREAM_LISTPACKS_3:
+		return "stream"
+	case RDB_TYPE_MODULE, RDB_TYPE_MODULE_2:
+		return "module"
+	case RDB_TYPE_JSON:
+		return "json (dragonfly)"
+	case RDB_TYPE_HASH_WITH_EXPIRY:
+		return "hash (dragonfly field ttl)"
+	case RDB_TYPE_SET_WITH_EXPIRY:
+		return "set (dragonfly member ttl)"
+	case RDB_TYPE_SBF:
+		return "bloom filter (dragonfly)"
+	}
+	return fmt.Sprintf("type %d", t)
+}
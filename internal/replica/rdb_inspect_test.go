@@ -0,0 +1,58 @@
+package replica
+
+import (
+	"bytes"
+	"testing"
+
+	"df2redis/internal/redisx"
+)
+
+func TestInspectRDBReport(t *testing.T) {
+	var stream bytes.Buffer
+	stream.WriteString("REDIS0009")
+	stream.WriteByte(RDB_TYPE_STRING)
+	stream.Write(rdbString([]byte("{u1}:name")))
+	stream.Write(rdbString([]byte("alice")))
+	stream.WriteByte(RDB_TYPE_SET)
+	stream.Write(rdbString([]byte("{u1}:tags")))
+	stream.WriteByte(3) // 6-bit length
+	for _, m := range []string{"admin", "ops", "oncall-primary"} {
+		stream.Write(rdbString([]byte(m)))
+	}
+	stream.WriteByte(RDB_TYPE_STRING)
+	stream.Write(rdbString([]byte("k")))
+	stream.Write(rdbString([]byte("v")))
+	stream.Write([]byte{RDB_OPCODE_EOF, 0, 0, 0, 0, 0, 0, 0, 0})
+
+	report := InspectRDB(bytes.NewReader(stream.Bytes()), 2, true)
+	if report.Err != nil {
+		t.Fatalf("InspectRDB: %v", report.Err)
+	}
+
+	name := len("{u1}:name") + len("alice")
+	tags := len("{u1}:tags") + len("admin") + len("ops") + len("oncall-primary")
+	if report.Keys != 3 || report.Bytes != int64(name+tags+2) {
+		t.Fatalf("Keys=%d Bytes=%d, want 3 and %d", report.Keys, report.Bytes, name+tags+2)
+	}
+	if report.TypeCounts["string"] != 2 || report.TypeCounts["set (hashtable)"] != 1 || report.TypeBytes["set (hashtable)"] != int64(tags) {
+		t.Fatalf("TypeCounts=%v TypeBytes=%v", report.TypeCounts, report.TypeBytes)
+	}
+
+	// Largest keys are sized with RDBEntry.ApproxSize and trimmed to topN
+	if len(report.Largest) != 2 {
+		t.Fatalf("Largest has %d keys, want 2", len(report.Largest))
+	}
+	if k := report.Largest[0]; k.Key != "{u1}:tags" || k.Size != int64(tags) || k.Elements != 3 {
+		t.Errorf("Largest[0] = %+v", k)
+	}
+	if k := report.Largest[1]; k.Key != "{u1}:name" || k.Size != int64(name) || k.Elements != 1 {
+		t.Errorf("Largest[1] = %+v", k)
+	}
+
+	if n := report.SlotKeys[redisx.Slot("u1")]; n != 2 {
+		t.Errorf("keys in slot of {u1} = %d, want 2", n)
+	}
+	if report.HashTags["u1"] != 2 || len(report.HashTags) != 1 {
+		t.Errorf("HashTags = %v", report.HashTags)
+	}
+}
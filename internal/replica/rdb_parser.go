@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/klauspost/compress/zstd"
@@ -15,18 +17,38 @@ import (
 
 // RDBParser streams and decodes RDB payloads
 type RDBParser struct {
-	reader         *bufio.Reader // current active reader
-	originalReader *bufio.Reader // original network stream
+	reader         *captureReader // current active reader
+	originalReader *captureReader // original network stream
 	flowID         int
+	rdbVersion     int // from the REDIS00NN magic
+
+	// dumpTargetVersion > 0 keeps a DUMP payload for values the target can
+	// RESTORE (see canDump); 0 disables capture
+	dumpTargetVersion int
+	// dumpMinElements > 0 keeps the payload only for collections with more
+	// elements (migrate.restoreThresholdElements); 0 keeps every capture
+	dumpMinElements int
+	// forceCommands excludes keys from capture (conflict.notifyPatterns)
+	forceCommands func(key string) bool
 
 	// State tracked during parsing
 	currentDB        int   // current database index
 	expireMs         int64 // current key expiration (absolute ms timestamp)
+	idleSec          int64 // pending LRU idle time (RDB_OPCODE_IDLE)
+	freq             int   // pending LFU counter (RDB_OPCODE_FREQ); -1 = none
 	lz4BlobCount     int   // number of LZ4 blobs processed
 	zstdBlobCount    int   // number of ZSTD blobs processed
 	journalBlobCount int   // number of journal blobs processed
 	seenFullSyncEnd  bool  // whether FULLSYNC_END marker has been seen
 
+	// Active compressed blob (streamed from originalReader until 0xCB)
+	blobInput  *io.LimitedReader
+	blobCloser func()
+
+	// Time spent blocked on the FLOW socket and decoding compressed blobs
+	readTime       time.Duration
+	decompressTime time.Duration
+
 	// Debug tracking for deadlock diagnosis
 	keysProcessed    int       // total keys processed (for progress logging)
 	lastKeyName      string    // last key processed (for debugging hangs)
@@ -44,17 +66,60 @@ func NewRDBParser(reader io.Reader, flowID int) *RDBParser {
 	// Use 1MB bufio.Reader to handle large RDB strings without fragmentation
 	// Prevents "expected N bytes, got M bytes" EOF errors during large string reads
 	const bufSize = 1024 * 1024 // 1MB
-	bufReader := bufio.NewReaderSize(reader, bufSize)
-	return &RDBParser{
-		reader:           bufReader,
-		originalReader:   bufReader,
+	p := &RDBParser{
 		flowID:           flowID,
 		currentDB:        0,
 		expireMs:         0,
+		freq:             -1,
 		keysProcessed:    0,
 		lastKeyName:      "",
 		lastActivityTime: time.Now(),
 	}
+	bufReader := newCaptureReader(bufio.NewReaderSize(&timedReader{r: reader, d: &p.readTime}, bufSize))
+	p.reader = bufReader
+	p.originalReader = bufReader
+	return p
+}
+
+// ParseTimings breaks down where ParseNext spent its time.
+type ParseTimings struct {
+	Read       time.Duration // blocked reading the source stream
+	Decompress time.Duration // LZ4/ZSTD decoding, excluding the reads it triggered
+}
+
+// Timings returns the time accumulated so far. Not safe for concurrent use
+// with ParseNext.
+func (p *RDBParser) Timings() ParseTimings {
+	return ParseTimings{Read: p.readTime, Decompress: p.decompressTime}
+}
+
+// timedReader adds the time spent in each Read to *d.
+type timedReader struct {
+	r io.Reader
+	d *time.Duration
+}
+
+func (t *timedReader) Read(b []byte) (int, error) {
+	start := time.Now()
+	n, err := t.r.Read(b)
+	*t.d += time.Since(start)
+	return n, err
+}
+
+// decompressTimer times reads from a decompressor. The decompressor pulls its
+// input through originalReader, so the network time of those reads is
+// subtracted to leave only the decoding cost.
+type decompressTimer struct {
+	r io.Reader
+	p *RDBParser
+}
+
+func (t *decompressTimer) Read(b []byte) (int, error) {
+	start := time.Now()
+	readBefore := t.p.readTime
+	n, err := t.r.Read(b)
+	t.p.decompressTime += time.Since(start) - (t.p.readTime - readBefore)
+	return n, err
 }
 
 // ParseHeader validates the RDB header ("REDIS0009" + AUX fields)
@@ -65,11 +130,16 @@ func (p *RDBParser) ParseHeader() error {
 		return fmt.Errorf("failed to read RDB magic: %w", err)
 	}
 
-	// Verify magic string
-	expectedMagic := "REDIS0009"
-	if string(magic) != expectedMagic {
-		return fmt.Errorf("invalid RDB magic: expect %s, got %s", expectedMagic, string(magic))
+	// Verify magic string. Dragonfly streams REDIS0009; local dump files may
+	// carry another version, and anything we can't decode still fails later.
+	if !strings.HasPrefix(string(magic), "REDIS") {
+		return fmt.Errorf("invalid RDB magic: expect REDIS0009, got %q", string(magic))
 	}
+	version, err := strconv.Atoi(string(magic[5:]))
+	if err != nil {
+		return fmt.Errorf("invalid RDB version in magic %q", string(magic))
+	}
+	p.rdbVersion = version
 
 	// 2. Skip AUX fields (0xFA + key + value) until we hit a non-0xFA opcode
 	for {
@@ -140,6 +210,24 @@ func (p *RDBParser) ParseNext() (*RDBEntry, error) {
 			p.expireMs = int64(expireSec) * 1000
 			continue
 
+		case RDB_OPCODE_IDLE:
+			// LRU idle time in seconds, applies to the next key
+			idle, _, err := p.readLength()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read LRU idle time: %w", err)
+			}
+			p.idleSec = int64(idle)
+			continue
+
+		case RDB_OPCODE_FREQ:
+			// LFU frequency counter, applies to the next key
+			freq, err := p.readByte()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read LFU frequency: %w", err)
+			}
+			p.freq = int(freq)
+			continue
+
 		case RDB_OPCODE_SELECTDB:
 			// Switch database
 			dbIndex, _, err := p.readLength()
@@ -295,7 +383,7 @@ func (p *RDBParser) ParseNext() (*RDBEntry, error) {
 		default:
 			// Data type opcode; parse the key/value pair
 			// Check if this looks like a valid RDB type (< 30) or a misaligned read
-			if opcode >= 30 && opcode < 0xC0 {
+			if opcode >= 30 && opcode < 0xC0 && opcode != RDB_TYPE_SBF {
 				log.Printf("  [FLOW-%d] ⚠ Warning: opcode 0x%02X (%d) is unusually high for an RDB type, possible stream corruption", p.flowID, opcode, opcode)
 			}
 			return p.parseKeyValue(opcode)
@@ -325,6 +413,20 @@ func (p *RDBParser) parseKeyValue(typeByte byte) (*RDBEntry, error) {
 		Type:     typeByte,
 		DbIndex:  p.currentDB,
 		ExpireMs: p.expireMs,
+		IdleSec:  p.idleSec,
+	}
+	if p.freq >= 0 {
+		entry.Freq, entry.HasFreq = byte(p.freq), true
+	}
+
+	// Keep the serialized value when it can be replayed with RESTORE
+	capture := p.dumpTargetVersion > 0 && canDump(typeByte, p.rdbVersion, p.dumpTargetVersion) &&
+		(p.dumpMinElements == 0 || typeByte != RDB_TYPE_STRING) &&
+		(p.forceCommands == nil || !p.forceCommands(key))
+	captureFrom := p.reader
+	if capture {
+		captureFrom.active = true
+		captureFrom.buf = captureFrom.buf[:0]
 	}
 
 	// 2. Parse value based on encoding
@@ -333,10 +435,11 @@ func (p *RDBParser) parseKeyValue(typeByte byte) (*RDBEntry, error) {
 	case RDB_TYPE_STRING:
 		entry.Value, err = p.parseString()
 
-	case RDB_TYPE_HASH, RDB_TYPE_HASH_ZIPLIST, RDB_TYPE_HASH_LISTPACK:
+	case RDB_TYPE_HASH, RDB_TYPE_HASH_ZIPLIST, RDB_TYPE_HASH_LISTPACK,
+		RDB_TYPE_HASH_LISTPACK_EX, RDB_TYPE_HASH_LISTPACK_EX_PRE_GA, RDB_TYPE_HASH_METADATA, RDB_TYPE_HASH_METADATA_PRE_GA:
 		entry.Value, err = p.parseHash(typeByte)
 
-	case RDB_TYPE_LIST_QUICKLIST, RDB_TYPE_LIST_QUICKLIST_2:
+	case RDB_TYPE_LIST_ZIPLIST, RDB_TYPE_LIST_QUICKLIST, RDB_TYPE_LIST_QUICKLIST_2:
 		entry.Value, err = p.parseList(typeByte)
 
 	case RDB_TYPE_SET, RDB_TYPE_SET_INTSET, RDB_TYPE_SET_LISTPACK:
@@ -348,17 +451,32 @@ func (p *RDBParser) parseKeyValue(typeByte byte) (*RDBEntry, error) {
 	case RDB_TYPE_STREAM_LISTPACKS, RDB_TYPE_STREAM_LISTPACKS_2, RDB_TYPE_STREAM_LISTPACKS_3:
 		entry.Value, err = p.parseStream(typeByte)
 
+	case RDB_TYPE_SBF:
+		entry.Value, err = p.parseSBF()
+
 	default:
 		// Unknown module/stream etc.
 		return nil, fmt.Errorf("unsupported RDB type: %d (key=%s)", typeByte, key)
 	}
 
+	if capture {
+		captureFrom.active = false
+		// A value split across compressed blobs isn't captured completely; write it with commands
+		if err == nil && captureFrom == p.reader {
+			if p.dumpMinElements == 0 || entry.ElementCount() > p.dumpMinElements {
+				entry.Dump = buildDumpPayload(typeByte, captureFrom.buf, p.rdbVersion)
+			}
+		}
+	}
+
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse value (type=%d, key=%s): %w", typeByte, key, err)
 	}
 
-	// Reset expiration tracking
+	// Reset per-key metadata
 	p.expireMs = 0
+	p.idleSec = 0
+	p.freq = -1
 
 	return entry, nil
 }
@@ -482,36 +600,46 @@ func (p *RDBParser) readLength() (uint64, bool, error) {
 	return 0, false, fmt.Errorf("invalid length encoding type: %d", typeField)
 }
 
+// openCompressedBlob prepares a length-limited view over the compressed blob
+// that follows a 0xC9/0xCA opcode. The blob is consumed straight from the
+// network stream instead of being buffered in memory.
+func (p *RDBParser) openCompressedBlob() (*io.LimitedReader, error) {
+	length, special, err := p.readLength()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read compressed blob length: %w", err)
+	}
+	if special {
+		return nil, fmt.Errorf("unexpected special string encoding (%d) for compressed blob", length)
+	}
+	return &io.LimitedReader{R: p.originalReader, N: int64(length)}, nil
+}
+
+// switchToBlobReader makes the decompressing stream the active reader.
+// RDB_OPCODE_COMPRESSED_BLOB_END (0xCB) is appended after the decompressed
+// data, matching Dragonfly's decompress.cc which adds this opcode to membuf.
+func (p *RDBParser) switchToBlobReader(limited *io.LimitedReader, decompressed io.Reader, closer func()) {
+	p.blobInput = limited
+	p.blobCloser = closer
+	decompressed = &decompressTimer{r: decompressed, p: p}
+	p.reader = newCaptureReader(bufio.NewReader(io.MultiReader(decompressed, bytes.NewReader([]byte{RDB_OPCODE_COMPRESSED_BLOB_END}))))
+}
+
 // handleZstdBlob handles ZSTD compressed blob (opcode 0xC9)
 func (p *RDBParser) handleZstdBlob() error {
-	// Read compressed data as a length-prefixed string
-	compressedData, err := p.readStringFull()
+	p.zstdBlobCount++
+
+	limited, err := p.openCompressedBlob()
 	if err != nil {
 		return fmt.Errorf("failed to read ZSTD compressed data: %w", err)
 	}
 
-	// Decompress using ZSTD
 	// Dragonfly uses ZSTD frame format with embedded metadata
-	decoder, err := zstd.NewReader(bytes.NewReader([]byte(compressedData)))
+	decoder, err := zstd.NewReader(limited, zstd.WithDecoderConcurrency(1))
 	if err != nil {
 		return fmt.Errorf("failed to create ZSTD decoder: %w", err)
 	}
-	defer decoder.Close()
-
-	decompressed, err := io.ReadAll(decoder)
-	if err != nil {
-		return fmt.Errorf("ZSTD decompression failed: %w", err)
-	}
-
-	// Append RDB_OPCODE_COMPRESSED_BLOB_END (0xCB) to the decompressed data
-	// This matches Dragonfly's behavior: decompress.cc adds this opcode to membuf
-	decompressedWithEnd := make([]byte, len(decompressed)+1)
-	copy(decompressedWithEnd, decompressed)
-	decompressedWithEnd[len(decompressed)] = RDB_OPCODE_COMPRESSED_BLOB_END
-
-	// Switch to reading from decompressed buffer (including the end marker)
-	p.reader = bufio.NewReader(bytes.NewReader(decompressedWithEnd))
 
+	p.switchToBlobReader(limited, decoder, decoder.Close)
 	return nil
 }
 
@@ -520,44 +648,36 @@ func (p *RDBParser) handleLZ4Blob() error {
 	p.lz4BlobCount++
 	blobNum := p.lz4BlobCount
 
-	// Read compressed data as a length-prefixed string
-	compressedData, err := p.readStringFull()
+	limited, err := p.openCompressedBlob()
 	if err != nil {
 		log.Printf("  [FLOW-%d] ✗ LZ4 blob #%d: failed to read compressed data: %v", p.flowID, blobNum, err)
 		return fmt.Errorf("failed to read compressed data (blob #%d): %w", blobNum, err)
 	}
-	log.Printf("  [FLOW-%d] → LZ4 blob #%d: read %d bytes of compressed data", p.flowID, blobNum, len(compressedData))
 
 	// Decompress using LZ4 Frame format (not Block format)
 	// Dragonfly uses LZ4F_compressFrame which produces Frame format with embedded metadata
-	decompressStart := time.Now()
-	reader := lz4.NewReader(bytes.NewReader([]byte(compressedData)))
-	decompressed, err := io.ReadAll(reader)
-	decompressDuration := time.Since(decompressStart)
-	if err != nil {
-		return fmt.Errorf("LZ4 Frame decompression failed: %w", err)
-	}
-
-	// Log slow decompressions (>1 second)
-	if decompressDuration > time.Second {
-		log.Printf("  [FLOW-%d] ⚠ LZ4 blob #%d: decompression took %v (compressed: %d bytes → uncompressed: %d bytes)",
-			p.flowID, blobNum, decompressDuration, len(compressedData), len(decompressed))
-	}
-
-	// Append RDB_OPCODE_COMPRESSED_BLOB_END (0xCB) to the decompressed data
-	// This matches Dragonfly's behavior: decompress.cc adds this opcode to membuf
-	decompressedWithEnd := make([]byte, len(decompressed)+1)
-	copy(decompressedWithEnd, decompressed)
-	decompressedWithEnd[len(decompressed)] = RDB_OPCODE_COMPRESSED_BLOB_END
-
-	// Switch to reading from decompressed buffer (including the end marker)
-	p.reader = bufio.NewReader(bytes.NewReader(decompressedWithEnd))
-
+	p.switchToBlobReader(limited, lz4.NewReader(limited), nil)
 	return nil
 }
 
 // handleLZ4BlobEnd handles compressed blob end marker (opcode 0xCB)
 func (p *RDBParser) handleLZ4BlobEnd() error {
+	if p.blobCloser != nil {
+		p.blobCloser()
+		p.blobCloser = nil
+	}
+
+	// The decoder may stop before consuming trailing frame bytes; skip them so
+	// the network stream is positioned right after the blob.
+	if p.blobInput != nil {
+		if p.blobInput.N > 0 {
+			if _, err := io.Copy(io.Discard, p.blobInput); err != nil {
+				return fmt.Errorf("failed to skip compressed blob remainder: %w", err)
+			}
+		}
+		p.blobInput = nil
+	}
+
 	// Switch back to original network stream
 	p.reader = p.originalReader
 	return nil
@@ -0,0 +1,53 @@
+package replica
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestParseNextEvictionOpcodes(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write([]byte{RDB_OPCODE_IDLE, 0x40, 0x96}) // 14-bit length: 150s
+	stream.WriteByte(RDB_TYPE_STRING)
+	stream.Write(rdbString([]byte("lru")))
+	stream.Write(rdbString([]byte("v1")))
+	stream.Write([]byte{RDB_OPCODE_FREQ, 7})
+	stream.WriteByte(RDB_TYPE_STRING)
+	stream.Write(rdbString([]byte("lfu")))
+	stream.Write(rdbString([]byte("v2")))
+	stream.WriteByte(RDB_TYPE_STRING)
+	stream.Write(rdbString([]byte("plain")))
+	stream.Write(rdbString([]byte("v3")))
+	stream.Write([]byte{RDB_OPCODE_EOF, 0, 0, 0, 0, 0, 0, 0, 0})
+
+	p := NewRDBParser(bytes.NewReader(stream.Bytes()), 0)
+	var entries []*RDBEntry
+	for {
+		entry, err := p.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ParseNext: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	if e := entries[0]; e.Key != "lru" || e.IdleSec != 150 || e.HasFreq {
+		t.Errorf("lru entry = %+v", e)
+	}
+	if e := entries[1]; e.Key != "lfu" || !e.HasFreq || e.Freq != 7 || e.IdleSec != 0 {
+		t.Errorf("lfu entry = %+v", e)
+	}
+	// Metadata applies to a single key only
+	if e := entries[2]; e.Key != "plain" || e.HasFreq || e.IdleSec != 0 {
+		t.Errorf("plain entry = %+v", e)
+	}
+	if v, ok := entries[2].Value.(*StringValue); !ok || v.Value != "v3" {
+		t.Errorf("plain value = %+v", entries[2].Value)
+	}
+}
@@ -0,0 +1,153 @@
+package replica
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+)
+
+// SBFValue describes a Dragonfly scalable bloom filter (RDB_TYPE_SBF).
+// Dragonfly hashes members with its own functions, so the filter bits can't
+// be loaded into RedisBloom; only the sizing parameters are kept.
+type SBFValue struct {
+	GrowFactor  float64
+	FPProb      float64 // target false-positive probability
+	PrevSize    uint64  // items held by the filters before the current one
+	CurrentSize uint64  // items in the current filter
+	MaxCapacity uint64  // capacity of the current filter
+	Filters     []SBFFilter
+}
+
+// SBFFilter is one layer of a scalable bloom filter.
+type SBFFilter struct {
+	HashCount uint64
+	Bytes     int // size of the bit array as serialized
+}
+
+// Items returns the approximate number of members added to the filter.
+func (v *SBFValue) Items() uint64 {
+	return v.PrevSize + v.CurrentSize
+}
+
+// ApproxSize returns the serialized size of the filter bit arrays.
+func (v *SBFValue) ApproxSize() int {
+	if v == nil {
+		return 0
+	}
+	n := 0
+	for _, f := range v.Filters {
+		n += f.Bytes
+	}
+	return n
+}
+
+// InitialCapacity estimates the capacity of the first layer, which is what
+// BF.RESERVE expects: each layer grows by GrowFactor over the previous one.
+func (v *SBFValue) InitialCapacity() uint64 {
+	capacity := float64(v.MaxCapacity)
+	if len(v.Filters) > 1 && v.GrowFactor > 1 {
+		capacity /= math.Pow(v.GrowFactor, float64(len(v.Filters)-1))
+	}
+	if capacity < 1 {
+		return 1
+	}
+	return uint64(math.Round(capacity))
+}
+
+// parseSBF decodes Dragonfly's SBF layout:
+// options(len, reserved) grow(double) fp(double) prev_size(len) current_size(len)
+// max_capacity(len) num_filters(len) then per filter: hash_cnt(len) + data(string).
+func (p *RDBParser) parseSBF() (*SBFValue, error) {
+	options, _, err := p.readLength()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SBF options: %w", err)
+	}
+	if options != 0 {
+		return nil, fmt.Errorf("unsupported SBF options %d", options)
+	}
+
+	v := &SBFValue{}
+	if v.GrowFactor, err = p.readDouble(); err != nil {
+		return nil, fmt.Errorf("failed to read SBF grow factor: %w", err)
+	}
+	if v.FPProb, err = p.readDouble(); err != nil {
+		return nil, fmt.Errorf("failed to read SBF fp probability: %w", err)
+	}
+	for _, field := range []*uint64{&v.PrevSize, &v.CurrentSize, &v.MaxCapacity} {
+		if *field, _, err = p.readLength(); err != nil {
+			return nil, fmt.Errorf("failed to read SBF sizes: %w", err)
+		}
+	}
+
+	numFilters, _, err := p.readLength()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SBF filter count: %w", err)
+	}
+	v.Filters = make([]SBFFilter, 0, numFilters)
+	for i := uint64(0); i < numFilters; i++ {
+		hashCount, _, err := p.readLength()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SBF filter %d hash count: %w", i, err)
+		}
+		data, err := p.readStringFull()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SBF filter %d data: %w", i, err)
+		}
+		v.Filters = append(v.Filters, SBFFilter{HashCount: hashCount, Bytes: len(data)})
+	}
+	return v, nil
+}
+
+// sbfReserveArgs builds BF.RESERVE for an empty RedisBloom filter with the
+// same error rate, capacity and growth as the source filter.
+func sbfReserveArgs(key string, v *SBFValue) []interface{} {
+	expansion := int(math.Round(v.GrowFactor))
+	if expansion < 1 {
+		expansion = 1
+	}
+	return []interface{}{"BF.RESERVE", key,
+		strconv.FormatFloat(v.FPProb, 'g', -1, 64),
+		strconv.FormatUint(v.InitialCapacity(), 10),
+		"EXPANSION", strconv.Itoa(expansion),
+	}
+}
+
+// detectBloomSupport reports whether the target accepts RedisBloom commands.
+func (r *Replicator) detectBloomSupport() bool {
+	reply, err := r.clusterClient.Do("COMMAND", "INFO", "BF.RESERVE")
+	if err != nil {
+		return false
+	}
+	arr, ok := reply.([]interface{})
+	return ok && len(arr) == 1 && arr[0] != nil
+}
+
+// writeSBF recreates a bloom filter on the target as an empty RedisBloom
+// filter with matching parameters.
+func (r *Replicator) writeSBF(entry *RDBEntry) error {
+	sbf, ok := entry.Value.(*SBFValue)
+	if !ok {
+		return fmt.Errorf("failed to convert SBF value")
+	}
+
+	r.rdbStats.mu.Lock()
+	r.rdbStats.Commands++
+	r.rdbStats.mu.Unlock()
+
+	args := sbfReserveArgs(entry.Key, sbf)
+	if _, err := r.clusterClient.Do(args[0].(string), args[1:]...); err != nil {
+		return fmt.Errorf("BF.RESERVE command failed: %w", err)
+	}
+	if sbf.Items() > 0 {
+		log.Printf("  ⚠ Bloom filter %s recreated empty (%d members can't be transferred from Dragonfly)", entry.Key, sbf.Items())
+	}
+
+	if entry.ExpireMs > 0 {
+		args := expireArgs(entry.Key, entry.ExpireMs, r.absoluteTTL())
+		if _, err := r.clusterClient.Do(args[0].(string), args[1:]...); err != nil {
+			return fmt.Errorf("%s command failed: %w", args[0], err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package replica
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestParseSBF(t *testing.T) {
+	var buf bytes.Buffer
+	buf.Write(rdbString([]byte("bf:users")))
+	buf.WriteByte(0) // options
+	buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(2)))
+	buf.Write(binary.LittleEndian.AppendUint64(nil, math.Float64bits(0.01)))
+	buf.Write([]byte{10, 5, 40, 2}) // prev_size, current_size, max_capacity, num_filters
+	buf.WriteByte(7)
+	buf.Write(rdbString(make([]byte, 16)))
+	buf.WriteByte(7)
+	buf.Write(rdbString(make([]byte, 32)))
+	buf.WriteByte(0xAA) // next opcode must be left unread
+
+	p := NewRDBParser(bytes.NewReader(buf.Bytes()), 0)
+	entry, err := p.parseKeyValue(RDB_TYPE_SBF)
+	if err != nil {
+		t.Fatalf("parseKeyValue: %v", err)
+	}
+	sbf, ok := entry.Value.(*SBFValue)
+	if !ok {
+		t.Fatalf("value is %T, want *SBFValue", entry.Value)
+	}
+	if entry.Key != "bf:users" || sbf.FPProb != 0.01 || sbf.Items() != 15 || len(sbf.Filters) != 2 {
+		t.Fatalf("unexpected filter %s: %+v", entry.Key, sbf)
+	}
+	if sbf.Filters[1].Bytes != 32 || sbf.Filters[1].HashCount != 7 {
+		t.Errorf("filter 1 = %+v", sbf.Filters[1])
+	}
+	if next, _ := p.readByte(); next != 0xAA {
+		t.Errorf("parser consumed past the value, next byte = 0x%02X", next)
+	}
+
+	// Two layers with growth 2: the first layer held half of max_capacity
+	args := sbfReserveArgs(entry.Key, sbf)
+	want := []interface{}{"BF.RESERVE", "bf:users", "0.01", "20", "EXPANSION", "2"}
+	for i := range want {
+		if args[i] != want[i] {
+			t.Fatalf("BF.RESERVE args = %v, want %v", args, want)
+		}
+	}
+}
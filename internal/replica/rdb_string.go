@@ -75,7 +75,7 @@ func (p *RDBParser) readStringEncoded(encoding uint64) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		return strconv.Itoa(int(val)), nil
+		return strconv.FormatInt(int64(val), 10), nil
 
 	case RDB_ENC_INT16:
 		// 16-bit integer
@@ -83,7 +83,7 @@ func (p *RDBParser) readStringEncoded(encoding uint64) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		return strconv.Itoa(int(val)), nil
+		return strconv.FormatInt(int64(val), 10), nil
 
 	case RDB_ENC_INT32:
 		// 32-bit integer
@@ -91,7 +91,7 @@ func (p *RDBParser) readStringEncoded(encoding uint64) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		return strconv.Itoa(int(val)), nil
+		return strconv.FormatInt(int64(val), 10), nil
 
 	case RDB_ENC_LZF:
 		// LZF-compressed string
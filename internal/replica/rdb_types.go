@@ -6,6 +6,10 @@ const (
 	RDB_OPCODE_EXPIRETIME_MS = 0xFC // expire time in milliseconds (8 bytes)
 	RDB_OPCODE_EXPIRETIME    = 0xFD // expire time in seconds (4 bytes)
 
+	// Eviction metadata (written when the source has an LRU/LFU maxmemory-policy)
+	RDB_OPCODE_IDLE = 0xF8 // LRU idle time in seconds (length-encoded)
+	RDB_OPCODE_FREQ = 0xF9 // LFU access frequency (1 byte)
+
 	// Database selection
 	// Database selection
 	RDB_OPCODE_SELECTDB = 0xFE // SELECT <db>
@@ -56,6 +60,12 @@ const (
 	RDB_TYPE_SET_LISTPACK       = 20 // Set encoded as listpack
 	RDB_TYPE_STREAM_LISTPACKS_3 = 21 // Stream listpack v3
 
+	// Redis 7.4+ hash field expiration encodings
+	RDB_TYPE_HASH_METADATA_PRE_GA    = 22 // Hash with absolute per-field TTL (7.4 RC)
+	RDB_TYPE_HASH_LISTPACK_EX_PRE_GA = 23 // Listpack hash with TTL, no min-expiry header (7.4 RC)
+	RDB_TYPE_HASH_METADATA           = 24 // Hash with min-expiry header + relative per-field TTL
+	RDB_TYPE_HASH_LISTPACK_EX        = 25 // Listpack hash of (field, value, ttl) triples
+
 	// Dragonfly custom types (30-35)
 	RDB_TYPE_JSON             = 30
 	RDB_TYPE_HASH_WITH_EXPIRY = 31 // Hash with per-field TTL
@@ -94,6 +104,14 @@ type RDBEntry struct {
 	Value    interface{} // decoded value (type-dependent)
 	ExpireMs int64       // absolute expiration timestamp in ms; 0 means no TTL
 	DbIndex  int         // database index
+
+	// Dump is a DUMP payload for RESTORE (migrate.writeMode: auto); nil = write with commands
+	Dump []byte
+
+	// Eviction metadata from RDB_OPCODE_IDLE / RDB_OPCODE_FREQ, replayed with RESTORE
+	IdleSec int64 // LRU idle time in seconds; 0 = none
+	Freq    byte  // LFU counter; only meaningful when HasFreq
+	HasFreq bool
 }
 
 // StringValue wraps a plain string
@@ -104,6 +122,9 @@ type StringValue struct {
 // HashValue contains hash fields
 type HashValue struct {
 	Fields map[string]string
+
+	// FieldExpiry holds per-field absolute expiry in ms (nil when no field has a TTL)
+	FieldExpiry map[string]int64
 }
 
 // ListValue stores list elements
@@ -140,6 +161,184 @@ type StreamMessage struct {
 	Fields map[string]string // Field-value pairs
 }
 
+// valueSizer is implemented by decoded values that can estimate their payload size.
+type valueSizer interface {
+	ApproxSize() int
+}
+
+// ApproxSize estimates the bytes this entry puts on the wire (key plus value,
+// or the DUMP payload when it will be restored). Used for byte-based batching.
+func (e *RDBEntry) ApproxSize() int {
+	if e.Dump != nil {
+		return len(e.Key) + len(e.Dump)
+	}
+	if v, ok := e.Value.(valueSizer); ok && v != nil {
+		return len(e.Key) + v.ApproxSize()
+	}
+	return len(e.Key)
+}
+
+// ApproxSize returns the string length.
+func (v *StringValue) ApproxSize() int {
+	if v == nil {
+		return 0
+	}
+	return len(v.Value)
+}
+
+// ApproxSize sums field names and values (plus 8 bytes per field TTL).
+func (v *HashValue) ApproxSize() int {
+	if v == nil {
+		return 0
+	}
+	n := 8 * len(v.FieldExpiry)
+	for field, value := range v.Fields {
+		n += len(field) + len(value)
+	}
+	return n
+}
+
+// ApproxSize sums element lengths.
+func (v *ListValue) ApproxSize() int {
+	if v == nil {
+		return 0
+	}
+	n := 0
+	for _, elem := range v.Elements {
+		n += len(elem)
+	}
+	return n
+}
+
+// ApproxSize sums member lengths.
+func (v *SetValue) ApproxSize() int {
+	if v == nil {
+		return 0
+	}
+	n := 0
+	for _, member := range v.Members {
+		n += len(member)
+	}
+	return n
+}
+
+// ApproxSize sums member lengths plus 8 bytes per score.
+func (v *ZSetValue) ApproxSize() int {
+	if v == nil {
+		return 0
+	}
+	n := 8 * len(v.Members)
+	for _, m := range v.Members {
+		n += len(m.Member)
+	}
+	return n
+}
+
+// ApproxSize sums message IDs, field names and values.
+func (v *StreamValue) ApproxSize() int {
+	if v == nil {
+		return 0
+	}
+	n := len(v.LastID)
+	for _, msg := range v.Messages {
+		n += len(msg.ID)
+		for field, value := range msg.Fields {
+			n += len(field) + len(value)
+		}
+	}
+	return n
+}
+
+// ElementCount returns the number of fields/elements/members of a collection
+// entry, or 0 for strings and other values.
+func (e *RDBEntry) ElementCount() int {
+	switch v := e.Value.(type) {
+	case *HashValue:
+		if v != nil {
+			return len(v.Fields)
+		}
+	case *ListValue:
+		if v != nil {
+			return len(v.Elements)
+		}
+	case *SetValue:
+		if v != nil {
+			return len(v.Members)
+		}
+	case *ZSetValue:
+		if v != nil {
+			return len(v.Members)
+		}
+	}
+	return 0
+}
+
+// LargestElement returns the length of the biggest single bulk string the
+// entry's write sends: the DUMP payload, a string value, or the longest
+// field, value, element or member of a collection.
+func (e *RDBEntry) LargestElement() int {
+	if e.Dump != nil {
+		return len(e.Dump)
+	}
+	n := 0
+	switch v := e.Value.(type) {
+	case *StringValue:
+		if v != nil {
+			n = len(v.Value)
+		}
+	case *HashValue:
+		if v != nil {
+			for field, value := range v.Fields {
+				n = max(n, len(field), len(value))
+			}
+		}
+	case *ListValue:
+		if v != nil {
+			for _, elem := range v.Elements {
+				n = max(n, len(elem))
+			}
+		}
+	case *SetValue:
+		if v != nil {
+			for _, member := range v.Members {
+				n = max(n, len(member))
+			}
+		}
+	case *ZSetValue:
+		if v != nil {
+			for _, zm := range v.Members {
+				n = max(n, len(zm.Member))
+			}
+		}
+	case *StreamValue:
+		if v != nil {
+			for _, msg := range v.Messages {
+				for field, value := range msg.Fields {
+					n = max(n, len(field), len(value))
+				}
+			}
+		}
+	}
+	return n
+}
+
+// IsEmptyCollection reports whether a collection entry parsed to zero elements.
+// Redis can't hold empty collections, so such entries need no write at all.
+func (e *RDBEntry) IsEmptyCollection() bool {
+	switch v := e.Value.(type) {
+	case *HashValue:
+		return v == nil || len(v.Fields) == 0
+	case *ListValue:
+		return v == nil || len(v.Elements) == 0
+	case *SetValue:
+		return v == nil || len(v.Members) == 0
+	case *ZSetValue:
+		return v == nil || len(v.Members) == 0
+	default:
+		return false
+	}
+}
+
 // IsExpired evaluates the TTL
 func (e *RDBEntry) IsExpired() bool {
 	if e.ExpireMs == 0 {
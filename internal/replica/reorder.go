@@ -0,0 +1,99 @@
+package replica
+
+import (
+	"container/heap"
+	"time"
+)
+
+// reorderBuffer holds journal entries from all FLOWs for a short window and
+// releases them in transaction order, so commands that hit different shards
+// replay in the order the source committed them rather than the order their
+// FLOW connections happened to deliver them.
+//
+// The stamped LSNs are per-FLOW positions and not comparable across FLOWs, so
+// entries are keyed by Dragonfly's global TxID instead. Within a FLOW the key
+// never decreases (entries without a TxID, such as SELECT, LSN and PING,
+// inherit the previous key), which keeps each FLOW's own order intact and
+// means an entry is never applied before one that preceded it on the same
+// connection. Equal keys fall back to arrival order.
+//
+// The window is a tradeoff: every entry is delayed by up to window before it
+// is applied, and two commands are only put back in order if the later one
+// arrives less than window after the earlier one.
+type reorderBuffer struct {
+	window  time.Duration
+	items   reorderHeap
+	lastKey map[int]uint64 // highest key seen per FLOW
+	seq     uint64
+}
+
+type reorderItem struct {
+	key     uint64
+	seq     uint64
+	arrived time.Time
+	entry   *FlowEntry
+}
+
+func newReorderBuffer(window time.Duration) *reorderBuffer {
+	return &reorderBuffer{window: window, lastKey: make(map[int]uint64)}
+}
+
+// Len returns the number of buffered entries (0 for a nil buffer).
+func (b *reorderBuffer) Len() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.items)
+}
+
+// push buffers an entry that arrived at now.
+func (b *reorderBuffer) push(fe *FlowEntry, now time.Time) {
+	key := b.lastKey[fe.FlowID]
+	if fe.Entry != nil && fe.Entry.TxID > key {
+		key = fe.Entry.TxID
+	}
+	b.lastKey[fe.FlowID] = key
+	b.seq++
+	heap.Push(&b.items, &reorderItem{key: key, seq: b.seq, arrived: now, entry: fe})
+}
+
+// popDue removes and returns the lowest-keyed entry once it has been held for
+// the full window, or nil if nothing is due yet.
+func (b *reorderBuffer) popDue(now time.Time) *FlowEntry {
+	if b.Len() == 0 || now.Sub(b.items[0].arrived) < b.window {
+		return nil
+	}
+	return heap.Pop(&b.items).(*reorderItem).entry
+}
+
+// pop removes and returns the lowest-keyed entry regardless of the window,
+// or nil when the buffer is empty. Used to flush at the end of the stream.
+func (b *reorderBuffer) pop() *FlowEntry {
+	if b.Len() == 0 {
+		return nil
+	}
+	return heap.Pop(&b.items).(*reorderItem).entry
+}
+
+type reorderHeap []*reorderItem
+
+func (h reorderHeap) Len() int { return len(h) }
+
+func (h reorderHeap) Less(i, j int) bool {
+	if h[i].key != h[j].key {
+		return h[i].key < h[j].key
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h reorderHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *reorderHeap) Push(x interface{}) { *h = append(*h, x.(*reorderItem)) }
+
+func (h *reorderHeap) Pop() interface{} {
+	old := *h
+	it := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return it
+}
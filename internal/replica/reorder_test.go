@@ -0,0 +1,62 @@
+package replica
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReorderBufferOrdersByTxID(t *testing.T) {
+	b := newReorderBuffer(10 * time.Millisecond)
+	t0 := time.Now()
+
+	cmd := func(flow int, txid uint64) *FlowEntry {
+		return &FlowEntry{FlowID: flow, Entry: &JournalEntry{Opcode: OpCommand, TxID: txid}}
+	}
+	// FLOW-1 delivers tx 5 before FLOW-0 delivers tx 3 and 4
+	b.push(cmd(1, 5), t0)
+	b.push(cmd(0, 3), t0.Add(time.Millisecond))
+	// A PING carries no TxID and must stay behind FLOW-0's tx 3
+	b.push(&FlowEntry{FlowID: 0, Entry: &JournalEntry{Opcode: OpPing}}, t0.Add(2*time.Millisecond))
+	b.push(cmd(0, 4), t0.Add(3*time.Millisecond))
+
+	if fe := b.popDue(t0.Add(5 * time.Millisecond)); fe != nil {
+		t.Fatalf("released %+v before the window passed", fe.Entry)
+	}
+
+	var got []string
+	now := t0.Add(20 * time.Millisecond)
+	for fe := b.popDue(now); fe != nil; fe = b.popDue(now) {
+		got = append(got, fmt.Sprintf("%s@%d#%d", fe.Entry.Opcode, fe.FlowID, fe.Entry.TxID))
+	}
+	want := []string{"COMMAND@0#3", "PING@0#0", "COMMAND@0#4", "COMMAND@1#5"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if b.Len() != 0 {
+		t.Fatalf("Len = %d after draining", b.Len())
+	}
+}
+
+func TestReorderBufferKeepsFlowOrder(t *testing.T) {
+	// A lower TxID later on the same FLOW must not jump ahead of earlier entries
+	b := newReorderBuffer(time.Millisecond)
+	t0 := time.Now()
+	b.push(&FlowEntry{FlowID: 0, Entry: &JournalEntry{Opcode: OpCommand, TxID: 9, LSN: 1}}, t0)
+	b.push(&FlowEntry{FlowID: 0, Entry: &JournalEntry{Opcode: OpCommand, TxID: 2, LSN: 2}}, t0)
+
+	for want := uint64(1); want <= 2; want++ {
+		fe := b.pop()
+		if fe == nil || fe.Entry.LSN != want {
+			t.Fatalf("pop = %+v, want LSN %d", fe, want)
+		}
+	}
+	if b.pop() != nil {
+		t.Fatal("pop on empty buffer returned an entry")
+	}
+
+	var nilBuf *reorderBuffer
+	if nilBuf.Len() != 0 || nilBuf.pop() != nil || nilBuf.popDue(t0) != nil {
+		t.Fatal("nil buffer should behave as empty")
+	}
+}
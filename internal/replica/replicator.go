@@ -3,6 +3,8 @@ package replica
 import (
 	"bufio"
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -18,6 +20,7 @@ import (
 	"df2redis/internal/logger"
 	"df2redis/internal/redisx"
 	"df2redis/internal/state"
+	"df2redis/internal/tracing"
 )
 
 // Replicator establishes the replication relationship with Dragonfly
@@ -32,6 +35,7 @@ type Replicator struct {
 	// Dedicated connections for each FLOW
 	flowConns      []*redisx.Client
 	flowBufReaders []*bufio.Reader
+	flowTrackers   []*streamTracker // byte offsets/history for diagnosing stream desyncs
 
 	// Redis Cluster client (replay commands)
 	clusterClient *redisx.ClusterClient
@@ -51,15 +55,66 @@ type Replicator struct {
 	listeningPort int
 	announceIP    string
 
+	// Connections subject to the handshake deadline (set during handshake)
+	handshakeConns *handshakeGuard
+
+	// partialSync is set when every FLOW resumed from resumeLSNs
+	partialSync bool
+
+	// Per-FLOW LSNs requested for partial sync: --lsn, else the saved
+	// checkpoint. resumeFrom is that checkpoint (nil for --lsn).
+	resumeLSNs map[int]uint64
+	resumeFrom *checkpoint.Checkpoint
+
 	// Replay statistics
 	replayStats ReplayStats
 
+	// Journal command allow/deny list
+	cmdFilter *commandFilter
+
+	// Snapshot data types to write (migrate.includeTypes; nil = all)
+	typeFilter *typeFilter
+
+	skippedKeys *skippedKeyLog
+
+	// skipAsOverwrite is set while a full sync writes into an empty target
+	// (conflict.emptyTargetOverwrite), see beginFullSyncConflicts.
+	skipAsOverwrite atomic.Bool
+
+	// First duplicate key found by the snapshot writers under
+	// conflict.policy=panic (see filterSnapshotConflicts)
+	conflictMu    sync.Mutex
+	conflictAbort error
+
+	// Rewrites keys/values before they reach the target (nil = unchanged)
+	transformer Transformer
+
+	// Extra targets that receive a copy of every write (see mirror.go)
+	mirrors []*mirrorTarget
+
+	// Abort threshold for migrate.maxWriteFailures / maxWriteFailureRate
+	writeBudget *writeBudget
+
+	// Recreate bloom filters (migrate.restoreBloomFilters and target has RedisBloom)
+	restoreBloom bool
+
+	// Target RDB version for migrate.writeMode=auto (0 = always write with commands)
+	dumpTargetVersion int
+	// Only collections above this many elements are RESTOREd (migrate.restoreThresholdElements; 0 = all)
+	dumpMinElements int
+	// Target proto-max-bulk-len checked against snapshot values (migrate.oversizeValueAction)
+	protoMaxBulkLen int64
+
 	// RDB snapshot statistics
 	rdbStats RDBStats
 
 	// Automatic checkpoint saving
 	checkpointInterval time.Duration
-	lastCheckpointTime time.Time
+	lastCheckpointTime time.Time // guarded by replayStats.mu
+	entriesSinceSave   int       // applied entries since the last save, guarded by replayStats.mu
+
+	// Journal entries waiting for the target to recover (stable sync only; nil = disabled)
+	retryQ *retryQueue
 
 	// Channel used to wait for Start() to finish
 	done chan struct{}
@@ -73,6 +128,10 @@ type Replicator struct {
 	totalSyncedKeys   int64
 	initialTargetKeys float64
 
+	// Snapshot progress (source DBSIZE used as the denominator)
+	sourceKeysEstimated float64
+	snapshotStartTime   time.Time
+
 	// Global performance metrics (aggregated from all flows)
 	globalPerfMetrics struct {
 		opsWindow  []opsRecord // Global operation window (all flows combined)
@@ -103,6 +162,11 @@ func NewReplicator(cfg *config.Config) *Replicator {
 	// Checkpoint file path: use configured path or the default path
 	checkpointPath := cfg.ResolveCheckpointPath()
 
+	checkpointMgr := checkpoint.NewManager(checkpointPath)
+	if cfg.Checkpoint.PerFlow {
+		checkpointMgr = checkpoint.NewPerFlowManager(checkpointPath)
+	}
+
 	// Checkpoint save interval: read from config (default 10 seconds)
 	checkpointInterval := time.Duration(cfg.Checkpoint.Interval) * time.Second
 
@@ -111,11 +175,72 @@ func NewReplicator(cfg *config.Config) *Replicator {
 		ctx:                ctx,
 		cancel:             cancel,
 		state:              StateDisconnected,
-		listeningPort:      16379, // default port
-		checkpointMgr:      checkpoint.NewManager(checkpointPath),
+		listeningPort:      cfg.Source.ReplicaListeningPort,
+		announceIP:         cfg.Source.AnnounceIP,
+		checkpointMgr:      checkpointMgr,
 		checkpointInterval: checkpointInterval,
-		done:               make(chan struct{}),
+		cmdFilter:          newCommandFilter(cfg.Conflict.CommandAllowList, cfg.Conflict.CommandDenyList),
+		transformer:        newPrefixRewriter(cfg.Migrate.KeyRewrites),
+		typeFilter:         newTypeFilter(cfg.Migrate.IncludeTypes),
+		skippedKeys:        newSkippedKeyLog(cfg.SkippedKeysPath()),
+		writeBudget:        newWriteBudget(cfg.Migrate.MaxWriteFailures, cfg.Migrate.MaxWriteFailureRate),
+		resumeLSNs:         startFlowLSNs(cfg.Checkpoint.StartLSNs),
+		replayStats: ReplayStats{
+			FlowLSNs:    startFlowLSNs(cfg.Checkpoint.StartLSNs),
+			AppliedLSNs: startFlowLSNs(cfg.Checkpoint.StartLSNs),
+		},
+		done: make(chan struct{}),
+	}
+}
+
+// startFlowLSNs seeds the replay LSNs from a manual --lsn override or a
+// loaded checkpoint.
+func startFlowLSNs(lsns map[int]uint64) map[int]uint64 {
+	if len(lsns) == 0 {
+		return nil
+	}
+	seeded := make(map[int]uint64, len(lsns))
+	for flowID, lsn := range lsns {
+		seeded[flowID] = lsn
+	}
+	return seeded
+}
+
+// loadResumeCheckpoint seeds resumeLSNs and the duplicate low-water marks
+// from the saved checkpoint when no --lsn was given. A missing or unreadable
+// checkpoint means a full sync.
+func (r *Replicator) loadResumeCheckpoint() {
+	if !r.cfg.Checkpoint.Enabled || len(r.resumeLSNs) > 0 {
+		return
+	}
+	cp, err := r.checkpointMgr.Load()
+	if err != nil {
+		log.Printf("  ⚠ Ignoring checkpoint, full sync required: %v", err)
+		return
+	}
+	if cp == nil || len(cp.FlowLSNs) == 0 {
+		return
 	}
+	log.Printf("  📍 Resuming from checkpoint %s (replication %s, LSNs %v)",
+		r.cfg.ResolveCheckpointPath(), cp.ReplicationID, cp.FlowLSNs)
+	r.resumeFrom = cp
+	r.resumeLSNs = startFlowLSNs(cp.FlowLSNs)
+	r.replayStats.mu.Lock()
+	r.replayStats.FlowLSNs = startFlowLSNs(cp.FlowLSNs)
+	r.replayStats.AppliedLSNs = startFlowLSNs(cp.FlowLSNs)
+	r.replayStats.mu.Unlock()
+}
+
+// forgetResumeLSN drops the resume position of a FLOW the master answered
+// with a full sync. The journal that follows the snapshot is numbered by the
+// master, not by the old position, so keeping it would drop new commands as
+// duplicates (e.g. after a master restart reset its LSNs).
+func (r *Replicator) forgetResumeLSN(flowID int) {
+	delete(r.resumeLSNs, flowID)
+	r.replayStats.mu.Lock()
+	delete(r.replayStats.FlowLSNs, flowID)
+	delete(r.replayStats.AppliedLSNs, flowID)
+	r.replayStats.mu.Unlock()
 }
 
 // AttachStateStore wires a state store for dashboard metrics.
@@ -126,65 +251,92 @@ func (r *Replicator) AttachStateStore(store *state.Store) {
 	}
 }
 
+// SetTransformer replaces the key/value transformer built from
+// migrate.keyRewrites; call it before Start.
+func (r *Replicator) SetTransformer(t Transformer) {
+	r.transformer = t
+}
+
 // Start launches the replication workflow
 func (r *Replicator) Start() error {
 	defer close(r.done) // ensure Stop() gets notified when exiting
 	if r.metrics != nil {
 		defer r.metrics.Close()
 	}
+	defer r.skippedKeys.Close()
 
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	log.Println("🚀 Starting Dragonfly replicator")
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
-	r.recordPipelineStatus("handshake", "Connecting to Dragonfly")
 	r.recordStage("replicator", "starting", "Starting replicator")
 
-	// Connect to Dragonfly
-	if err := r.connect(); err != nil {
-		r.recordPipelineStatus("error", fmt.Sprintf("Connection failed: %v", err))
-		return fmt.Errorf("connection failed: %w", err)
+	// With the shake engine the snapshot comes from a file and Dragonfly is never contacted
+	fromFile := r.cfg.Migrate.FullLoadEngine == FullLoadShake
+	if fromFile && !r.cfg.Migrate.SnapshotOnly {
+		err := fmt.Errorf("migrate.fullLoadEngine=%s loads a file with no replication offset to continue from; use the migrate command or migrate.snapshotOnly", FullLoadShake)
+		r.recordPipelineStatus("error", err.Error())
+		return err
 	}
 
-	// Perform handshake
-	if err := r.handshake(); err != nil {
-		r.recordPipelineStatus("error", fmt.Sprintf("Handshake failed: %v", err))
-		return fmt.Errorf("handshake failed: %w", err)
-	}
-	r.recordPipelineStatus("full_sync", "Receiving RDB snapshot")
-	r.estimateSourceKeys()
+	if fromFile {
+		r.recordPipelineStatus("full_sync", "Loading RDB snapshot file")
+	} else {
+		r.recordPipelineStatus("handshake", "Connecting to Dragonfly")
+		r.loadResumeCheckpoint()
 
-	// Clear old FLOW stages from previous runs
-	r.clearOldFlowStages()
+		// Connect to Dragonfly
+		if err := r.connect(); err != nil {
+			r.recordPipelineStatus("error", fmt.Sprintf("Connection failed: %v", err))
+			return fmt.Errorf("connection failed: %w", err)
+		}
+		if err := r.checkRewriteSource(); err != nil {
+			r.recordPipelineStatus("error", err.Error())
+			return err
+		}
 
-	// Initialize Redis client (auto-detects cluster/standalone)
-	log.Println("")
-	log.Println("🔗 Connecting to target Redis...")
+		// Perform handshake
+		if err := r.handshake(); err != nil {
+			r.recordPipelineStatus("error", fmt.Sprintf("Handshake failed: %v", err))
+			return fmt.Errorf("handshake failed: %w", err)
+		}
+		r.recordEvent(state.SeverityInfo, "handshake",
+			fmt.Sprintf("Handshake with %s done, %d FLOWs", r.cfg.Source.Addr, len(r.flows)))
+		r.recordPipelineStatus("full_sync", "Receiving RDB snapshot")
+		r.estimateSourceKeys()
 
-	seeds := r.cfg.Target.Cluster.Seeds
-	if len(seeds) == 0 {
-		seeds = []string{r.cfg.Target.Addr}
+		// Clear old FLOW stages from previous runs
+		r.clearOldFlowStages()
 	}
 
-	var err error
-	if strings.Contains(strings.ToLower(r.cfg.Target.Type), "cluster") {
-		// Cluster mode: auto-detect topology
-		r.clusterClient, err = redisx.DialCluster(r.ctx, seeds, r.cfg.Target.Password)
-	} else {
-		// Standalone mode: force single node topology
-		r.clusterClient, err = redisx.DialStandalone(r.ctx, seeds[0], r.cfg.Target.Password)
-	}
+	closeTrace, err := r.connectTarget()
 	if err != nil {
-		r.recordPipelineStatus("error", fmt.Sprintf("Failed to connect to target Redis: %v", err))
-		return fmt.Errorf("failed to connect to target Redis: %w", err)
+		return err
 	}
-	r.estimateTargetKeys()
+	defer closeTrace()
 
-	// Detect topology
-	masterCount := r.clusterClient.MasterCount()
-	if masterCount > 1 {
-		log.Printf("  ✓ Connected to Redis Cluster (%d masters)", masterCount)
-	} else {
-		log.Println("  ✓ Connected to Redis (Single/Standalone)")
+	if err := r.connectMirrors(); err != nil {
+		r.recordPipelineStatus("error", err.Error())
+		return err
+	}
+	defer r.closeMirrors()
+
+	if fromFile {
+		r.state = StateFullSync
+		r.beginFullSyncConflicts()
+		err := r.loadSnapshotFile()
+		r.endFullSyncConflicts()
+		if err != nil {
+			if budgetErr := r.writeBudget.Err(); budgetErr != nil {
+				err = budgetErr
+			} else if conflictErr := r.conflictErr(); conflictErr != nil {
+				err = conflictErr
+			}
+			r.recordPipelineStatus("error", fmt.Sprintf("Snapshot file load failed: %v", err))
+			return fmt.Errorf("snapshot file load failed: %w", err)
+		}
+		r.waitTargetReplicas()
+		log.Println("🏁 RDB file loaded; fullLoadEngine=shake has no journal to follow.")
+		return nil
 	}
 
 	// Send DFLY SYNC to trigger the RDB transfer
@@ -193,27 +345,58 @@ func (r *Replicator) Start() error {
 		return fmt.Errorf("sending DFLY SYNC failed: %w", err)
 	}
 
-	// Receive snapshot in parallel
-	r.state = StateFullSync
-	if err := r.receiveSnapshot(); err != nil {
-		r.recordPipelineStatus("error", fmt.Sprintf("Snapshot reception failed: %v", err))
-		return fmt.Errorf("snapshot reception failed: %w", err)
+	if r.partialSync {
+		// Partial sync: no snapshot is sent, the journal resumes right after STARTSTABLE
+		if err := r.sendStartStable(); err != nil {
+			r.recordPipelineStatus("error", fmt.Sprintf("Switching to stable sync failed: %v", err))
+			return fmt.Errorf("switching to stable sync failed: %w", err)
+		}
+		r.recordEvent(state.SeverityInfo, "partial-sync", "Resumed from checkpoint LSNs, no full sync needed")
+	} else {
+		// Receive snapshot in parallel
+		r.state = StateFullSync
+		r.beginFullSyncConflicts()
+		err := r.receiveSnapshot()
+		r.endFullSyncConflicts()
+		if err != nil {
+			if budgetErr := r.writeBudget.Err(); budgetErr != nil {
+				err = budgetErr // report the threshold rather than the cancellation it caused
+			} else if conflictErr := r.conflictErr(); conflictErr != nil {
+				err = conflictErr
+			}
+			r.recordPipelineStatus("error", fmt.Sprintf("Snapshot reception failed: %v", err))
+			return fmt.Errorf("snapshot reception failed: %w", err)
+		}
+		r.waitTargetReplicas()
 	}
 
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	log.Println("🎯 Replicator started successfully!")
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
-	// If SnapshotOnly mode is enabled (for migrate command), exit here.
+	// If SnapshotOnly mode is enabled (migrate command or migrate.snapshotOnly), exit here.
 	if r.cfg.Migrate.SnapshotOnly {
 		log.Println("🏁 SnapshotOnly mode enabled: Stopping before incremental / stable sync.")
-		r.recordPipelineStatus("completed", "Migration (Snapshot Only) finished successfully")
+		// Record the LSNs reached so a later replicate run can resume from this copy
+		if r.cfg.Checkpoint.Enabled {
+			if err := r.saveCheckpoint(); err != nil {
+				log.Printf("  ⚠ Final checkpoint save failed: %v", err)
+				r.recordEvent(state.SeverityWarn, "checkpoint", fmt.Sprintf("Final checkpoint save failed: %v", err))
+			} else {
+				log.Printf("  ✓ Final checkpoint saved")
+				r.recordEvent(state.SeverityInfo, "checkpoint", "Final checkpoint saved")
+			}
+		}
+		// Run hangs up and records completion; Stop would overwrite the status
 		return nil
 	}
 
 	// Receive and parse the journal stream
 	// Note: Pipeline status will be updated to "incremental" when journal stream starts
 	if err := r.receiveJournal(); err != nil {
+		if budgetErr := r.writeBudget.Err(); budgetErr != nil {
+			err = budgetErr
+		}
 		r.recordPipelineStatus("error", fmt.Sprintf("Journal stream reception failed: %v", err))
 		return fmt.Errorf("journal stream reception failed: %w", err)
 	}
@@ -320,6 +503,81 @@ func (r *Replicator) UpdateConfig(qps int, batchSize int) error {
 	return nil
 }
 
+// connectTarget dials the target (auto-detecting cluster/standalone) and
+// probes what the write path needs from it. The returned func closes the
+// write trace, if any.
+func (r *Replicator) connectTarget() (func(), error) {
+	// Initialize Redis client (auto-detects cluster/standalone)
+	log.Println("")
+	log.Println("🔗 Connecting to target Redis...")
+
+	var err error
+	r.clusterClient, err = DialTarget(r.ctx, r.cfg.Target, "df2redis:target")
+	if err != nil {
+		r.recordPipelineStatus("error", fmt.Sprintf("Failed to connect to target Redis: %v", err))
+		return nil, fmt.Errorf("failed to connect to target Redis: %w", err)
+	}
+	if err := r.checkSameEndpoint("target", r.clusterClient); err != nil {
+		r.recordPipelineStatus("error", err.Error())
+		return nil, err
+	}
+	closeTrace := func() {}
+	if path := r.cfg.Observability.TraceWrites; path != "" {
+		tracer, err := redisx.NewCommandTracer(path)
+		if err != nil {
+			r.recordPipelineStatus("error", fmt.Sprintf("Failed to open write trace: %v", err))
+			return nil, err
+		}
+		closeTrace = func() { tracer.Close() }
+		r.clusterClient.SetTracer(tracer)
+		log.Printf("  📝 Tracing target writes to %s (values redacted)", path)
+	}
+	r.estimateTargetKeys()
+
+	if r.cfg.Migrate.WriteMode == "auto" {
+		version, err := detectTargetRDBVersion(r.clusterClient)
+		if err != nil {
+			log.Printf("  ⚠ migrate.writeMode=auto: cannot determine target RDB version (%v), writing with commands", err)
+		} else {
+			r.dumpTargetVersion = version
+			log.Printf("  ✓ writeMode=auto: RESTORE for Redis-native encodings up to RDB v%d, commands for the rest", version)
+		}
+	} else if threshold := r.cfg.Migrate.RestoreThresholdElements; threshold > 0 {
+		version, err := detectTargetRDBVersion(r.clusterClient)
+		if err != nil {
+			log.Printf("  ⚠ migrate.restoreThresholdElements: cannot determine target RDB version (%v), writing with commands", err)
+		} else {
+			r.dumpTargetVersion = version
+			r.dumpMinElements = threshold
+			log.Printf("  ✓ RESTORE for collections with more than %d elements (target RDB v%d)", threshold, version)
+		}
+	}
+
+	r.protoMaxBulkLen, err = detectProtoMaxBulkLen(r.clusterClient)
+	if err != nil {
+		r.protoMaxBulkLen = defaultProtoMaxBulkLen
+		logger.Debug("  Cannot read target proto-max-bulk-len (%v), assuming %d", err, r.protoMaxBulkLen)
+	}
+
+	if r.cfg.Migrate.RestoreBloomFilters {
+		r.restoreBloom = r.detectBloomSupport()
+		if r.restoreBloom {
+			log.Println("  ✓ Target supports RedisBloom, bloom filters will be recreated")
+		} else {
+			log.Println("  ⚠ migrate.restoreBloomFilters is set but the target has no RedisBloom module; bloom filter keys will be skipped")
+		}
+	}
+
+	// Detect topology
+	masterCount := r.clusterClient.MasterCount()
+	if masterCount > 1 {
+		log.Printf("  ✓ Connected to Redis Cluster (%d masters)", masterCount)
+	} else {
+		log.Println("  ✓ Connected to Redis (Single/Standalone)")
+	}
+	return closeTrace, nil
+}
+
 // connect creates the primary connection to Dragonfly for the handshake
 func (r *Replicator) connect() error {
 	r.state = StateConnecting
@@ -329,9 +587,12 @@ func (r *Replicator) connect() error {
 	defer cancel()
 
 	client, err := redisx.Dial(dialCtx, redisx.Config{
-		Addr:     r.cfg.Source.Addr,
-		Password: r.cfg.Source.Password,
-		TLS:      r.cfg.Source.TLS,
+		Addr:        r.cfg.Source.Addr,
+		Password:    r.cfg.Source.Password,
+		TLS:         r.cfg.Source.TLS,
+		TLSCAFile:   r.cfg.Source.TLSCAFile,
+		TLSInsecure: r.cfg.Source.TLSInsecure,
+		Name:        "df2redis:source:main",
 	})
 
 	if err != nil {
@@ -344,59 +605,141 @@ func (r *Replicator) connect() error {
 	return nil
 }
 
-// handshake performs the full handshake procedure
-func (r *Replicator) handshake() error {
+// handshake performs the full handshake procedure.
+// The whole sequence shares one deadline (source.handshakeTimeoutSeconds) so a
+// half-responsive master cannot wedge the process between steps.
+func (r *Replicator) handshake() (err error) {
 	r.state = StateHandshaking
 	log.Println("")
 	log.Println("🤝 Starting handshake")
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 
+	timeout := time.Duration(r.cfg.Source.HandshakeTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, timeout)
+	defer cancel()
+
+	ctx, span := tracing.Start(ctx, "handshake", "source.addr", r.cfg.Source.Addr)
+	defer func() {
+		span.SetAttributes("dfly.version", r.masterInfo.Version.String(), "dfly.flows", r.masterInfo.NumFlows)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	// Unblock a pending reply on the main connection, or on a FLOW connection
+	// registerFlow opens, as soon as the deadline hits
+	guard := &handshakeGuard{}
+	guard.add(r.mainConn)
+	r.handshakeConns = guard
+	defer func() { r.handshakeConns = nil }()
+	stop := context.AfterFunc(ctx, guard.interrupt)
+	defer stop()
+
+	step := func(n int, name string, fn func() error) (err error) {
+		_, stepSpan := tracing.Start(ctx, "handshake."+name, "step", n)
+		defer func() {
+			stepSpan.RecordError(err)
+			stepSpan.End()
+		}()
+
+		if ctx.Err() == nil {
+			if err := fn(); err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			if err == context.DeadlineExceeded {
+				return fmt.Errorf("handshake timed out at step %d/6 (%s) after %v", n, name, timeout)
+			}
+			return fmt.Errorf("handshake cancelled at step %d/6 (%s): %w", n, name, err)
+		}
+		return nil
+	}
+
 	// Step 1: PING
-	log.Println("  [1/6] Sending PING...")
-	if err := r.sendPing(); err != nil {
+	if err := step(1, "PING", func() error {
+		log.Println("  [1/6] Sending PING...")
+		if err := r.sendPing(); err != nil {
+			return err
+		}
+		log.Println("  ✓ PONG received")
+		return nil
+	}); err != nil {
 		return err
 	}
-	log.Println("  ✓ PONG received")
 
 	// Step 2: REPLCONF listening-port
-	log.Printf("  [2/6] Declaring listening port: %d...", r.listeningPort)
-	if err := r.sendListeningPort(); err != nil {
+	if err := step(2, "REPLCONF listening-port", func() error {
+		log.Printf("  [2/6] Declaring listening port: %d...", r.listeningPort)
+		if err := r.sendListeningPort(); err != nil {
+			return err
+		}
+		log.Println("  ✓ Listening port registered")
+		return nil
+	}); err != nil {
 		return err
 	}
-	log.Println("  ✓ Listening port registered")
 
 	// Step 3: REPLCONF ip-address (optional)
-	if r.announceIP != "" {
+	if err := step(3, "REPLCONF ip-address", func() error {
+		if r.announceIP == "" {
+			log.Println("  [3/6] Skipping IP address declaration")
+			return nil
+		}
 		log.Printf("  [3/6] Declaring IP address: %s...", r.announceIP)
 		if err := r.sendIPAddress(); err != nil {
 			log.Printf("  ⚠ Failed to register IP address (primary may be older): %v", err)
 		} else {
 			log.Println("  ✓ IP address registered")
 		}
-	} else {
-		log.Println("  [3/6] Skipping IP address declaration")
+		return nil
+	}); err != nil {
+		return err
 	}
 
 	// Step 4: REPLCONF capa eof psync2
-	log.Println("  [4/6] Declaring capabilities: eof psync2...")
-	if err := r.sendCapaEOF(); err != nil {
+	if err := step(4, "REPLCONF capa eof psync2", func() error {
+		log.Println("  [4/6] Declaring capabilities: eof psync2...")
+		if err := r.sendCapaEOF(); err != nil {
+			return err
+		}
+		log.Println("  ✓ Capabilities declared")
+		return nil
+	}); err != nil {
 		return err
 	}
-	log.Println("  ✓ Capabilities declared")
 
 	// Step 5: REPLCONF capa dragonfly
-	log.Println("  [5/6] Declaring Dragonfly compatibility...")
-	if err := r.sendCapaDragonfly(); err != nil {
+	if err := step(5, "REPLCONF capa dragonfly", func() error {
+		log.Println("  [5/6] Declaring Dragonfly compatibility...")
+		if err := r.sendCapaDragonfly(); err != nil {
+			return err
+		}
+		log.Printf("  ✓ Dragonfly version: %s, shards: %d", r.masterInfo.Version, r.masterInfo.NumFlows)
+		return nil
+	}); err != nil {
 		return err
 	}
-	log.Printf("  ✓ Dragonfly version: %s, shards: %d", r.masterInfo.Version, r.masterInfo.NumFlows)
 
 	// Step 6: establish FLOW connections
-	log.Printf("  [6/6] Establishing %d FLOW connections...", r.masterInfo.NumFlows)
-	if err := r.establishFlows(); err != nil {
+	if err := step(6, "DFLY FLOW", func() error {
+		log.Printf("  [6/6] Establishing %d FLOW connections...", r.masterInfo.NumFlows)
+		if err := r.establishFlows(ctx); err != nil {
+			return err
+		}
+		log.Printf("  ✓ All FLOW connections established")
+		return nil
+	}); err != nil {
 		return err
 	}
-	log.Printf("  ✓ All FLOW connections established")
+
+	// The deadline only governs the handshake; restore normal I/O on its connections
+	stop()
+	if err := guard.release(); err != nil {
+		return fmt.Errorf("failed to reset connection deadlines after the handshake: %w", err)
+	}
 
 	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	log.Println("✓ Handshake complete")
@@ -406,6 +749,52 @@ func (r *Replicator) handshake() error {
 	return nil
 }
 
+// handshakeGuard tracks the connections opened during the handshake so its
+// deadline can interrupt whichever one is blocked on a reply.
+type handshakeGuard struct {
+	mu       sync.Mutex
+	conns    []*redisx.Client
+	fired    bool // deadline hit: connections added later are interrupted at once
+	released bool // handshake done: the deadline no longer applies
+}
+
+func (g *handshakeGuard) add(c *redisx.Client) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.conns = append(g.conns, c)
+	if g.fired {
+		c.Interrupt()
+	}
+}
+
+func (g *handshakeGuard) interrupt() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.released {
+		return
+	}
+	g.fired = true
+	for _, c := range g.conns {
+		c.Interrupt()
+	}
+}
+
+// release clears the read and write deadlines of every open connection.
+func (g *handshakeGuard) release() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.released = true
+	for _, c := range g.conns {
+		if c.IsClosed() {
+			continue
+		}
+		if err := c.SetDeadline(time.Time{}); err != nil {
+			return fmt.Errorf("%s: %w", c.Addr(), err)
+		}
+	}
+	return nil
+}
+
 // sendPing issues a PING command over the main connection
 func (r *Replicator) sendPing() error {
 	resp, err := r.mainConn.Do("PING")
@@ -496,6 +885,7 @@ func (r *Replicator) sendCapaDragonfly() error {
 		return fmt.Errorf("failed to parse flow count: %s", arr[2])
 	}
 	r.masterInfo.NumFlows = numFlows
+	r.applyFlowOverride()
 
 	// Element 3: Dragonfly protocol version
 	version, err := strconv.Atoi(arr[3])
@@ -512,88 +902,218 @@ func (r *Replicator) sendCapaDragonfly() error {
 	return nil
 }
 
+// applyFlowOverride caps the FLOW count for debugging (replicate --flows).
+// Dragonfly has one FLOW per shard, so asking for more than it reported is
+// ignored; fewer leaves the remaining shards unreplicated.
+func (r *Replicator) applyFlowOverride() {
+	n := r.cfg.Source.FlowOverride
+	if n <= 0 || n == r.masterInfo.NumFlows {
+		return
+	}
+	if n > r.masterInfo.NumFlows {
+		log.Printf("  ⚠ --flows %d ignored: master reported only %d shards", n, r.masterInfo.NumFlows)
+		return
+	}
+	log.Printf("  ⚠ --flows %d overrides the master's %d FLOWs: shards %d-%d will NOT be replicated (debugging only)",
+		n, r.masterInfo.NumFlows, n, r.masterInfo.NumFlows-1)
+	r.masterInfo.NumFlows = n
+}
+
 // establishFlows creates dedicated FLOW connections for each shard
-func (r *Replicator) establishFlows() error {
+func (r *Replicator) establishFlows(ctx context.Context) error {
 	numFlows := r.masterInfo.NumFlows
 	log.Printf("    • Establishing %d parallel FLOW connections...", numFlows)
 
 	r.flows = make([]FlowInfo, numFlows)
 	r.flowConns = make([]*redisx.Client, numFlows)
 	r.flowBufReaders = make([]*bufio.Reader, numFlows)
+	r.flowTrackers = make([]*streamTracker, numFlows)
 	r.initFlowTracking(numFlows)
 
+	// A checkpoint from another master process or FLOW layout can't resume
+	if cp := r.resumeFrom; cp != nil && (cp.ReplicationID != r.masterInfo.ReplID || cp.NumFlows != numFlows) {
+		log.Printf("    ⚠ Checkpoint is from replication %s with %d FLOWs, master is %s with %d; full sync required",
+			cp.ReplicationID, cp.NumFlows, r.masterInfo.ReplID, numFlows)
+		for flowID := range cp.FlowLSNs {
+			r.forgetResumeLSN(flowID)
+		}
+	}
+
 	// Create independent TCP connections for each FLOW
 	for i := 0; i < numFlows; i++ {
 		log.Printf("    • Establishing FLOW-%d dedicated connection...", i)
 		r.recordFlowStage(i, "connecting", "Establishing FLOW connection")
 
-		// 1. Create a new TCP connection
-		dialCtx, cancel := context.WithTimeout(r.ctx, 10*time.Second)
-		flowConn, err := redisx.Dial(dialCtx, redisx.Config{
-			Addr:     r.cfg.Source.Addr,
-			Password: r.cfg.Source.Password,
-			TLS:      r.cfg.Source.TLS,
-		})
-		cancel()
-
-		if err != nil {
-			return fmt.Errorf("FLOW-%d connection failed: %w", i, err)
-		}
-
-		r.flowConns[i] = flowConn
-		// Use 1MB buffer to ensure RDBParser and JournalReader share the same buffer context
-		r.flowBufReaders[i] = bufio.NewReaderSize(flowConn, 1024*1024)
-
-		// 2. Send PING (optional, ensures the connection is alive)
-		if err := flowConn.Ping(); err != nil {
-			return fmt.Errorf("FLOW-%d PING failed: %w", i, err)
-		}
-
-		// 3. Send DFLY FLOW to register this FLOW
-		// Command: DFLY FLOW <master_id> <sync_id> <flow_id>
-		resp, err := flowConn.Do("DFLY", "FLOW", r.masterInfo.ReplID, r.masterInfo.SyncID, strconv.Itoa(i))
-		if err != nil {
-			return fmt.Errorf("FLOW-%d registration failed: %w", i, err)
-		}
-
-		// 4. Parse response: ["FULL", <eof_token>] or ["PARTIAL", <eof_token>]
-		arr, err := redisx.ToStringSlice(resp)
-		if err != nil {
-			// Could be a simple OK string
-			if err := r.expectOK(resp); err != nil {
-				return fmt.Errorf("FLOW-%d returned error: %w", i, err)
+		// Without a resume LSN the master must answer FULL for every FLOW.
+		// An unexpected PARTIAL reply would make the subsequent RDB parse desync, so
+		// re-register the FLOW on a fresh connection once and abort if it still isn't FULL.
+		_, hasStartLSN := r.resumeLSNs[i]
+		var flow FlowInfo
+		for attempt := 1; ; attempt++ {
+			var err error
+			flow, err = r.registerFlow(ctx, i)
+			if err != nil {
+				return err
 			}
-			r.flows[i] = FlowInfo{
-				FlowID:   i,
-				State:    "established",
-				SyncType: "OK",
-				EOFToken: "",
+			if flow.SyncType == flowSyncFull || flow.SyncType == "OK" {
+				break
 			}
-		} else {
-			if len(arr) < 2 {
-				return fmt.Errorf("FLOW-%d response malformed, expected 2 elements: %v", i, arr)
+			if flow.SyncType == flowSyncPartial && hasStartLSN {
+				break
 			}
-			syncType := arr[0]
-			eofToken := arr[1]
-
-			r.flows[i] = FlowInfo{
-				FlowID:   i,
-				State:    "established",
-				SyncType: syncType,
-				EOFToken: eofToken,
+			r.flowConns[i].Close()
+			if attempt >= maxFlowRegisterAttempts {
+				return fmt.Errorf("FLOW-%d: master reported sync type %s but full sync expected %s (gave up after %d attempts)",
+					i, flow.SyncType, flowSyncFull, attempt)
 			}
-
-			log.Printf("      → Sync type: %s, EOF Token: %s...", syncType, eofToken[:min(8, len(eofToken))])
+			log.Printf("    ⚠ FLOW-%d: master reported %s, expected %s; re-issuing DFLY FLOW (attempt %d/%d)",
+				i, flow.SyncType, flowSyncFull, attempt+1, maxFlowRegisterAttempts)
+		}
+		r.flows[i] = flow
+		if flow.SyncType != flowSyncPartial {
+			r.forgetResumeLSN(i)
 		}
 
 		log.Printf("    ✓ FLOW-%d connection and registration complete", i)
 		r.recordFlowStage(i, "established", fmt.Sprintf("%s FLOW established", r.flows[i].SyncType))
 	}
 
+	// Dragonfly streams either a snapshot or the journal on every FLOW, never a mix
+	partial := 0
+	for _, flow := range r.flows {
+		if flow.SyncType == flowSyncPartial {
+			partial++
+		}
+	}
+	switch {
+	case partial == numFlows:
+		r.partialSync = true
+		log.Printf("    ✓ Master accepted partial sync on all %d FLOWs, skipping the RDB snapshot", numFlows)
+	case partial > 0:
+		return fmt.Errorf("master accepted partial sync on %d/%d FLOWs only; every FLOW needs a resumable LSN (check --lsn)", partial, numFlows)
+	}
+
 	log.Printf("    ✓ All %d FLOW connections established", numFlows)
 	return nil
 }
 
+const (
+	flowSyncFull            = "FULL"
+	flowSyncPartial         = "PARTIAL"
+	maxFlowRegisterAttempts = 2
+)
+
+// registerFlow dials a dedicated connection for FLOW i and registers it via DFLY FLOW.
+func (r *Replicator) registerFlow(ctx context.Context, i int) (FlowInfo, error) {
+	// 1. Create a new TCP connection
+	dialCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	flowCfg := redisx.Config{
+		Addr:        r.cfg.Source.Addr,
+		Password:    r.cfg.Source.Password,
+		TLS:         r.cfg.Source.TLS,
+		TLSCAFile:   r.cfg.Source.TLSCAFile,
+		TLSInsecure: r.cfg.Source.TLSInsecure,
+		SkipPing:    r.cfg.Source.SkipFlowPing,
+	}
+	if !r.cfg.Source.SkipFlowPing {
+		// SETNAME would be the first command, which those builds reject too
+		flowCfg.Name = fmt.Sprintf("df2redis:source:flow-%d", i)
+	}
+	flowConn, err := redisx.Dial(dialCtx, flowCfg)
+	cancel()
+
+	if err != nil {
+		return FlowInfo{}, fmt.Errorf("FLOW-%d connection failed: %w", i, err)
+	}
+
+	r.flowConns[i] = flowConn
+	if r.handshakeConns != nil {
+		r.handshakeConns.add(flowConn)
+	}
+	// Use 1MB buffer to ensure RDBParser and JournalReader share the same buffer context
+	r.flowTrackers[i] = newStreamTracker(flowConn)
+	r.flowBufReaders[i] = bufio.NewReaderSize(r.flowTrackers[i], flowBufSize)
+
+	// 2. Send PING (optional, ensures the connection is alive; off with source.skipFlowPing)
+	// A command started after the handshake deadline would reset the
+	// connection's deadline, so check it before each one
+	if !r.cfg.Source.SkipFlowPing {
+		if err := ctx.Err(); err != nil {
+			return FlowInfo{}, err
+		}
+		if err := flowConn.Ping(); err != nil {
+			return FlowInfo{}, fmt.Errorf("FLOW-%d PING failed: %w", i, err)
+		}
+	}
+
+	// 3. Send DFLY FLOW to register this FLOW
+	// Command: DFLY FLOW <master_id> <sync_id> <flow_id> [<lsn>]
+	flowArgs := []interface{}{"FLOW", r.masterInfo.ReplID, r.masterInfo.SyncID, strconv.Itoa(i)}
+	if lsn, ok := r.resumeLSNs[i]; ok {
+		log.Printf("      → Requesting partial sync from LSN %d", lsn)
+		flowArgs = append(flowArgs, strconv.FormatUint(lsn, 10))
+	}
+	if err := ctx.Err(); err != nil {
+		return FlowInfo{}, err
+	}
+	resp, err := flowConn.Do("DFLY", flowArgs...)
+	if err != nil {
+		return FlowInfo{}, fmt.Errorf("FLOW-%d registration failed: %w", i, err)
+	}
+
+	// 4. Parse response: ["FULL", <eof_token>] or ["PARTIAL", <eof_token>]
+	arr, err := redisx.ToStringSlice(resp)
+	if err != nil {
+		// Could be a simple OK string
+		if err := r.expectOK(resp); err != nil {
+			return FlowInfo{}, fmt.Errorf("FLOW-%d returned error: %w", i, err)
+		}
+		return FlowInfo{
+			FlowID:   i,
+			State:    "established",
+			SyncType: "OK",
+			EOFToken: "",
+		}, nil
+	}
+	if len(arr) < 2 {
+		return FlowInfo{}, fmt.Errorf("FLOW-%d response malformed, expected 2 elements: %v", i, arr)
+	}
+	syncType := arr[0]
+	eofToken := arr[1]
+
+	log.Printf("      → Sync type: %s, EOF Token: %s...", syncType, eofToken[:min(8, len(eofToken))])
+	return FlowInfo{
+		FlowID:   i,
+		State:    "established",
+		SyncType: syncType,
+		EOFToken: eofToken,
+	}, nil
+}
+
+// eofTokenMismatch logs both tokens and the bytes preceding the received one
+// as hex, plus the FLOW's stream offset. A mismatch almost always means the
+// parser lost byte alignment earlier, so the offset matters more than the tokens.
+func (r *Replicator) eofTokenMismatch(flowID int, expected, received string) error {
+	consumed := r.flowStreamOffset(flowID)
+	tokenOffset := consumed - int64(len(received))
+	preceding := r.flowTrackers[flowID].window(tokenOffset, 16)
+
+	log.Printf("  [FLOW-%d] ✗ EOF token mismatch at stream offset %d (%d bytes consumed on this FLOW)\n  expected (%d bytes):\n%s  received (%d bytes):\n%s  preceding %d bytes:\n%s",
+		flowID, tokenOffset, consumed,
+		len(expected), hexBlock([]byte(expected)),
+		len(received), hexBlock([]byte(received)),
+		len(preceding), hexBlock(preceding))
+
+	return fmt.Errorf("FLOW-%d: EOF token mismatch at stream offset %d after %d bytes consumed (stream likely desynced earlier; see hex dump in log)",
+		flowID, tokenOffset, consumed)
+}
+
+// flowStreamOffset returns how many bytes of the FLOW stream have been consumed
+// through its buffered reader.
+func (r *Replicator) flowStreamOffset(flowID int) int64 {
+	return r.flowTrackers[flowID].total - int64(r.flowBufReaders[flowID].Buffered())
+}
+
 // min returns the smaller of two integers
 func min(a, b int) int {
 	if a < b {
@@ -660,6 +1180,13 @@ func (r *Replicator) receiveSnapshot() error {
 
 	log.Printf("  • Using %d FLOW connections to receive and parse the RDB snapshot", numFlows)
 
+	r.metricsMu.Lock()
+	r.snapshotStartTime = time.Now()
+	r.metricsMu.Unlock()
+
+	snapCtx, snapSpan := tracing.Start(r.ctx, "snapshot", "flows", numFlows)
+	defer snapSpan.End()
+
 	// Wait for all goroutines
 	var wg sync.WaitGroup
 	errChan := make(chan error, numFlows)
@@ -671,28 +1198,25 @@ func (r *Replicator) receiveSnapshot() error {
 		ErrorCount       int
 		InlineJournalOps int
 		mu               sync.Mutex
+
+		// Timing, only touched by the FLOW goroutine
+		ParseTime   time.Duration // inside ParseNext, including inline journal replay
+		JournalTime time.Duration // replaying inline journal entries
+		EnqueueTime time.Duration // blocked handing entries to the writer
+		Parse       ParseTimings
 	}
 	statsMap := make(map[int]*FlowStats)
 	var statsMu sync.Mutex
 
-	// Create async writers for each flow with adaptive concurrency
-	r.flowWriters = make([]*FlowWriter, numFlows)
-	for i := 0; i < numFlows; i++ {
-		var pipelineClient *redisx.Client
-		if r.cfg.Target.Type == "redis-standalone" || r.cfg.Target.Type == "redis" {
-			// Extract the single connection for pipeline usage
-			// We use GetNodeClient with the configured address
-			// Ignore error as connection was already established in connectTarget
-			pipelineClient, _ = r.clusterClient.GetNodeClient(r.cfg.Target.Addr)
-		}
-
-		// Pass initial config with ops reporter callback for global QPS tracking
-		r.flowWriters[i] = NewFlowWriter(i, r.writeRDBEntry, numFlows, r.cfg.Target.Type, pipelineClient, r.clusterClient, r.ReportOps)
+	// Shared writer pool: all FLOWs draw from one write budget so an unbalanced
+	// shard distribution doesn't leave most of the capacity idle (nil = no cap)
+	writerPool := newSharedWriterPool(r.cfg)
+	defer writerPool.Close()
 
-		// Apply initial advanced config
-		r.flowWriters[i].UpdateConfig(r.cfg.Advanced.QPS, r.cfg.Advanced.BatchSize)
-
-		r.flowWriters[i].Start()
+	// Create async writers for each flow with adaptive concurrency
+	r.startFlowWriters(numFlows, writerPool, snapCtx)
+	for _, m := range r.mirrors {
+		m.r.startFlowWriters(numFlows, writerPool, snapCtx)
 	}
 
 	// CRITICAL FIX: Global synchronization barrier matching Dragonfly's BlockingCounter design
@@ -726,29 +1250,54 @@ func (r *Replicator) receiveSnapshot() error {
 	}()
 	defer close(perfDone)
 
+	// Optionally flag keys that more than one FLOW imports
+	var dups *dupTracker
+	if max := r.cfg.Migrate.DuplicateKeyTracking; max > 0 && numFlows > 1 {
+		dups = newDupTracker(max)
+		log.Printf("  • Tracking up to %d keys for cross-FLOW duplicates", max)
+	}
+
 	// Start a goroutine per FLOW to read and parse RDB data
 	for i := 0; i < numFlows; i++ {
 		statsMap[i] = &FlowStats{}
 		wg.Add(1)
 		go func(flowID int) {
 			defer wg.Done()
+			defer r.recoverFlow(flowID, flowPhaseSnapshot, func(err error) { errChan <- err })
 
 			// Use the persistent buffered reader to preserve data across RDB -> Journal transition
 			parser := NewRDBParser(r.flowBufReaders[flowID], flowID)
+			parser.dumpTargetVersion = r.dumpTargetVersion
+			parser.dumpMinElements = r.dumpMinElements
+			if len(r.cfg.Conflict.NotifyPatterns) > 0 {
+				parser.forceCommands = r.matchesNotifyPattern
+			}
 
 			stats := statsMap[flowID]
 			flowWriter := r.flowWriters[flowID]
 			r.recordFlowStage(flowID, "rdb", "Receiving RDB snapshot")
 
+			_, flowSpan := tracing.Start(snapCtx, "snapshot.flow", "flow.id", flowID)
+			defer func() {
+				stats.mu.Lock()
+				flowSpan.SetAttributes("keys", stats.KeyCount, "skipped", stats.SkippedCount,
+					"errors", stats.ErrorCount, "inline_journal", stats.InlineJournalOps)
+				stats.mu.Unlock()
+				flowSpan.End()
+			}()
+
 			// Track whether this FLOW has completed RDB phase and synchronized via barrier
 			rdbCompleted := false
 
 			// Set callback for inline journal entries during RDB phase
 			parser.onJournalEntry = func(entry *JournalEntry) error {
+				replayStart := time.Now()
+				defer func() { stats.JournalTime += time.Since(replayStart) }()
 				// Apply journal entry using existing replication logic
 				if err := r.replayCommand(flowID, entry); err != nil {
 					return fmt.Errorf("failed to apply inline journal entry: %w", err)
 				}
+				r.mirrorJournalEntry(flowID, entry)
 				DebugTotalParsedJournal.Add(1) // DEBUG COUNTER
 				// Update local flow stats
 				stats.mu.Lock()
@@ -802,7 +1351,10 @@ func (r *Replicator) receiveSnapshot() error {
 				}
 
 				// Parse next entry
+				parseStart := time.Now()
 				entry, err := parser.ParseNext()
+				stats.ParseTime += time.Since(parseStart)
+				stats.Parse = parser.Timings()
 				if err != nil {
 					// EOF: Dragonfly sent EOF (either after STARTSTABLE or directly)
 					if err == io.EOF {
@@ -859,8 +1411,7 @@ func (r *Replicator) receiveSnapshot() error {
 
 								receivedToken := string(eofTokenBuf)
 								if receivedToken != r.flows[flowID].EOFToken {
-									errChan <- fmt.Errorf("FLOW-%d: EOF token mismatch\n  expected: %s\n  received: %s",
-										flowID, r.flows[flowID].EOFToken, receivedToken)
+									errChan <- r.eofTokenMismatch(flowID, r.flows[flowID].EOFToken, receivedToken)
 									return
 								}
 								log.Printf("  [FLOW-%d] ✓ EOF token verified successfully", flowID)
@@ -920,19 +1471,67 @@ func (r *Replicator) receiveSnapshot() error {
 
 				// Skip expired keys
 				if entry.IsExpired() {
+					r.skippedKeys.Record(entry, skipReasonExpired)
+					statsMu.Lock()
+					stats.SkippedCount++
+					statsMu.Unlock()
+					continue
+				}
+
+				// Bloom filters are only written when they can be recreated
+				if entry.Type == RDB_TYPE_SBF && !r.restoreBloom {
+					log.Printf("  [FLOW-%d] ⊘ Skipped bloom filter key=%s (set migrate.restoreBloomFilters and load RedisBloom on the target to recreate it)", flowID, entry.Key)
+					r.skippedKeys.Record(entry, skipReasonBloom)
+					statsMu.Lock()
+					stats.SkippedCount++
+					statsMu.Unlock()
+					continue
+				}
+
+				if !r.typeFilter.Allowed(entry.Type) {
+					r.countTypeFiltered(entry)
 					statsMu.Lock()
 					stats.SkippedCount++
 					statsMu.Unlock()
 					continue
 				}
 
+				write, err := r.checkOversize(flowID, entry)
+				if err != nil {
+					errChan <- fmt.Errorf("FLOW-%d: %w", flowID, err)
+					r.recordFlowStage(flowID, "error", err.Error())
+					return
+				}
+				if !write {
+					statsMu.Lock()
+					stats.SkippedCount++
+					statsMu.Unlock()
+					continue
+				}
+
+				if dups != nil {
+					dups.observe(entry.DbIndex, entry.Key, flowID)
+				}
+
+				// Rename before the writer routes the key to its slot
+				if r.transformer != nil {
+					r.transformer.TransformEntry(entry)
+				}
+
+				// Mirrors get their own copy: writers may drop entry.Dump on RESTORE failure
+				r.mirrorSnapshotEntry(flowID, entry)
+
 				// Write entry into Redis
-				if err := flowWriter.Enqueue(entry); err != nil {
+				enqueueStart := time.Now()
+				err = flowWriter.Enqueue(entry)
+				stats.EnqueueTime += time.Since(enqueueStart)
+				if err != nil {
 					log.Printf("  [FLOW-%d] ⚠ Write failed (key=%s): %v", flowID, entry.Key, err)
 					statsMu.Lock()
 					stats.ErrorCount++
 					statsMu.Unlock()
 					r.recordFlowStage(flowID, "error", fmt.Sprintf("Write failed key=%s", entry.Key))
+					r.recordWriteResults(0, 1)
 				} else {
 					DebugTotalEnqueued.Add(1) // DEBUG COUNTER
 					statsMu.Lock()
@@ -988,6 +1587,15 @@ func (r *Replicator) receiveSnapshot() error {
 	}
 	log.Printf("  ✓ RDB snapshot: total %d keys, skipped %d (expired), failed %d, inline_journal=%d",
 		totalKeys, totalSkipped, totalErrors, totalInlineJournal)
+	severity := state.SeverityInfo
+	if totalErrors > 0 {
+		severity = state.SeverityWarn
+	}
+	r.recordEvent(severity, "full-sync", fmt.Sprintf("Full sync complete: %d keys, %d skipped, %d failed",
+		totalKeys, totalSkipped, totalErrors))
+	if n := dups.Duplicates(); n > 0 {
+		log.Printf("  ⚠ %d keys were imported by more than one FLOW; the last write won", n)
+	}
 	log.Printf("")
 
 	// CRITICAL: Send STARTSTABLE immediately after barrier
@@ -1020,10 +1628,15 @@ func (r *Replicator) receiveSnapshot() error {
 	for i, fw := range r.flowWriters {
 		fw.Stop()
 		received, written, batches := fw.GetStats()
-		log.Printf("  [FLOW-%d] Writer stats: received=%d, written=%d, batches=%d",
-			i, received, written, batches)
+		_, _, blocked, blockedFor := fw.GetBackpressure()
+		log.Printf("  [FLOW-%d] Writer stats: received=%d, written=%d, batches=%d, bytes=~%.1fMB, blocked_enqueues=%d (%v)",
+			i, received, written, batches, float64(fw.GetBytesWritten())/(1024*1024), blocked, blockedFor.Truncate(time.Millisecond))
 	}
+	r.stopMirrorWriters()
 	log.Println("  ✓ All writers stopped, all data flushed")
+	if err := r.writeBudget.Err(); err != nil {
+		return err
+	}
 
 	// Final stats after all journal blobs processed
 	log.Println("")
@@ -1046,6 +1659,51 @@ func (r *Replicator) receiveSnapshot() error {
 	}
 	log.Printf("  ✓ Total: %d keys, skipped %d (expired), failed %d, inline_journal=%d",
 		totalKeys, totalSkipped, totalErrors, totalInlineJournal)
+	r.rdbStats.mu.Lock()
+	partialKeys := r.rdbStats.PartialKeys
+	oversizeKeys := r.rdbStats.OversizeKeys
+	typeFiltered := r.rdbStats.TypeFiltered
+	r.rdbStats.mu.Unlock()
+	if typeFiltered > 0 {
+		log.Printf("  ⊘ %d keys skipped by migrate.includeTypes %v", typeFiltered, r.cfg.Migrate.IncludeTypes)
+	}
+	if oversizeKeys > 0 {
+		log.Printf("  ⚠ %d keys had an element over the target proto-max-bulk-len (%d bytes, migrate.oversizeValueAction=%s)",
+			oversizeKeys, r.protoMaxBulkLen, oversizeAction(r.cfg.Migrate.OversizeValueAction))
+	}
+	if partialKeys > 0 {
+		action := "left as written"
+		if r.cfg.Migrate.DeletePartialKeys {
+			action = "deleted"
+		}
+		log.Printf("  ⚠ %d sets/zsets were only partially written (%s)", partialKeys, action)
+		r.recordEvent(state.SeverityWarn, "partial-keys", fmt.Sprintf("%d sets/zsets only partially written (%s)", partialKeys, action))
+	}
+	log.Printf("")
+
+	// Where the time went: a FLOW is network-, CPU- or target-bound
+	log.Println("⏱  Snapshot timing breakdown:")
+	var total snapshotTiming
+	for flowID := 0; flowID < numFlows; flowID++ {
+		stats := statsMap[flowID]
+		t := snapshotTiming{
+			Network:    stats.Parse.Read,
+			Decompress: stats.Parse.Decompress,
+			Journal:    stats.JournalTime,
+			Enqueue:    stats.EnqueueTime,
+			Write:      r.flowWriters[flowID].GetWriteTime(),
+		}
+		t.Decode = stats.ParseTime - t.Network - t.Decompress - t.Journal
+		if t.Decode < 0 {
+			t.Decode = 0
+		}
+		log.Printf("  [FLOW-%d] %s", flowID, t)
+		total.add(t)
+	}
+	if stage, share := total.dominant(); stage != "" {
+		log.Printf("  ✓ Total: %s", total)
+		log.Printf("  → Most time spent in %s (%.0f%%)", stage, share*100)
+	}
 	log.Printf("")
 
 	// EOF token verification is now done inline in each FLOW goroutine
@@ -1055,6 +1713,52 @@ func (r *Replicator) receiveSnapshot() error {
 	return nil
 }
 
+// startFlowWriters creates and starts one async writer per FLOW for r's target.
+func (r *Replicator) startFlowWriters(numFlows int, writerPool *WriterPool, snapCtx context.Context) {
+	r.flowWriters = make([]*FlowWriter, numFlows)
+	for i := 0; i < numFlows; i++ {
+		var pipelineClient *redisx.Client
+		if r.cfg.Target.Type == "redis-standalone" || r.cfg.Target.Type == "redis" {
+			// Extract the single connection for pipeline usage
+			// We use GetNodeClient with the configured address
+			// Ignore error as connection was already established in connectTarget
+			pipelineClient, _ = r.clusterClient.GetNodeClient(r.cfg.Target.Addr)
+		}
+
+		// Pass initial config with ops reporter callback for global QPS tracking
+		r.flowWriters[i] = NewFlowWriter(i, r.writeRDBEntry, numFlows, r.cfg.Target.Type, pipelineClient, r.clusterClient, r.ReportOps)
+		r.flowWriters[i].SetWriterPool(writerPool)
+		r.flowWriters[i].SetTraceContext(snapCtx)
+		r.flowWriters[i].SetResultReporter(r.recordWriteResults)
+		r.flowWriters[i].SetPartialWriteHandler(r.handlePartialWrite)
+		r.flowWriters[i].SetOversizeHandler(r.handleOversizeWrite)
+		r.flowWriters[i].SetConflictFilter(r.filterSnapshotConflicts)
+		r.flowWriters[i].SetAbsoluteTTL(r.absoluteTTL())
+
+		// Apply initial advanced config
+		r.flowWriters[i].UpdateConfig(r.cfg.Advanced.QPS, r.cfg.Advanced.BatchSize)
+		if r.cfg.Advanced.BatchBytes != 0 {
+			r.flowWriters[i].SetBatchBytes(r.cfg.Advanced.BatchBytes)
+		}
+
+		r.flowWriters[i].Start()
+	}
+}
+
+// recordWriteResults feeds write outcomes into the failure budget and aborts
+// the run (via r.cancel) the first time migrate.maxWriteFailures or
+// migrate.maxWriteFailureRate is exceeded.
+func (r *Replicator) recordWriteResults(success, failed int) {
+	if err := r.writeBudget.Record(success, failed); err != nil {
+		log.Println("")
+		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		log.Printf("✗ Aborting: %v", err)
+		log.Println("  The target is rejecting writes; check target.addr, credentials, memory and cluster state.")
+		log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+		r.cancel()
+	}
+}
+
 // sendStartStable issues DFLY STARTSTABLE on the main connection
 func (r *Replicator) sendStartStable() error {
 	log.Println("")
@@ -1136,66 +1840,54 @@ func (r *Replicator) verifyEofTokens() error {
 
 			// Legacy EOF verification (only if FULLSYNC_END was NOT seen)
 			log.Printf("  [FLOW-%d] 🔍 Verifying legacy EOF token...", flowID)
-			parser := NewRDBParser(flowConn, flowID) // Create a parser to use PeekByte/ReadByte
-			maxRetries := 100                        // Look ahead 100 bytes for EOF
-			// expectedToken is already set to r.flows[flowID].EOFToken above
+			// Read through the FLOW's buffered reader so bytes it already holds aren't skipped
+			flowReader := r.flowBufReaders[flowID]
 
-			for j := 0; j < maxRetries; j++ {
-				opcodeByte, err := parser.peekByte()
-				if err != nil {
-					if err == io.EOF {
-						break
-					}
-					errChan <- fmt.Errorf("FLOW-%d: error peeking for EOF: %w", flowID, err)
+			// 1. Optional JOURNAL_OFFSET block (0xD3 + 8 bytes) before the EOF opcode.
+			// Only protocol VER2+ (partial sync support) emits it, and even then only
+			// consume it when it is actually there so we never misalign the stream.
+			peeked, err := flowReader.Peek(1)
+			if err != nil {
+				errChan <- fmt.Errorf("FLOW-%d: error peeking for EOF: %w", flowID, err)
+				return
+			}
+			if peeked[0] == RDB_OPCODE_JOURNAL_OFFSET {
+				if r.masterInfo.Version < DflyVersion2 {
+					errChan <- fmt.Errorf("FLOW-%d: unexpected JOURNAL_OFFSET (0xD3) at stream offset %d for protocol %s",
+						flowID, r.flowStreamOffset(flowID), r.masterInfo.Version)
 					return
 				}
-
-				switch opcodeByte {
-				case 0xD2, 0xD3: // Journal blobs (unexpected here logic-wise if rdbCompleted, but safe to ignore if we were just scanning)
-					// If we see journal ops, we consumed too far or are in mixed state.
-					// But for legacy EOF search, we shouldn't see these unless we missed the transition.
-					// We'll treat them as non-EOF.
-					if _, err := parser.readByte(); err != nil { // consume
-						errChan <- err
-						return
-					}
-					continue
-
-				case 0xFF: // RDB_OPCODE_EOF
-					// Found EOF!
-					log.Printf("  [FLOW-%d] ✓ Found legacy EOF opcode (0xFF)", flowID)
-					// Consume the opcode
-					parser.readByte()
-					goto foundEOF
-
-				default:
-					// Consume and continue searching/skipping junk?
-					// Strict mode: if we don't find it immediately, it's an error?
-					// Let's consume and retry
-					if _, err := parser.readByte(); err != nil {
-						errChan <- err
-						return
-					}
+				metaBuf := make([]byte, 9)
+				if _, err := io.ReadFull(flowReader, metaBuf); err != nil {
+					errChan <- fmt.Errorf("FLOW-%d: failed to read JOURNAL_OFFSET block: %w", flowID, err)
+					return
 				}
+				log.Printf("  [FLOW-%d] → Skipped JOURNAL_OFFSET block (offset=%d)", flowID, binary.LittleEndian.Uint64(metaBuf[1:]))
 			}
 
-			// If we reach here, we exceeded max retries
-			errChan <- fmt.Errorf("FLOW-%d: exceeded max retries (%d) while searching for EOF", flowID, maxRetries)
-			return
-
-		foundEOF:
-			// 2. Now we have EOF opcode, continue with checksum and token
+			// 2. EOF opcode
+			opcodeByte, err := flowReader.ReadByte()
+			if err != nil {
+				errChan <- fmt.Errorf("FLOW-%d: failed to read EOF opcode: %w", flowID, err)
+				return
+			}
+			if opcodeByte != RDB_OPCODE_EOF {
+				errChan <- fmt.Errorf("FLOW-%d: expected EOF opcode 0xFF, got 0x%02X at stream offset %d",
+					flowID, opcodeByte, r.flowStreamOffset(flowID)-1)
+				return
+			}
+			log.Printf("  [FLOW-%d] ✓ Found legacy EOF opcode (0xFF)", flowID)
 
 			// 3. Read checksum (8 bytes)
 			checksumBuf := make([]byte, 8)
-			if _, err := io.ReadFull(flowConn, checksumBuf); err != nil {
+			if _, err := io.ReadFull(flowReader, checksumBuf); err != nil {
 				errChan <- fmt.Errorf("FLOW-%d: failed to read checksum: %w", flowID, err)
 				return
 			}
 
 			// 4. Read EOF token (40 bytes)
 			tokenBuf := make([]byte, 40)
-			if _, err := io.ReadFull(flowConn, tokenBuf); err != nil {
+			if _, err := io.ReadFull(flowReader, tokenBuf); err != nil {
 				errChan <- fmt.Errorf("FLOW-%d: failed to read EOF token: %w", flowID, err)
 				return
 			}
@@ -1203,8 +1895,7 @@ func (r *Replicator) verifyEofTokens() error {
 
 			// 5. Compare token
 			if receivedToken != expectedToken {
-				errChan <- fmt.Errorf("FLOW-%d: EOF token mismatch\n  expected: %s\n  actual: %s",
-					flowID, expectedToken, receivedToken)
+				errChan <- r.eofTokenMismatch(flowID, expectedToken, receivedToken)
 				return
 			}
 
@@ -1277,13 +1968,17 @@ func (r *Replicator) sendFlowACK(flowID int, lsn uint64) error {
 	return nil
 }
 
-// startFlowHeartbeat launches a goroutine that periodically sends REPLCONF ACK for a specific FLOW
 // startFlowHeartbeat launches a goroutine that periodically sends REPLCONF ACK for a specific FLOW
 // Smart Heartbeat: Only sends ACK if ACK value changed or keepalive timeout (10s) reached.
-func (r *Replicator) startFlowHeartbeat(flowID int, currentLSN *uint64, opsCount *uint64, forcePing *bool, mu *sync.Mutex, done chan struct{}) {
+// With source.ackApplied the ACK is held at the last replayed entry while commands are in flight.
+func (r *Replicator) startFlowHeartbeat(flowID int, st *FlowACKState, done chan struct{}) {
 	logger.Debug("  [FLOW-%d] [DEBUG] Smart Heartbeat function entered", flowID)
 
-	ticker := time.NewTicker(1 * time.Second) // Check state every 1 second
+	interval := time.Duration(r.cfg.Source.AckIntervalMs) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval) // Check state every interval (default 1s)
 	defer ticker.Stop()
 
 	logger.Info("  [FLOW-%d] ✓ Smart Heartbeat goroutine started", flowID)
@@ -1296,13 +1991,24 @@ func (r *Replicator) startFlowHeartbeat(flowID int, currentLSN *uint64, opsCount
 	for {
 		select {
 		case <-ticker.C:
-			mu.Lock()
-			lsn := *currentLSN
-			ops := *opsCount
+			st.mu.Lock()
+			lsn := st.currentLSN
+			ops := st.opsCount
 			ackVal := lsn + ops
-			shouldForcePing := *forcePing
-			*forcePing = false // Reset forcePing flag
-			mu.Unlock()
+			lastCmdAck := st.lastCmdAck
+			shouldForcePing := st.forcePing
+			st.forcePing = false // Reset forcePing flag
+			st.mu.Unlock()
+
+			if r.cfg.Source.AckApplied {
+				if applied := r.appliedAck(flowID); lastCmdAck > applied {
+					ackVal = applied
+				}
+			}
+			// Never move the master's view of this replica backwards
+			if ackVal < lastSentAckVal {
+				ackVal = lastSentAckVal
+			}
 
 			// Smart Heartbeat Logic
 			shouldSend := false
@@ -1349,6 +2055,125 @@ func (r *Replicator) startFlowHeartbeat(flowID int, currentLSN *uint64, opsCount
 	}
 }
 
+// roleCheckInterval is how often stable sync confirms the source is still a master.
+const roleCheckInterval = 5 * time.Second
+
+// ErrSourceNotMaster is returned when the source was demoted (e.g. a failover)
+// during stable sync; its journal would otherwise just stop with an EOF.
+var ErrSourceNotMaster = errors.New("source is no longer master")
+
+// mainConnPollInterval is how long stable sync waits for a master-initiated
+// command on the main connection before checking for shutdown or a role check.
+const mainConnPollInterval = time.Second
+
+// watchSourceRole owns the main connection during stable sync. It polls INFO
+// replication and reports a demotion once, and in between reads what the
+// master sends on its own: REPLCONF GETACK is answered with REPLCONF ACK and
+// the applied offset, anything else is logged. Poll failures are only logged:
+// the FLOW readers notice a dead source.
+func (r *Replicator) watchSourceRole(roleErr chan<- error, done <-chan struct{}) {
+	nextCheck := time.Now().Add(roleCheckInterval)
+	for {
+		select {
+		case <-done:
+			return
+		case <-r.ctx.Done():
+			return
+		default:
+		}
+
+		if !time.Now().Before(nextCheck) {
+			nextCheck = time.Now().Add(roleCheckInterval)
+			info, err := r.sourceReplicationInfo()
+			if err != nil {
+				log.Printf("  ⚠ Source role check failed: %v", err)
+			} else if role := parseReplicationRole(info); role != "" && role != "master" {
+				roleErr <- fmt.Errorf("%w (role=%s)", ErrSourceNotMaster, role)
+				return
+			}
+		}
+
+		reply, err := r.mainConn.ReadPush(mainConnPollInterval)
+		if err != nil {
+			// Broken connection: keep polling the role at the old pace
+			select {
+			case <-time.After(time.Until(nextCheck)):
+			case <-done:
+				return
+			case <-r.ctx.Done():
+				return
+			}
+			continue
+		}
+		if reply != nil {
+			r.handleMasterCommand(reply)
+		}
+	}
+}
+
+// sourceReplicationInfo runs INFO replication on the main connection. Master
+// commands that arrive ahead of the reply are handled on the way.
+func (r *Replicator) sourceReplicationInfo() (string, error) {
+	reply, err := r.mainConn.Do("INFO", "replication")
+	for err == nil && isMasterCommand(reply) {
+		r.handleMasterCommand(reply)
+		reply, err = r.mainConn.ReadPush(roleCheckInterval)
+		if err == nil && reply == nil {
+			err = fmt.Errorf("no reply to INFO replication")
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return redisx.ToString(reply)
+}
+
+// isMasterCommand reports whether reply is a command array the master sent
+// on its own rather than the answer to one of ours.
+func isMasterCommand(reply interface{}) bool {
+	args, ok := reply.([]interface{})
+	return ok && len(args) > 0
+}
+
+// handleMasterCommand answers REPLCONF GETACK from the master with the
+// offset replayed so far and logs any other command it sends.
+func (r *Replicator) handleMasterCommand(reply interface{}) {
+	args, err := redisx.ToStringSlice(reply)
+	if err != nil || len(args) == 0 {
+		log.Printf("  ⚠ Unexpected message from source on the main connection: %v", reply)
+		return
+	}
+	cmd := strings.ToUpper(args[0])
+	switch {
+	case cmd == "REPLCONF" && len(args) > 1 && strings.EqualFold(args[1], "GETACK"):
+		r.ackMu.Lock()
+		offset := r.lastAckedLSN
+		r.ackMu.Unlock()
+		if err := r.mainConn.Send("REPLCONF", "ACK", strconv.FormatUint(offset, 10)); err != nil {
+			log.Printf("  ⚠ Answering REPLCONF GETACK failed: %v", err)
+			return
+		}
+		logger.Debug("  ✓ Answered REPLCONF GETACK with ACK %d", offset)
+	case cmd == "PING":
+		logger.Debug("  Source PING on the main connection")
+	default:
+		log.Printf("  ⓘ Source sent %s on the main connection (ignored)", strings.Join(args, " "))
+	}
+}
+
+// parseReplicationRole extracts the role field from an INFO replication reply.
+func parseReplicationRole(info string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), "role:"); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// replaySpanEntries caps how many journal entries one replay.batch span covers.
+const replaySpanEntries = 500
+
 // receiveJournal consumes journal streams from all FLOW connections in parallel
 func (r *Replicator) receiveJournal() error {
 	log.Println("")
@@ -1359,6 +2184,7 @@ func (r *Replicator) receiveJournal() error {
 	r.recordPipelineStatus("incremental", "Replaying journal incrementally")
 	r.recordStage("replicator", "journal", "Listening to journal stream")
 	r.state = StateStableSync // Set state to Incremental/Stable
+	r.recordEvent(state.SeverityInfo, "stable-sync", "Entered stable sync, replaying the journal")
 
 	numFlows := len(r.flowConns)
 	if numFlows == 0 {
@@ -1403,20 +2229,51 @@ func (r *Replicator) receiveJournal() error {
 		close(entryChan)
 	}()
 
+	// Writes that hit a transient target error are retried in order once it recovers
+	if size := r.cfg.Advanced.RetryQueueSize; size > 0 {
+		r.retryQ = newRetryQueue(size)
+	}
+	retryTicker := time.NewTicker(retryBaseDelay)
+	defer retryTicker.Stop()
+
+	// A failover on the source ends its journal; catch the demotion explicitly
+	roleErr := make(chan error, 1)
+	roleDone := make(chan struct{})
+	defer close(roleDone)
+	go r.watchSourceRole(roleErr, roleDone)
+
+	// Optionally hold entries briefly so cross-FLOW commands replay in TxID order
+	var reorder *reorderBuffer
+	var reorderTick <-chan time.Time
+	if ms := r.cfg.Advanced.ReorderWindowMs; ms > 0 {
+		window := time.Duration(ms) * time.Millisecond
+		reorder = newReorderBuffer(window)
+		tick := window / 2
+		if tick < time.Millisecond {
+			tick = time.Millisecond
+		}
+		reorderTicker := time.NewTicker(tick)
+		defer reorderTicker.Stop()
+		reorderTick = reorderTicker.C
+		log.Printf("  • Reordering entries across FLOWs by TxID within a %v window", window)
+	}
+
 	// Main processing loop
 	entriesCount := 0
 	currentDB := uint64(0)
 	flowStats := make(map[int]int) // entries per FLOW
 
-	for flowEntry := range entryChan {
-		// Handle errors
-		if flowEntry.Error != nil {
-			err := fmt.Errorf("FLOW-%d fatal error: %w", flowEntry.FlowID, flowEntry.Error)
-			log.Printf("  ✗ %v", err)
-			r.cancel() // Stop all other flows immediately
-			return err
-		}
+	// Replay is traced per batch of entries; a span per command would flood the collector
+	var replaySpan *tracing.Span
+	var spanEntries, spanFailed int
+	endReplaySpan := func() {
+		replaySpan.SetAttributes("entries", spanEntries, "failed", spanFailed)
+		replaySpan.End()
+		replaySpan, spanEntries, spanFailed = nil, 0, 0
+	}
+	defer func() { endReplaySpan() }()
 
+	process := func(flowEntry *FlowEntry) error {
 		entriesCount++
 		flowStats[flowEntry.FlowID]++
 		entry := flowEntry.Entry
@@ -1434,10 +2291,28 @@ func (r *Replicator) receiveJournal() error {
 		r.replayStats.TotalCommands++
 		r.replayStats.mu.Unlock()
 
+		if replaySpan == nil {
+			_, replaySpan = tracing.Start(r.ctx, "replay.batch")
+		}
+		spanEntries++
+
 		// METRICS INSTRUMENTATION: Track latency and ops count
 		start := time.Now()
-		if err := r.replayCommand(flowEntry.FlowID, entry); err != nil {
+		if err := r.replayCommand(flowEntry.FlowID, entry); errors.Is(err, errQueuedForRetry) {
+			// counted once the retry queue applies or drops it
+		} else if err != nil {
 			log.Printf("  ✗ Replay failed: %v", err)
+			r.recordWriteResults(0, 1)
+			spanFailed++
+			replaySpan.RecordError(err)
+		} else {
+			r.recordWriteResults(1, 0)
+		}
+		r.mirrorJournalEntry(flowEntry.FlowID, entry)
+		// A full queue stalls the journal until the target is back
+		r.drainRetryQueue(r.retryQ.Full())
+		if err := r.writeBudget.Err(); err != nil {
+			return err
 		}
 		duration := time.Since(start)
 		r.addJournalLatency(duration)
@@ -1448,31 +2323,103 @@ func (r *Replicator) receiveJournal() error {
 
 		// Log statistics every 50 entries
 		if entriesCount%50 == 0 {
-			r.replayStats.mu.Lock()
-			log.Printf("  📊 Stats: total=%d, success=%d, skipped=%d, failed=%d",
-				r.replayStats.TotalCommands,
-				r.replayStats.ReplayedOK,
-				r.replayStats.Skipped,
-				r.replayStats.Failed)
-
-			// Report per-FLOW stats
-			for fid, count := range flowStats {
-				lsn := r.replayStats.FlowLSNs[fid]
-				log.Printf("    FLOW-%d: %d entries, LSN=%d", fid, count, lsn)
+			r.logReplayStats(flowStats)
+		}
+		if spanEntries >= replaySpanEntries {
+			endReplaySpan()
+		}
+		return nil
+	}
+
+	// releaseDue applies every buffered entry whose window has passed
+	releaseDue := func() error {
+		now := time.Now()
+		for fe := reorder.popDue(now); fe != nil; fe = reorder.popDue(now) {
+			if err := process(fe); err != nil {
+				return err
 			}
-			r.replayStats.mu.Unlock()
+		}
+		return nil
+	}
+
+	for {
+		var flowEntry *FlowEntry
+		var ok bool
+		select {
+		case flowEntry, ok = <-entryChan:
+		case <-reorderTick:
+			if err := releaseDue(); err != nil {
+				return err
+			}
+			continue
+		case <-retryTicker.C:
+			// Close the batch span on every tick too, so it never times an idle stream
+			endReplaySpan()
+			// Keep draining while the journal is idle
+			r.drainRetryQueue(false)
+			r.metrics.Set(state.MetricRetryQueueLen, float64(r.retryQ.Len()))
+			r.metrics.Set(state.MetricReorderBufferLen, float64(reorder.Len()))
+			continue
+		case err := <-roleErr:
+			log.Printf("  ✗ %v, stopping replication", err)
+			r.cancel() // Stop all FLOW readers
+			r.drainRetryQueue(false)
+			if r.cfg.Checkpoint.Enabled {
+				if cpErr := r.saveCheckpoint(); cpErr != nil {
+					log.Printf("  ⚠ Failed to save checkpoint: %v", cpErr)
+				} else {
+					r.recordEvent(state.SeverityInfo, "checkpoint", "Checkpoint saved after the source was demoted")
+					log.Printf("  💾 Checkpoint saved to %s; resume once the source is a master again", r.cfg.Checkpoint.Path)
+				}
+			}
+			return err
+		}
+		if !ok {
+			break
+		}
+
+		// Handle errors
+		if flowEntry.Error != nil {
+			err := fmt.Errorf("FLOW-%d fatal error: %w", flowEntry.FlowID, flowEntry.Error)
+			log.Printf("  ✗ %v", err)
+			r.cancel() // Stop all other flows immediately
+			return err
+		}
+
+		if reorder != nil {
+			reorder.push(flowEntry, time.Now())
+			if err := releaseDue(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := process(flowEntry); err != nil {
+			return err
+		}
+	}
+
+	// The stream is over; nothing else can arrive to be ordered before what's left
+	for fe := reorder.pop(); fe != nil; fe = reorder.pop() {
+		if err := process(fe); err != nil {
+			return err
 		}
 	}
 
 	log.Println("  • Journal stream finished for all FLOW connections")
+	r.drainRetryQueue(false)
+	if n := r.retryQ.Len(); n > 0 {
+		log.Printf("  ⚠ %d journal entries are still waiting for the target; the checkpoint stops before them", n)
+	}
 
 	// Persist final checkpoint if enabled
 	if r.cfg.Checkpoint.Enabled {
 		log.Println("  💾 Saving final checkpoint...")
 		if err := r.saveCheckpoint(); err != nil {
 			log.Printf("  ⚠ Failed to save final checkpoint: %v", err)
+			r.recordEvent(state.SeverityWarn, "checkpoint", fmt.Sprintf("Final checkpoint save failed: %v", err))
 		} else {
 			log.Println("  ✓ Checkpoint saved")
+			r.recordEvent(state.SeverityInfo, "checkpoint", "Final checkpoint saved")
 		}
 	}
 
@@ -1483,29 +2430,101 @@ func (r *Replicator) receiveJournal() error {
 type FlowACKState struct {
 	currentLSN uint64
 	opsCount   uint64 // Number of operations executed since last LSN
+	markLSN    uint64 // last OpLSN value received; commands are stamped relative to it
+	markOps    uint64 // commands received since markLSN
+	lastCmdAck uint64 // ACK value of the last command handed to replay (source.ackApplied)
 	forcePing  bool
 	mu         sync.Mutex
 }
 
+// advance moves the stream position past entry, simulating native Dragonfly
+// replica behavior: ACK value = currentLSN + opsCount (operations executed
+// since the last LSN checkpoint).
+//
+// Commands are stamped with their position so replayCommand can drop entries
+// that were already applied before a resume. The stamp is the last OpLSN value
+// Dragonfly sent plus the commands seen since, and is reset to every OpLSN even
+// when it goes backwards, so a window the source sends twice is stamped the
+// same both times. Commands also record the ACK value reached with them
+// (AckPos), which is what source.ackApplied may acknowledge once they are
+// replayed. The caller holds s.mu.
+func (s *FlowACKState) advance(entry *JournalEntry) {
+	// Handle OpLSN: Check if this is a checkpoint that advances our position
+	if entry.Opcode == OpLSN {
+		currentTotal := s.currentLSN + s.opsCount
+		if entry.LSN > currentTotal {
+			// Jump forward: new LSN checkpoint is ahead of our current position
+			s.currentLSN = entry.LSN
+			s.opsCount = 0
+		} else {
+			// No jump: LSN checkpoint is behind or equal, treat as regular opcode
+			s.opsCount++
+		}
+	} else {
+		// All non-LSN opcodes increment the operation counter
+		s.opsCount++
+	}
+
+	switch entry.Opcode {
+	case OpLSN:
+		s.markLSN, s.markOps = entry.LSN, 0
+	case OpCommand, OpExpired:
+		s.markOps++
+		entry.LSN = s.markLSN + s.markOps
+		entry.AckPos = s.currentLSN + s.opsCount
+		s.lastCmdAck = entry.AckPos
+	}
+
+	// Handle OpPing: force immediate ACK
+	if entry.Opcode == OpPing {
+		s.forcePing = true
+	}
+}
+
 // readFlowJournal reads the journal stream for a specific FLOW
 func (r *Replicator) readFlowJournal(flowID int, entryChan chan<- *FlowEntry, wg *sync.WaitGroup) {
 	defer wg.Done()
+	defer r.recoverFlow(flowID, flowPhaseJournal, func(err error) {
+		select {
+		case entryChan <- &FlowEntry{FlowID: flowID, Error: err}:
+		case <-r.ctx.Done():
+		}
+	})
 
 	// Use the persistent buffered reader: this is CRITICAL to recover any journal data
 	// that was buffered during the RDB phase (immediately after the EOF token).
-	reader := NewJournalReader(r.flowBufReaders[flowID])
+	var src io.Reader = r.flowBufReaders[flowID]
+	var capture *journalCapture
+	if base := r.cfg.ResolvePath(r.cfg.Observability.CaptureJournal); base != "" {
+		path := captureJournalPath(base, flowID)
+		var err error
+		if capture, err = newJournalCapture(src, path); err != nil {
+			log.Printf("  [FLOW-%d] ⚠ Journal capture disabled: %v", flowID, err)
+		} else {
+			defer func(c *journalCapture) {
+				if err := c.Close(); err != nil {
+					log.Printf("  [FLOW-%d] ⚠ Journal capture %s incomplete: %v", flowID, path, err)
+				}
+			}(capture)
+			src = capture
+			log.Printf("  [FLOW-%d] 📼 Capturing journal stream to %s", flowID, path)
+		}
+	}
+	reader := NewJournalReader(src)
 	log.Printf("  [FLOW-%d] Starting journal stream reception", flowID)
 	r.recordFlowStage(flowID, "journal", "Listening to journal stream")
 
 	// CRITICAL: Start REPLCONF ACK heartbeat for THIS flow
 	// Each flow maintains its own connection to master and must send independent ACKs
 	ackState := &FlowACKState{
-		currentLSN: 0,
+		currentLSN: r.resumeLSNs[flowID],
+		markLSN:    r.resumeLSNs[flowID],
 		opsCount:   0,
 		forcePing:  false,
 	}
+	r.markAcked(flowID, ackState.currentLSN)
 	heartbeatDone := make(chan struct{})
-	go r.startFlowHeartbeat(flowID, &ackState.currentLSN, &ackState.opsCount, &ackState.forcePing, &ackState.mu, heartbeatDone)
+	go r.startFlowHeartbeat(flowID, ackState, heartbeatDone)
 	defer close(heartbeatDone)
 
 	for {
@@ -1532,37 +2551,25 @@ func (r *Replicator) readFlowJournal(flowID int, entryChan chan<- *FlowEntry, wg
 				Error:  fmt.Errorf("read failed: %w", err),
 			}
 			log.Printf("  [FLOW-%d] ✗ Fatal error: %v", flowID, err)
+			r.recordEvent(state.SeverityError, "flow", fmt.Sprintf("FLOW-%d journal stream lost: %v", flowID, err))
 			r.recordFlowStage(flowID, "error", fmt.Sprintf("Journal read failed: %v", err))
 			return
 		}
 
-		// Update ACK state to simulate native Dragonfly replica behavior
-		// ACK value = currentLSN + opsCount (operations executed since last LSN checkpoint)
 		ackState.mu.Lock()
-
-		// Handle OpLSN: Check if this is a checkpoint that advances our position
-		if entry.Opcode == OpLSN {
-			currentTotal := ackState.currentLSN + ackState.opsCount
-			if entry.LSN > currentTotal {
-				// Jump forward: new LSN checkpoint is ahead of our current position
-				ackState.currentLSN = entry.LSN
-				ackState.opsCount = 0
-			} else {
-				// No jump: LSN checkpoint is behind or equal, treat as regular opcode
-				ackState.opsCount++
-			}
-		} else {
-			// All non-LSN opcodes increment the operation counter
-			ackState.opsCount++
+		ackState.advance(entry)
+		ackState.mu.Unlock()
+		if entry.Opcode == OpCommand || entry.Opcode == OpExpired {
+			r.metrics.Set(fmt.Sprintf(state.MetricFlowReceivedLSNFormat, flowID), float64(entry.LSN))
 		}
 
-		// Handle OpPing: force immediate ACK
-		if entry.Opcode == OpPing {
-			ackState.forcePing = true
+		if capture != nil {
+			if err := capture.Flush(); err != nil {
+				log.Printf("  [FLOW-%d] ⚠ Journal capture write failed, stopping capture: %v", flowID, err)
+				capture = nil
+			}
 		}
 
-		ackState.mu.Unlock()
-
 		// Forward entry
 		entryChan <- &FlowEntry{
 			FlowID: flowID,
@@ -1585,17 +2592,8 @@ func (r *Replicator) displayFlowEntry(flowID int, entry *JournalEntry, currentDB
 		log.Printf("  [%d] FLOW-%d: PING", count, flowID)
 
 	case OpCommand:
-		// Format arguments
-		args := make([]string, len(entry.Args))
-		for i, arg := range entry.Args {
-			if len(arg) > 50 {
-				args[i] = fmt.Sprintf("\"%s...\"", arg[:50])
-			} else {
-				args[i] = fmt.Sprintf("\"%s\"", arg)
-			}
-		}
 		log.Printf("  [%d] FLOW-%d: %s %s (txid=%d, shards=%d)",
-			count, flowID, entry.Command, strings.Join(args, " "), entry.TxID, entry.ShardCnt)
+			count, flowID, entry.Command, formatArgs(entry.Args, r.cfg.Log.HideValues, " "), entry.TxID, entry.ShardCnt)
 
 	case OpExpired:
 		log.Printf("  [%d] FLOW-%d: EXPIRED %s (txid=%d)",
@@ -1616,35 +2614,16 @@ func (r *Replicator) displayEntry(entry *JournalEntry, currentDB uint64, count i
 	case OpLSN:
 		log.Printf("  [%d] LSN %d", count, entry.LSN)
 
-	case OpPing:
-		log.Printf("  [%d] PING", count)
-
-	case OpCommand:
-		// Format arguments
-		args := make([]string, len(entry.Args))
-		for i, arg := range entry.Args {
-			if len(arg) > 50 {
-				args[i] = fmt.Sprintf("\"%s...\"", arg[:50])
-			} else {
-				args[i] = fmt.Sprintf("\"%s\"", arg)
-			}
-		}
+	case OpPing:
+		log.Printf("  [%d] PING", count)
 
+	case OpCommand:
 		log.Printf("  [%d] DB=%d COMMAND %s %s",
-			count, currentDB, entry.Command, strings.Join(args, " "))
+			count, currentDB, entry.Command, formatArgs(entry.Args, r.cfg.Log.HideValues, " "))
 
 	case OpExpired:
-		args := make([]string, len(entry.Args))
-		for i, arg := range entry.Args {
-			if len(arg) > 50 {
-				args[i] = fmt.Sprintf("\"%s...\"", arg[:50])
-			} else {
-				args[i] = fmt.Sprintf("\"%s\"", arg)
-			}
-		}
-
 		log.Printf("  [%d] DB=%d EXPIRED %s %s",
-			count, currentDB, entry.Command, strings.Join(args, " "))
+			count, currentDB, entry.Command, formatArgs(entry.Args, r.cfg.Log.HideValues, " "))
 
 	default:
 		log.Printf("  [%d] %s", count, entry.String())
@@ -1673,7 +2652,12 @@ type ReplayStats struct {
 	ReplayedOK     int64
 	Skipped        int64
 	Failed         int64
+	Blocked        int64          // commands rejected by the allow/deny list
+	Duplicates     int64          // commands dropped because their LSN was already applied
+	RetryQueued    int64          // commands parked in the retry queue after a transient target error
 	FlowLSNs       map[int]uint64 // latest LSN per FLOW
+	AppliedLSNs    map[int]uint64 // highest LSN replayed per FLOW (low-water mark for duplicates)
+	AppliedAcks    map[int]uint64 // ACK value reached by the commands replayed per FLOW (source.ackApplied)
 	LastReplayTime time.Time
 }
 
@@ -1683,10 +2667,202 @@ type RDBStats struct {
 	Commands         int64 // Total Redis commands executed during RDB import (excludes inline journal)
 	Keys             int64 // Total keys imported
 	InlineJournalOps int64 // Inline journal operations applied during RDB phase
+	PartialKeys      int64 // Sets/zsets whose member writes failed midway
+	OversizeKeys     int64 // Keys with an element over the target proto-max-bulk-len
+	TypeFiltered     int64 // Keys skipped by migrate.includeTypes
+}
+
+// alreadyApplied reports whether the command at lsn was applied before, e.g.
+// by a previous run when the source resumes from an older position. Entries
+// without a stream position (inline journal during the snapshot) are always
+// replayed.
+func (r *Replicator) alreadyApplied(flowID int, lsn uint64) bool {
+	if lsn == 0 {
+		return false
+	}
+	r.replayStats.mu.Lock()
+	defer r.replayStats.mu.Unlock()
+	return lsn <= r.replayStats.AppliedLSNs[flowID]
+}
+
+// markApplied advances the applied LSN of a FLOW once the command at lsn was
+// written (or deliberately skipped), and publishes it for status and cutover.
+func (r *Replicator) markApplied(flowID int, lsn uint64) {
+	if lsn == 0 {
+		return
+	}
+	r.replayStats.mu.Lock()
+	if r.replayStats.AppliedLSNs == nil {
+		r.replayStats.AppliedLSNs = make(map[int]uint64)
+	}
+	if lsn > r.replayStats.AppliedLSNs[flowID] {
+		r.replayStats.AppliedLSNs[flowID] = lsn
+	}
+	applied := r.replayStats.AppliedLSNs[flowID]
+	var max uint64
+	for _, v := range r.replayStats.AppliedLSNs {
+		if v > max {
+			max = v
+		}
+	}
+	r.replayStats.mu.Unlock()
+
+	r.metrics.Set(fmt.Sprintf(state.MetricFlowAppliedLSNFormat, flowID), float64(applied))
+	r.metrics.Set(state.MetricIncrementalLSNApplied, float64(max))
+}
+
+// logReplayStats prints replay counters and per-FLOW LSNs. The counters are
+// copied under replayStats.mu and logged after releasing it, so nothing that
+// takes the lock again (checkpoint saves, metrics) can run while it is held.
+func (r *Replicator) logReplayStats(flowStats map[int]int) {
+	r.replayStats.mu.Lock()
+	total, ok, skipped := r.replayStats.TotalCommands, r.replayStats.ReplayedOK, r.replayStats.Skipped
+	blocked, dups, failed := r.replayStats.Blocked, r.replayStats.Duplicates, r.replayStats.Failed
+	lsns := make(map[int]uint64, len(flowStats))
+	for fid := range flowStats {
+		lsns[fid] = r.replayStats.FlowLSNs[fid]
+	}
+	r.replayStats.mu.Unlock()
+
+	log.Printf("  📊 Stats: total=%d, success=%d, skipped=%d, blocked=%d, duplicate=%d, failed=%d",
+		total, ok, skipped, blocked, dups, failed)
+	for fid, count := range flowStats {
+		log.Printf("    FLOW-%d: %d entries, LSN=%d", fid, count, lsns[fid])
+	}
+}
+
+// errQueuedForRetry marks a journal entry parked in the retry queue; it is
+// neither a success nor a failure until the queue applies or drops it.
+var errQueuedForRetry = errors.New("queued for retry")
+
+// retryable reports whether a failed write should wait for the target to recover.
+func (r *Replicator) retryable(err error) bool {
+	return r.retryQ != nil && redisx.IsRetryableError(err)
+}
+
+// queueForRetry parks an entry behind the retry queue. While the queue itself
+// is being drained the error is returned unchanged so the drain backs off.
+// A queued command does not count as applied (source.ackApplied, cutover)
+// until drainRetryQueue writes it.
+func (r *Replicator) queueForRetry(flowID int, entry *JournalEntry, err error) error {
+	if r.retryQ.isDraining() {
+		return err
+	}
+	r.retryQ.push(flowID, entry)
+	r.replayStats.mu.Lock()
+	r.replayStats.RetryQueued++
+	r.replayStats.mu.Unlock()
+	if err != nil {
+		log.Printf("  [FLOW-%d] ⏸ Target unavailable, queued %s LSN=%d for retry (%d waiting): %v",
+			flowID, entry.Command, entry.LSN, r.retryQ.Len(), err)
+	}
+	return errQueuedForRetry
+}
+
+// drainRetryQueue re-applies queued entries in order once their backoff has
+// elapsed. With block set it waits (backing off) until the queue is empty or
+// the replicator stops; otherwise it returns at the first unexpired backoff.
+func (r *Replicator) drainRetryQueue(block bool) {
+	q := r.retryQ
+	for q.Len() > 0 {
+		if !q.due() {
+			if !block {
+				return
+			}
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(retryBaseDelay):
+			}
+			continue
+		}
+
+		it, _ := q.head()
+		q.setDraining(true)
+		err := r.applyJournalEntry(it.flowID, it.entry)
+		q.setDraining(false)
+
+		switch {
+		case err == nil:
+			q.pop()
+			r.markApplied(it.flowID, it.entry.LSN)
+			r.markAcked(it.flowID, it.entry.AckPos)
+			r.recordWriteResults(1, 0)
+		case redisx.IsRetryableError(err):
+			delay := q.backoff()
+			log.Printf("  ⏸ Target still unavailable (%d journal entries queued), retrying in %v: %v", q.Len(), delay, err)
+		default:
+			// The target is reachable again but rejected this command; it won't succeed later either
+			q.pop()
+			log.Printf("  [FLOW-%d] ✗ Dropping queued %s LSN=%d: %v", it.flowID, it.entry.Command, it.entry.LSN, err)
+			r.recordWriteResults(0, 1)
+		}
+	}
+}
+
+// appliedLSN returns the stream position of the last command replayed on a FLOW.
+func (r *Replicator) appliedLSN(flowID int) uint64 {
+	r.replayStats.mu.Lock()
+	defer r.replayStats.mu.Unlock()
+	return r.replayStats.AppliedLSNs[flowID]
+}
+
+// markAcked advances the ACK value a FLOW may report under source.ackApplied.
+// Command stamps (LSN) only count commands, while the ACK counter counts every
+// opcode and jumps to each OpLSN, so the two are tracked separately.
+func (r *Replicator) markAcked(flowID int, pos uint64) {
+	r.replayStats.mu.Lock()
+	defer r.replayStats.mu.Unlock()
+	if r.replayStats.AppliedAcks == nil {
+		r.replayStats.AppliedAcks = make(map[int]uint64)
+	}
+	if pos > r.replayStats.AppliedAcks[flowID] {
+		r.replayStats.AppliedAcks[flowID] = pos
+	}
+}
+
+// appliedAck returns the ACK value reached by the last command replayed on a FLOW.
+func (r *Replicator) appliedAck(flowID int) uint64 {
+	r.replayStats.mu.Lock()
+	defer r.replayStats.mu.Unlock()
+	return r.replayStats.AppliedAcks[flowID]
 }
 
 // replayCommand replays a single journal command into Redis Cluster
 func (r *Replicator) replayCommand(flowID int, entry *JournalEntry) error {
+	isCmd := entry.Opcode == OpCommand || entry.Opcode == OpExpired
+	if isCmd && r.alreadyApplied(flowID, entry.LSN) {
+		log.Printf("  [FLOW-%d] ⊘ Dropped duplicate: %s LSN=%d (reason: already applied)", flowID, entry.Command, entry.LSN)
+		r.replayStats.mu.Lock()
+		r.replayStats.Duplicates++
+		r.replayStats.mu.Unlock()
+		r.markAcked(flowID, entry.AckPos)
+		return nil
+	}
+	if r.transformer != nil && isCmd {
+		entry.Args = r.transformer.TransformCommand(strings.ToUpper(entry.Command), entry.Args)
+	}
+	// Pin expire-family commands to an absolute deadline now, so neither
+	// retries nor relative/absolute semantics on the target shift the TTL
+	if entry.Opcode == OpCommand {
+		if cmd, args, ok := normalizeExpire(entry.Command, entry.Args, getCurrentTimeMillis()); ok {
+			entry.Command, entry.Args = cmd, args
+		}
+	}
+	// Keep per-key order: nothing overtakes writes still waiting for a retry
+	if isCmd && r.retryQ.Len() > 0 && !r.retryQ.isDraining() {
+		return r.queueForRetry(flowID, entry, nil)
+	}
+	err := r.applyJournalEntry(flowID, entry)
+	if err == nil && isCmd {
+		r.markApplied(flowID, entry.LSN)
+		r.markAcked(flowID, entry.AckPos)
+	}
+	return err
+}
+
+// applyJournalEntry replays an entry that is not a duplicate.
+func (r *Replicator) applyJournalEntry(flowID int, entry *JournalEntry) error {
 	switch entry.Opcode {
 	case OpSelect:
 		// Redis Cluster only exposes DB 0, ignore SELECT
@@ -1731,9 +2907,12 @@ func (r *Replicator) replayCommand(flowID int, entry *JournalEntry) error {
 		// Handle expired key by re-applying TTL using PEXPIRE
 		keyName := "unknown"
 		if len(entry.Args) > 0 {
-			keyName = entry.Args[0]
+			keyName = quoteArg(entry.Args[0])
 		}
 		if err := r.handleExpiredKey(entry); err != nil {
+			if r.retryable(err) {
+				return r.queueForRetry(flowID, entry, err)
+			}
 			log.Printf("  [FLOW-%d] ✗ FAILED OpExpired key=%s, error: %v", flowID, keyName, err)
 			r.replayStats.mu.Lock()
 			r.replayStats.Failed++
@@ -1752,7 +2931,7 @@ func (r *Replicator) replayCommand(flowID int, entry *JournalEntry) error {
 		cmd := strings.ToUpper(entry.Command)
 		keyName := "N/A"
 		if len(entry.Args) > 0 {
-			keyName = entry.Args[0]
+			keyName = quoteArg(entry.Args[0])
 		}
 
 		if isGlobalCommand(cmd) {
@@ -1763,16 +2942,64 @@ func (r *Replicator) replayCommand(flowID int, entry *JournalEntry) error {
 			return nil
 		}
 
+		if !r.cmdFilter.Allowed(cmd, entry.Args) {
+			log.Printf("  [FLOW-%d] ⊘ Blocked command: %s key=%s (reason: conflict.commandAllowList/commandDenyList)", flowID, cmd, keyName)
+			r.replayStats.mu.Lock()
+			r.replayStats.Blocked++
+			r.replayStats.mu.Unlock()
+			return nil
+		}
+
+		// Slot-scoped flush from a Dragonfly cluster source
+		if isFlushSlots(entry) && r.transformer != nil {
+			log.Printf("  [FLOW-%d] ⊘ Skipped DFLYCLUSTER FLUSHSLOTS %s (reason: source slots don't match rewritten keys)",
+				flowID, formatArgs(entry.Args[1:], false, " "))
+			r.recordEvent(state.SeverityWarn, "flushslots", "FLUSHSLOTS skipped: keys are rewritten (migrate.keyRewrites)")
+			r.replayStats.mu.Lock()
+			r.replayStats.Skipped++
+			r.replayStats.mu.Unlock()
+			return nil
+		}
+		if isFlushSlots(entry) {
+			deleted, err := r.flushSlots(entry)
+			if err != nil {
+				log.Printf("  [FLOW-%d] ✗ FAILED DFLYCLUSTER FLUSHSLOTS %s, error: %v", flowID, formatArgs(entry.Args[1:], false, " "), err)
+				r.replayStats.mu.Lock()
+				r.replayStats.Failed++
+				r.replayStats.mu.Unlock()
+				return fmt.Errorf("FLUSHSLOTS failed: %w", err)
+			}
+			log.Printf("  [FLOW-%d] ✓ FLUSHSLOTS applied: slots=[%s] deleted=%d keys", flowID, formatArgs(entry.Args[1:], false, " "), deleted)
+			r.replayStats.mu.Lock()
+			r.replayStats.ReplayedOK++
+			r.replayStats.LastReplayTime = time.Now()
+			r.replayStats.mu.Unlock()
+			return nil
+		}
+
 		// Execute regular command
 		if err := r.executeCommand(entry); err != nil {
-			log.Printf("  [FLOW-%d] ✗ FAILED command: %s key=%s args=%v, error: %v", flowID, entry.Command, keyName, entry.Args[1:], err)
+			if r.retryable(err) {
+				return r.queueForRetry(flowID, entry, err)
+			}
+			if errors.Is(err, redisx.ErrCrossSlot) {
+				// Keys of a multi-key command live in different target slots; retrying can't fix that
+				log.Printf("  [FLOW-%d] ⊘ Skipped %s key=%s (reason: CROSSSLOT on target, keys span slots)", flowID, cmd, keyName)
+				r.replayStats.mu.Lock()
+				r.replayStats.Skipped++
+				r.replayStats.mu.Unlock()
+				return nil
+			}
+			log.Printf("  [FLOW-%d] ✗ FAILED command: %s key=%s args=[%s], error: %v",
+				flowID, entry.Command, keyName, formatValueArgs(entry.Args[1:], r.cfg.Log.HideValues), err)
 			r.replayStats.mu.Lock()
 			r.replayStats.Failed++
 			r.replayStats.mu.Unlock()
 			return fmt.Errorf("Command execution failed: %w", err)
 		}
 
-		log.Printf("  [FLOW-%d] ✓ Command applied: %s key=%s args=%v", flowID, entry.Command, keyName, entry.Args[1:])
+		log.Printf("  [FLOW-%d] ✓ Command applied: %s key=%s args=[%s]",
+			flowID, entry.Command, keyName, formatValueArgs(entry.Args[1:], r.cfg.Log.HideValues))
 		r.replayStats.mu.Lock()
 		r.replayStats.ReplayedOK++
 		r.replayStats.LastReplayTime = time.Now()
@@ -1817,21 +3044,34 @@ func (r *Replicator) executeCommand(entry *JournalEntry) error {
 	return err
 }
 
+// absoluteTTL reports whether key TTLs are written as absolute timestamps
+// (conflict.ttlMode: absolute) rather than as time left on this host's clock.
+func (r *Replicator) absoluteTTL() bool {
+	return r.cfg.Conflict.TTLMode == "absolute"
+}
+
+// matchesNotifyPattern reports whether key must be written with commands so
+// the target emits its regular keyspace notifications (conflict.notifyPatterns).
+func (r *Replicator) matchesNotifyPattern(key string) bool {
+	for _, p := range r.cfg.Conflict.NotifyPatterns {
+		if redisx.MatchGlob(p, key) {
+			return true
+		}
+	}
+	return false
+}
+
 // isGlobalCommand checks if a command needs cluster-wide coordination
 func isGlobalCommand(cmd string) bool {
 	globalCmds := map[string]bool{
-		"FLUSHDB":                true,
-		"FLUSHALL":               true,
-		"DFLYCLUSTER FLUSHSLOTS": true,
+		"FLUSHDB":  true,
+		"FLUSHALL": true,
 	}
 	return globalCmds[cmd]
 }
 
 // saveCheckpoint persists the current checkpoint state
 func (r *Replicator) saveCheckpoint() error {
-	r.replayStats.mu.Lock()
-	defer r.replayStats.mu.Unlock()
-
 	// Build checkpoint payload
 	cp := &checkpoint.Checkpoint{
 		ReplicationID: r.masterInfo.ReplID,
@@ -1840,33 +3080,54 @@ func (r *Replicator) saveCheckpoint() error {
 		FlowLSNs:      make(map[int]uint64),
 	}
 
-	// Copy FlowLSNs
+	// Copy FlowLSNs; the file writes happen without holding the replay lock
+	r.replayStats.mu.Lock()
 	for flowID, lsn := range r.replayStats.FlowLSNs {
 		cp.FlowLSNs[flowID] = lsn
 	}
+	r.replayStats.mu.Unlock()
+
+	// Never checkpoint past a write that is still waiting in the retry queue
+	for flowID, lsn := range r.retryQ.oldestLSNs() {
+		if cur, ok := cp.FlowLSNs[flowID]; !ok || cur >= lsn {
+			cp.FlowLSNs[flowID] = lsn - 1
+		}
+	}
 
 	// Save to file
 	if err := r.checkpointMgr.Save(cp); err != nil {
 		return fmt.Errorf("Failed to save checkpoint: %w", err)
 	}
 
-	r.lastCheckpointTime = time.Now()
+	now := time.Now()
+	r.replayStats.mu.Lock()
+	r.lastCheckpointTime = now
+	r.entriesSinceSave = 0
+	r.replayStats.mu.Unlock()
 	if r.metrics != nil {
-		r.metrics.Set(state.MetricCheckpointSavedAtUnix, float64(r.lastCheckpointTime.Unix()))
+		r.metrics.Set(state.MetricCheckpointSavedAtUnix, float64(now.Unix()))
 	}
 	return nil
 }
 
-// tryAutoSaveCheckpoint periodically persists checkpoints
+// tryAutoSaveCheckpoint persists a checkpoint once checkpoint.intervalSeconds
+// have passed or, with checkpoint.everyEntries, that many entries were
+// applied since the last save. Called once per applied journal entry.
 func (r *Replicator) tryAutoSaveCheckpoint() {
 	// Skip when checkpointing is disabled
 	if !r.cfg.Checkpoint.Enabled {
 		return
 	}
 
-	if time.Since(r.lastCheckpointTime) >= r.checkpointInterval {
+	r.replayStats.mu.Lock()
+	r.entriesSinceSave++
+	due := time.Since(r.lastCheckpointTime) >= r.checkpointInterval ||
+		(r.cfg.Checkpoint.Entries > 0 && r.entriesSinceSave >= r.cfg.Checkpoint.Entries)
+	r.replayStats.mu.Unlock()
+	if due {
 		if err := r.saveCheckpoint(); err != nil {
 			log.Printf("  ⚠ Automatic checkpoint save failed: %v", err)
+			r.recordEvent(state.SeverityWarn, "checkpoint", fmt.Sprintf("Automatic checkpoint save failed: %v", err))
 		}
 	}
 }
@@ -1876,8 +3137,8 @@ func (r *Replicator) tryAutoSaveCheckpoint() {
 func (r *Replicator) checkKeyConflict(key string) (bool, error) {
 	policy := r.cfg.Conflict.Policy
 
-	// overwrite: always write
-	if policy == "overwrite" {
+	// overwrite: always write (skip too while a full sync fills an empty target)
+	if policy == "overwrite" || (policy == "skip" && r.skipAsOverwrite.Load()) {
 		return true, nil
 	}
 
@@ -1887,9 +3148,13 @@ func (r *Replicator) checkKeyConflict(key string) (bool, error) {
 		return false, fmt.Errorf("Failed to check key existence: %w", err)
 	}
 
-	exists, ok := reply.(int64)
-	if !ok {
-		return false, fmt.Errorf("EXISTS command returned unexpected type")
+	// A nil reply ($-1/*-1, e.g. a proxy answering for a key deleted mid-check) means absent
+	var exists int64
+	if reply != nil {
+		exists, err = redisx.ToInt64(reply)
+		if err != nil {
+			return false, fmt.Errorf("EXISTS command returned unexpected reply: %w", err)
+		}
 	}
 
 	if exists == 0 {
@@ -1909,6 +3174,17 @@ func (r *Replicator) checkKeyConflict(key string) (bool, error) {
 
 // writeRDBEntry writes an RDB entry into Redis
 func (r *Replicator) writeRDBEntry(entry *RDBEntry) error {
+	// Empty collections would only result in a DEL on the target; leave the key alone
+	if entry.IsEmptyCollection() {
+		logger.Debug("  Skipping empty collection key=%s type=%d", entry.Key, entry.Type)
+		return nil
+	}
+
+	if !r.typeFilter.Allowed(entry.Type) {
+		r.countTypeFiltered(entry)
+		return nil
+	}
+
 	// Check conflicts
 	shouldWrite, err := r.checkKeyConflict(entry.Key)
 	if err != nil {
@@ -1918,14 +3194,29 @@ func (r *Replicator) writeRDBEntry(entry *RDBEntry) error {
 		return nil // skip mode simply ignores it
 	}
 
+	if entry.Dump != nil {
+		r.rdbStats.mu.Lock()
+		r.rdbStats.Commands++
+		r.rdbStats.mu.Unlock()
+
+		args := restoreArgs(entry, r.absoluteTTL())
+		_, err := r.clusterClient.Do(args[0].(string), args[1:]...)
+		if err == nil {
+			return nil
+		}
+		log.Printf("  ⚠ RESTORE failed for key=%s (%v), falling back to commands", entry.Key, err)
+		entry.Dump = nil
+	}
+
 	switch entry.Type {
 	case RDB_TYPE_STRING:
 		return r.writeString(entry)
 
-	case RDB_TYPE_HASH, RDB_TYPE_HASH_ZIPLIST, RDB_TYPE_HASH_LISTPACK:
+	case RDB_TYPE_HASH, RDB_TYPE_HASH_ZIPLIST, RDB_TYPE_HASH_LISTPACK,
+		RDB_TYPE_HASH_LISTPACK_EX, RDB_TYPE_HASH_LISTPACK_EX_PRE_GA, RDB_TYPE_HASH_METADATA, RDB_TYPE_HASH_METADATA_PRE_GA:
 		return r.writeHash(entry)
 
-	case RDB_TYPE_LIST_QUICKLIST, RDB_TYPE_LIST_QUICKLIST_2:
+	case RDB_TYPE_LIST_ZIPLIST, RDB_TYPE_LIST_QUICKLIST, RDB_TYPE_LIST_QUICKLIST_2:
 		return r.writeList(entry)
 
 	case RDB_TYPE_SET, RDB_TYPE_SET_INTSET, RDB_TYPE_SET_LISTPACK:
@@ -1937,6 +3228,13 @@ func (r *Replicator) writeRDBEntry(entry *RDBEntry) error {
 	case RDB_TYPE_STREAM_LISTPACKS, RDB_TYPE_STREAM_LISTPACKS_2, RDB_TYPE_STREAM_LISTPACKS_3:
 		return r.writeStream(entry)
 
+	case RDB_TYPE_SBF:
+		if !r.restoreBloom {
+			log.Printf("  ⊘ Skipped bloom filter key=%s (bloom filter restore disabled or unsupported by target)", entry.Key)
+			return nil
+		}
+		return r.writeSBF(entry)
+
 	default:
 		return fmt.Errorf("unsupported RDB type: %d", entry.Type)
 	}
@@ -1962,17 +3260,13 @@ func (r *Replicator) writeString(entry *RDBEntry) error {
 
 	// Apply TTL if needed
 	if entry.ExpireMs > 0 {
-		// Compute remaining TTL
-		remainingMs := entry.ExpireMs - getCurrentTimeMillis()
-		if remainingMs > 0 {
-			r.rdbStats.mu.Lock()
-			r.rdbStats.Commands++
-			r.rdbStats.mu.Unlock()
-
-			_, err := r.clusterClient.Do("PEXPIRE", entry.Key, fmt.Sprintf("%d", remainingMs))
-			if err != nil {
-				return fmt.Errorf("PEXPIRE command failed: %w", err)
-			}
+		r.rdbStats.mu.Lock()
+		r.rdbStats.Commands++
+		r.rdbStats.mu.Unlock()
+
+		args := expireArgs(entry.Key, entry.ExpireMs, r.absoluteTTL())
+		if _, err := r.clusterClient.Do(args[0].(string), args[1:]...); err != nil {
+			return fmt.Errorf("%s command failed: %w", args[0], err)
 		}
 	}
 
@@ -2017,22 +3311,28 @@ func (r *Replicator) writeHash(entry *RDBEntry) error {
 			return fmt.Errorf("HSET command failed: %w", err)
 		}
 		log.Printf("  [DEBUG] HSET command succeeded")
-	} else {
-		log.Printf("  [DEBUG] Field empty, skipping write")
+	}
+
+	// Per-field TTLs (Redis 7.4+ hash field expiration)
+	for field, expireAt := range hashVal.FieldExpiry {
+		r.rdbStats.mu.Lock()
+		r.rdbStats.Commands++
+		r.rdbStats.mu.Unlock()
+
+		if _, err := r.clusterClient.Do("HPEXPIREAT", entry.Key, strconv.FormatInt(expireAt, 10), "FIELDS", "1", field); err != nil {
+			return fmt.Errorf("HPEXPIREAT command failed (field=%s): %w", field, err)
+		}
 	}
 
 	// Apply TTL if needed
 	if entry.ExpireMs > 0 {
-		remainingMs := entry.ExpireMs - getCurrentTimeMillis()
-		if remainingMs > 0 {
-			r.rdbStats.mu.Lock()
-			r.rdbStats.Commands++
-			r.rdbStats.mu.Unlock()
+		r.rdbStats.mu.Lock()
+		r.rdbStats.Commands++
+		r.rdbStats.mu.Unlock()
 
-			_, err := r.clusterClient.Do("PEXPIRE", entry.Key, fmt.Sprintf("%d", remainingMs))
-			if err != nil {
-				return fmt.Errorf("PEXPIRE command failed: %w", err)
-			}
+		args := expireArgs(entry.Key, entry.ExpireMs, r.absoluteTTL())
+		if _, err := r.clusterClient.Do(args[0].(string), args[1:]...); err != nil {
+			return fmt.Errorf("%s command failed: %w", args[0], err)
 		}
 	}
 
@@ -2077,16 +3377,13 @@ func (r *Replicator) writeList(entry *RDBEntry) error {
 
 	// Apply TTL
 	if entry.ExpireMs > 0 {
-		remainingMs := entry.ExpireMs - getCurrentTimeMillis()
-		if remainingMs > 0 {
-			r.rdbStats.mu.Lock()
-			r.rdbStats.Commands++
-			r.rdbStats.mu.Unlock()
+		r.rdbStats.mu.Lock()
+		r.rdbStats.Commands++
+		r.rdbStats.mu.Unlock()
 
-			_, err := r.clusterClient.Do("PEXPIRE", entry.Key, fmt.Sprintf("%d", remainingMs))
-			if err != nil {
-				return fmt.Errorf("PEXPIRE command failed: %w", err)
-			}
+		args := expireArgs(entry.Key, entry.ExpireMs, r.absoluteTTL())
+		if _, err := r.clusterClient.Do(args[0].(string), args[1:]...); err != nil {
+			return fmt.Errorf("%s command failed: %w", args[0], err)
 		}
 	}
 
@@ -2112,35 +3409,19 @@ func (r *Replicator) writeSet(entry *RDBEntry) error {
 	_, _ = r.clusterClient.Do("DEL", entry.Key)
 
 	// Insert members via SADD
-	if len(setVal.Members) > 0 {
-		args := make([]interface{}, 0, 1+len(setVal.Members))
-		args = append(args, entry.Key)
-		for _, member := range setVal.Members {
-			args = append(args, member)
-		}
-
-		r.rdbStats.mu.Lock()
-		r.rdbStats.Commands++
-		r.rdbStats.mu.Unlock()
-
-		_, err := r.clusterClient.Do("SADD", args...)
-		if err != nil {
-			return fmt.Errorf("SADD command failed: %w", err)
-		}
+	if err := r.writeMembers(entry.Key, setMemberCommands(entry.Key, setVal.Members), len(setVal.Members)); err != nil {
+		return err
 	}
 
 	// Apply TTL
 	if entry.ExpireMs > 0 {
-		remainingMs := entry.ExpireMs - getCurrentTimeMillis()
-		if remainingMs > 0 {
-			r.rdbStats.mu.Lock()
-			r.rdbStats.Commands++
-			r.rdbStats.mu.Unlock()
+		r.rdbStats.mu.Lock()
+		r.rdbStats.Commands++
+		r.rdbStats.mu.Unlock()
 
-			_, err := r.clusterClient.Do("PEXPIRE", entry.Key, fmt.Sprintf("%d", remainingMs))
-			if err != nil {
-				return fmt.Errorf("PEXPIRE command failed: %w", err)
-			}
+		args := expireArgs(entry.Key, entry.ExpireMs, r.absoluteTTL())
+		if _, err := r.clusterClient.Do(args[0].(string), args[1:]...); err != nil {
+			return fmt.Errorf("%s command failed: %w", args[0], err)
 		}
 	}
 
@@ -2166,35 +3447,19 @@ func (r *Replicator) writeZSet(entry *RDBEntry) error {
 	_, _ = r.clusterClient.Do("DEL", entry.Key)
 
 	// Insert members via ZADD key score member ...
-	if len(zsetVal.Members) > 0 {
-		args := make([]interface{}, 0, 1+len(zsetVal.Members)*2)
-		args = append(args, entry.Key)
-		for _, zm := range zsetVal.Members {
-			args = append(args, fmt.Sprintf("%f", zm.Score), zm.Member)
-		}
-
-		r.rdbStats.mu.Lock()
-		r.rdbStats.Commands++
-		r.rdbStats.mu.Unlock()
-
-		_, err := r.clusterClient.Do("ZADD", args...)
-		if err != nil {
-			return fmt.Errorf("ZADD command failed: %w", err)
-		}
+	if err := r.writeMembers(entry.Key, zsetMemberCommands(entry.Key, zsetVal.Members), len(zsetVal.Members)); err != nil {
+		return err
 	}
 
 	// Apply TTL
 	if entry.ExpireMs > 0 {
-		remainingMs := entry.ExpireMs - getCurrentTimeMillis()
-		if remainingMs > 0 {
-			r.rdbStats.mu.Lock()
-			r.rdbStats.Commands++
-			r.rdbStats.mu.Unlock()
+		r.rdbStats.mu.Lock()
+		r.rdbStats.Commands++
+		r.rdbStats.mu.Unlock()
 
-			_, err := r.clusterClient.Do("PEXPIRE", entry.Key, fmt.Sprintf("%d", remainingMs))
-			if err != nil {
-				return fmt.Errorf("PEXPIRE command failed: %w", err)
-			}
+		args := expireArgs(entry.Key, entry.ExpireMs, r.absoluteTTL())
+		if _, err := r.clusterClient.Do(args[0].(string), args[1:]...); err != nil {
+			return fmt.Errorf("%s command failed: %w", args[0], err)
 		}
 	}
 
@@ -2205,6 +3470,29 @@ func (r *Replicator) writeZSet(entry *RDBEntry) error {
 	return nil
 }
 
+// writeMembers runs the chunked SADD/ZADD commands of one key. A failure
+// after the first chunk is reported as a partialWriteError.
+func (r *Replicator) writeMembers(key string, cmds [][]interface{}, intended int) error {
+	written := 0
+	for _, cmd := range cmds {
+		r.rdbStats.mu.Lock()
+		r.rdbStats.Commands++
+		r.rdbStats.mu.Unlock()
+
+		if _, err := r.clusterClient.Do(cmd[0].(string), cmd[1:]...); err != nil {
+			err = fmt.Errorf("%s command failed: %w", cmd[0], err)
+			if written == 0 {
+				return err
+			}
+			pe := &partialWriteError{Key: key, Written: written, Intended: intended, Err: err}
+			r.handlePartialWrite(pe)
+			return pe
+		}
+		written += commandMembers(cmd)
+	}
+	return nil
+}
+
 // writeStream handles stream entries (XADD for each message)
 func (r *Replicator) writeStream(entry *RDBEntry) error {
 	// Extract value
@@ -2241,16 +3529,13 @@ func (r *Replicator) writeStream(entry *RDBEntry) error {
 
 	// Apply TTL if needed
 	if entry.ExpireMs > 0 {
-		remainingMs := entry.ExpireMs - getCurrentTimeMillis()
-		if remainingMs > 0 {
-			r.rdbStats.mu.Lock()
-			r.rdbStats.Commands++
-			r.rdbStats.mu.Unlock()
+		r.rdbStats.mu.Lock()
+		r.rdbStats.Commands++
+		r.rdbStats.mu.Unlock()
 
-			_, err := r.clusterClient.Do("PEXPIRE", entry.Key, fmt.Sprintf("%d", remainingMs))
-			if err != nil {
-				return fmt.Errorf("PEXPIRE command failed: %w", err)
-			}
+		args := expireArgs(entry.Key, entry.ExpireMs, r.absoluteTTL())
+		if _, err := r.clusterClient.Do(args[0].(string), args[1:]...); err != nil {
+			return fmt.Errorf("%s command failed: %w", args[0], err)
 		}
 	}
 
@@ -2279,6 +3564,16 @@ func (r *Replicator) recordStage(name, status, message string) {
 	}
 }
 
+// recordEvent adds an entry to the dashboard timeline.
+func (r *Replicator) recordEvent(severity, eventType, message string) {
+	if r.store == nil {
+		return
+	}
+	if err := r.store.AddEvent(severity, eventType, message); err != nil {
+		log.Printf("[state] Failed to record event %s: %v", eventType, err)
+	}
+}
+
 func (r *Replicator) recordFlowStage(flowID int, status, message string) {
 	r.recordStage(fmt.Sprintf("flow:%d", flowID), status, message)
 }
@@ -2314,21 +3609,38 @@ func (r *Replicator) clearOldFlowStages() {
 }
 
 func (r *Replicator) estimateSourceKeys() {
-	if r.metrics == nil || r.mainConn == nil {
+	if r.mainConn == nil {
 		return
 	}
-	reply, err := r.mainConn.Do("INFO", "keyspace")
-	if err != nil {
-		log.Printf("[state] Failed to fetch source key count: %v", err)
-		return
+
+	// Dragonfly answers DBSIZE with the key count summed across all shards;
+	// fall back to INFO keyspace if the command is unavailable.
+	total := -1.0
+	if reply, err := r.mainConn.Do("DBSIZE"); err == nil {
+		if n, convErr := redisx.ToInt64(reply); convErr == nil {
+			total = float64(n)
+		}
 	}
-	info, err := redisx.ToString(reply)
-	if err != nil {
-		log.Printf("[state] Failed to parse source keyspace: %v", err)
-		return
+	if total < 0 {
+		reply, err := r.mainConn.Do("INFO", "keyspace")
+		if err != nil {
+			log.Printf("[state] Failed to fetch source key count: %v", err)
+			return
+		}
+		info, err := redisx.ToString(reply)
+		if err != nil {
+			log.Printf("[state] Failed to parse source keyspace: %v", err)
+			return
+		}
+		total = parseKeyspaceInfo(info)
 	}
-	total := parseKeyspaceInfo(info)
-	if total >= 0 {
+
+	r.metricsMu.Lock()
+	r.sourceKeysEstimated = total
+	r.metricsMu.Unlock()
+	log.Printf("  • Source key count (DBSIZE): %.0f", total)
+
+	if r.metrics != nil {
 		r.metrics.Set(state.MetricSourceKeysEstimated, total)
 	}
 }
@@ -2393,9 +3705,6 @@ func (r *Replicator) estimateTargetKeys() {
 }
 
 func (r *Replicator) onSnapshotKey(flowID int) {
-	if r.metrics == nil {
-		return
-	}
 	r.metricsMu.Lock()
 	if flowID >= len(r.flowKeyCounts) {
 		r.metricsMu.Unlock()
@@ -2408,6 +3717,13 @@ func (r *Replicator) onSnapshotKey(flowID int) {
 	base := r.initialTargetKeys
 	r.metricsMu.Unlock()
 
+	if total%10000 == 0 {
+		r.logSnapshotProgress(total)
+	}
+
+	if r.metrics == nil {
+		return
+	}
 	if flowCount%500 == 0 {
 		r.metrics.SetFlowImported(flowID, float64(flowCount))
 	}
@@ -2420,6 +3736,36 @@ func (r *Replicator) onSnapshotKey(flowID int) {
 	}
 }
 
+// logSnapshotProgress prints the overall import percentage and a rough ETA
+// derived from the average import rate since the snapshot started.
+func (r *Replicator) logSnapshotProgress(imported int64) {
+	r.metricsMu.Lock()
+	expected := r.sourceKeysEstimated
+	started := r.snapshotStartTime
+	r.metricsMu.Unlock()
+
+	if expected <= 0 || started.IsZero() {
+		log.Printf("📈 Snapshot progress: %d keys imported", imported)
+		return
+	}
+
+	percent := float64(imported) / expected * 100
+	if percent > 100 {
+		percent = 100
+	}
+	eta := "unknown"
+	elapsed := time.Since(started)
+	if rate := float64(imported) / elapsed.Seconds(); rate > 0 {
+		remaining := expected - float64(imported)
+		if remaining < 0 {
+			remaining = 0
+		}
+		eta = (time.Duration(remaining/rate) * time.Second).Round(time.Second).String()
+	}
+	log.Printf("📈 Snapshot progress: %d/%.0f keys (%.1f%%), elapsed %s, ETA %s",
+		imported, expected, percent, elapsed.Round(time.Second), eta)
+}
+
 func (r *Replicator) recordFlowLSN(flowID int, lsn uint64) {
 	if r.metrics == nil {
 		return
@@ -2437,8 +3783,8 @@ func (r *Replicator) recordFlowLSN(flowID int, lsn uint64) {
 		}
 	}
 	r.metricsMu.Unlock()
+	r.metrics.Set(fmt.Sprintf(state.MetricFlowLSNFormat, flowID), float64(lsn))
 	r.metrics.Set(state.MetricIncrementalLSNCurrent, float64(max))
-	r.metrics.Set(state.MetricIncrementalLSNApplied, float64(max))
 	r.metrics.Set(state.MetricIncrementalLagMs, 0)
 
 	// Update operation statistics
@@ -2455,6 +3801,8 @@ func (r *Replicator) recordFlowLSN(flowID int, lsn uint64) {
 	r.metrics.Set(state.MetricIncrementalOpsSuccess, float64(r.replayStats.ReplayedOK))
 	r.metrics.Set(state.MetricIncrementalOpsSkipped, float64(r.replayStats.Skipped))
 	r.metrics.Set(state.MetricIncrementalOpsFailed, float64(r.replayStats.Failed))
+	r.metrics.Set(state.MetricIncrementalOpsBlocked, float64(r.replayStats.Blocked))
+	r.metrics.Set(state.MetricIncrementalOpsDuplicate, float64(r.replayStats.Duplicates))
 
 	r.rdbStats.mu.Unlock()
 	r.replayStats.mu.Unlock()
@@ -2569,6 +3917,14 @@ func (r *Replicator) collectPerfMetrics() {
 
 		_, _, _, latencyP50, latencyP95, latencyP99, latencyAvg, latencyMax := fw.GetPerfMetrics()
 
+		flowID := fw.flowID
+		queued, capacity, blocked, blockedFor := fw.GetBackpressure()
+		r.metrics.Set(fmt.Sprintf(state.MetricFlowQueueLenFormat, flowID), float64(queued))
+		r.metrics.Set(fmt.Sprintf(state.MetricFlowQueueCapFormat, flowID), float64(capacity))
+		r.metrics.Set(fmt.Sprintf(state.MetricFlowEnqueueBlockedFormat, flowID), float64(blocked))
+		r.metrics.Set(fmt.Sprintf(state.MetricFlowEnqueueBlockedMsFormat, flowID), float64(blockedFor.Milliseconds()))
+		r.metrics.Set(fmt.Sprintf(state.MetricFlowBytesWrittenFormat, flowID), float64(fw.GetBytesWritten()))
+
 		totalLatencyP50 += latencyP50
 		totalLatencyP95 += latencyP95
 		totalLatencyP99 += latencyP99
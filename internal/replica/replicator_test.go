@@ -0,0 +1,411 @@
+package replica
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"df2redis/internal/config"
+	"df2redis/internal/redisx"
+)
+
+// stubTarget is a single-node target that records the commands it receives
+// (PING aside) and answers each with reply(args), a raw RESP string.
+type stubTarget struct {
+	mu    sync.Mutex
+	cmds  [][]string
+	reply func(args []string) string
+}
+
+func (s *stubTarget) received() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.cmds...)
+}
+
+// newStubTarget starts a stubTarget and connects a client to it; reply nil
+// answers +OK to everything.
+func newStubTarget(t *testing.T, reply func(args []string) string) (*stubTarget, *redisx.ClusterClient) {
+	t.Helper()
+	if reply == nil {
+		reply = func([]string) string { return "+OK\r\n" }
+	}
+	st := &stubTarget{reply: reply}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go st.serve(conn)
+		}
+	}()
+	cc, err := redisx.DialStandalone(context.Background(), ln.Addr().String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return st, cc
+}
+
+func (s *stubTarget) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		req, err := redisx.DecodeReply(r)
+		if err != nil {
+			return
+		}
+		raw, _ := req.([]interface{})
+		args := make([]string, len(raw))
+		for i, a := range raw {
+			args[i], _ = redisx.ToString(a)
+		}
+		if len(args) > 0 && strings.EqualFold(args[0], "PING") {
+			conn.Write([]byte("+PONG\r\n"))
+			continue
+		}
+		s.mu.Lock()
+		s.cmds = append(s.cmds, args)
+		s.mu.Unlock()
+		if _, err := conn.Write([]byte(s.reply(args))); err != nil {
+			return
+		}
+	}
+}
+
+// TestReplayCheckpointConcurrency hammers journal replay bookkeeping and
+// checkpoint saves from several goroutines; run with -race.
+func TestReplayCheckpointConcurrency(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Checkpoint.Enabled = true
+	cfg.Checkpoint.Path = filepath.Join(t.TempDir(), "checkpoint.json")
+	r := NewReplicator(cfg)
+	defer r.cancel()
+	r.masterInfo = MasterInfo{ReplID: "repl", SyncID: "SYNC1"}
+
+	const flows, perFlow = 4, 200
+	var wg sync.WaitGroup
+	for flowID := 0; flowID < flows; flowID++ {
+		wg.Add(1)
+		go func(flowID int) {
+			defer wg.Done()
+			for i := 1; i <= perFlow; i++ {
+				if err := r.replayCommand(flowID, &JournalEntry{Opcode: OpLSN, LSN: uint64(i)}); err != nil {
+					t.Errorf("replay LSN: %v", err)
+					return
+				}
+				if !r.alreadyApplied(flowID, uint64(i)) {
+					r.markApplied(flowID, uint64(i))
+				}
+				r.tryAutoSaveCheckpoint()
+				r.logReplayStats(map[int]int{flowID: i})
+			}
+		}(flowID)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < perFlow; i++ {
+			if err := r.saveCheckpoint(); err != nil {
+				t.Errorf("saveCheckpoint: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if err := r.saveCheckpoint(); err != nil {
+		t.Fatalf("final saveCheckpoint: %v", err)
+	}
+	cp, err := r.checkpointMgr.Load()
+	if err != nil {
+		t.Fatalf("load checkpoint: %v", err)
+	}
+	for flowID := 0; flowID < flows; flowID++ {
+		if cp.FlowLSNs[flowID] != perFlow {
+			t.Errorf("FLOW-%d checkpoint LSN = %d, want %d", flowID, cp.FlowLSNs[flowID], perFlow)
+		}
+	}
+}
+
+func TestParseReplicationRole(t *testing.T) {
+	cases := map[string]string{
+		"# Replication\r\nrole:master\r\nconnected_slaves:1\r\n":    "master",
+		"# Replication\r\nrole:replica\r\nmaster_host:10.0.0.1\r\n": "replica",
+		"role:slave": "slave",
+		"":           "",
+	}
+	for info, want := range cases {
+		if got := parseReplicationRole(info); got != want {
+			t.Errorf("parseReplicationRole(%q) = %q, want %q", info, got, want)
+		}
+	}
+}
+
+func TestFormatArgsEscapesBinary(t *testing.T) {
+	if got, want := quoteArg("a\x00b\x1b[2J\xff"), `"a\x00b\x1b[2J\xff"`; got != want {
+		t.Errorf("quoteArg = %s, want %s", got, want)
+	}
+	// 49 ASCII bytes followed by a 3-byte rune must not be cut mid-rune
+	long := strings.Repeat("x", 49) + "€€"
+	if got, want := quoteArg(long), `"`+strings.Repeat("x", 49)+`"...`; got != want {
+		t.Errorf("quoteArg(long) = %s, want %s", got, want)
+	}
+	if got, want := formatArgs([]string{"user:1", "secret"}, true, " "), `"user:1" <6 bytes>`; got != want {
+		t.Errorf("formatArgs(hide) = %s, want %s", got, want)
+	}
+	if got, want := formatValueArgs([]string{"secret", "\x01"}, true), `<6 bytes> <1 bytes>`; got != want {
+		t.Errorf("formatValueArgs(hide) = %s, want %s", got, want)
+	}
+	if got, want := formatValueArgs([]string{"v", "\x01"}, false), `"v" "\x01"`; got != want {
+		t.Errorf("formatValueArgs = %s, want %s", got, want)
+	}
+}
+
+// TestResumeDropsOverlappingWindow replays a journal window the source sends
+// again after a resume: stamps follow the source's OpLSN values, so the
+// commands that were applied before are recognized and dropped.
+func TestResumeDropsOverlappingWindow(t *testing.T) {
+	window := func() []*JournalEntry {
+		return []*JournalEntry{
+			{Opcode: OpSelect, DbIndex: 0},
+			{Opcode: OpCommand, Command: "SET", Args: []string{"a", "1"}},
+			{Opcode: OpLSN, LSN: 101},
+			{Opcode: OpCommand, Command: "SET", Args: []string{"b", "1"}},
+			{Opcode: OpPing},
+			{Opcode: OpLSN, LSN: 102},
+			{Opcode: OpCommand, Command: "SET", Args: []string{"c", "1"}},
+			{Opcode: OpLSN, LSN: 103},
+		}
+	}
+
+	target, cc := newStubTarget(t, nil)
+	r := &Replicator{cfg: &config.Config{}, clusterClient: cc}
+
+	// First run: everything up to b was applied when the process stopped
+	pos := &FlowACKState{markLSN: 100}
+	for _, entry := range window()[:4] {
+		pos.advance(entry)
+		if err := r.replayCommand(0, entry); err != nil {
+			t.Fatalf("replay %v: %v", entry, err)
+		}
+	}
+	if got := r.appliedLSN(0); got != 102 {
+		t.Fatalf("applied LSN after first run = %d, want 102", got)
+	}
+
+	// Resume: the source starts over at an older position and resends a and b
+	pos = &FlowACKState{markLSN: 100}
+	for _, entry := range window() {
+		pos.advance(entry)
+		if entry.Opcode == OpPing {
+			continue
+		}
+		if err := r.replayCommand(0, entry); err != nil {
+			t.Fatalf("replay %v: %v", entry, err)
+		}
+	}
+
+	var keys []string
+	for _, cmd := range target.received() {
+		keys = append(keys, cmd[1])
+	}
+	if got := strings.Join(keys, ","); got != "a,b,c" {
+		t.Fatalf("target received writes for %s, want a,b,c", got)
+	}
+	if r.replayStats.Duplicates != 2 {
+		t.Fatalf("Duplicates = %d, want 2", r.replayStats.Duplicates)
+	}
+	if got := r.appliedLSN(0); got != 103 {
+		t.Fatalf("applied LSN = %d, want 103", got)
+	}
+}
+
+func TestFailedWriteDoesNotAdvanceAppliedLSN(t *testing.T) {
+	_, cc := newStubTarget(t, func([]string) string { return "-ERR wrong number of arguments\r\n" })
+	r := &Replicator{cfg: &config.Config{}, clusterClient: cc}
+
+	entry := &JournalEntry{Opcode: OpCommand, Command: "SET", Args: []string{"k"}, LSN: 7}
+	if err := r.replayCommand(0, entry); err == nil {
+		t.Fatal("replay succeeded against a target that rejected the write")
+	}
+	if got := r.appliedLSN(0); got != 0 {
+		t.Fatalf("applied LSN = %d after a failed write, want 0", got)
+	}
+}
+
+func TestAppliedAckCountsEveryOpcode(t *testing.T) {
+	_, cc := newStubTarget(t, func(cmd []string) string {
+		if cmd[1] == "c" {
+			return "-ERR wrong number of arguments\r\n"
+		}
+		return "+OK\r\n"
+	})
+	r := &Replicator{cfg: &config.Config{}, clusterClient: cc}
+
+	set := func(key string) *JournalEntry {
+		return &JournalEntry{Opcode: OpCommand, Command: "SET", Args: []string{key, "v"}}
+	}
+	pos := &FlowACKState{}
+	for _, entry := range []*JournalEntry{
+		{Opcode: OpSelect, DbIndex: 0},
+		set("a"),
+		{Opcode: OpLSN, LSN: 50},
+		{Opcode: OpSelect, DbIndex: 0},
+		set("b"),
+		set("c"),
+	} {
+		pos.advance(entry)
+		r.replayCommand(0, entry)
+	}
+
+	// b is the second command after LSN 50 for the ACK counter, the first for its stamp
+	if got := r.appliedLSN(0); got != 51 {
+		t.Fatalf("applied LSN = %d, want 51", got)
+	}
+	if got := r.appliedAck(0); got != 52 {
+		t.Fatalf("applied ACK = %d, want 52", got)
+	}
+	if pos.lastCmdAck != 53 {
+		t.Fatalf("last command ACK = %d, want 53", pos.lastCmdAck)
+	}
+}
+
+func TestAutoCheckpointEveryEntries(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Checkpoint.Enabled = true
+	cfg.Checkpoint.Interval = 3600
+	cfg.Checkpoint.Entries = 3
+	cfg.Checkpoint.Path = filepath.Join(t.TempDir(), "checkpoint.json")
+	r := NewReplicator(cfg)
+	defer r.cancel()
+	r.masterInfo = MasterInfo{ReplID: "repl", SyncID: "SYNC1"}
+	r.lastCheckpointTime = time.Now()
+
+	for i := 1; i <= 2; i++ {
+		r.replayStats.FlowLSNs = map[int]uint64{0: uint64(i)}
+		r.tryAutoSaveCheckpoint()
+	}
+	if _, err := os.Stat(cfg.Checkpoint.Path); !os.IsNotExist(err) {
+		t.Fatalf("checkpoint saved before 3 entries (stat err=%v)", err)
+	}
+
+	r.replayStats.FlowLSNs = map[int]uint64{0: 3}
+	r.tryAutoSaveCheckpoint()
+	cp, err := r.checkpointMgr.Load()
+	if err != nil {
+		t.Fatalf("no checkpoint after 3 entries: %v", err)
+	}
+	if cp.FlowLSNs[0] != 3 {
+		t.Fatalf("checkpoint FLOW-0 LSN = %d, want 3", cp.FlowLSNs[0])
+	}
+	if r.entriesSinceSave != 0 {
+		t.Fatalf("entriesSinceSave = %d after a save, want 0", r.entriesSinceSave)
+	}
+}
+
+func TestResumeFromSavedCheckpoint(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Checkpoint.Enabled = true
+	cfg.Checkpoint.Path = filepath.Join(t.TempDir(), "checkpoint.json")
+	saved := NewReplicator(cfg)
+	defer saved.cancel()
+	saved.masterInfo = MasterInfo{ReplID: "repl", SyncID: "SYNC1"}
+	saved.flows = make([]FlowInfo, 2)
+	saved.replayStats.FlowLSNs = map[int]uint64{0: 40, 1: 70}
+	if err := saved.saveCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReplicator(cfg)
+	defer r.cancel()
+	r.loadResumeCheckpoint()
+	if r.resumeLSNs[0] != 40 || r.resumeLSNs[1] != 70 {
+		t.Fatalf("resume LSNs = %v, want the checkpoint's", r.resumeLSNs)
+	}
+	if !r.alreadyApplied(1, 70) || r.alreadyApplied(1, 71) {
+		t.Fatal("checkpointed LSN is not the duplicate low-water mark")
+	}
+
+	// The master answered FULL for FLOW-1: its new journal must not be dropped
+	r.forgetResumeLSN(1)
+	if _, ok := r.resumeLSNs[1]; ok || r.alreadyApplied(1, 70) {
+		t.Fatalf("FLOW-1 still resumes after a full sync (resume LSNs %v)", r.resumeLSNs)
+	}
+	if !r.alreadyApplied(0, 40) {
+		t.Fatal("forgetting FLOW-1 cleared FLOW-0")
+	}
+
+	// --lsn wins over the checkpoint
+	cfg.Checkpoint.StartLSNs = map[int]uint64{0: 5, 1: 6}
+	r = NewReplicator(cfg)
+	defer r.cancel()
+	r.loadResumeCheckpoint()
+	if r.resumeLSNs[0] != 5 || r.resumeFrom != nil {
+		t.Fatalf("resume LSNs = %v, want --lsn", r.resumeLSNs)
+	}
+}
+
+// TestHandshakeGuardInterruptsFlowConns checks that the handshake deadline
+// unblocks a FLOW connection waiting for a reply and that release clears it.
+func TestHandshakeGuardInterruptsFlowConns(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close() // never replies, like a hung master
+		}
+	}()
+	dial := func() *redisx.Client {
+		c, err := redisx.Dial(context.Background(), redisx.Config{Addr: ln.Addr().String(), SkipPing: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return c
+	}
+
+	guard := &handshakeGuard{}
+	guard.add(dial())
+	flow := dial()
+	guard.add(flow)
+
+	start := time.Now()
+	time.AfterFunc(50*time.Millisecond, guard.interrupt)
+	if _, err := flow.Do("DFLY", "FLOW"); err == nil {
+		t.Fatal("DFLY FLOW succeeded against a master that never replies")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("DFLY FLOW blocked %v past the handshake deadline", elapsed)
+	}
+
+	// A connection opened after the deadline is interrupted as it is added
+	guard.add(dial())
+
+	if err := guard.release(); err != nil {
+		t.Fatal(err)
+	}
+	guard.interrupt() // a deadline firing after release must not touch the connections
+	if !guard.released || len(guard.conns) != 3 {
+		t.Fatalf("guard released=%v with %d connections", guard.released, len(guard.conns))
+	}
+}
@@ -0,0 +1,119 @@
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RunResult is the final outcome of a Run, written as JSON to
+// <stateDir>/replicate.result.json (migrate.result.json for snapshot-only
+// runs) so CI and orchestration can act on it without parsing logs.
+type RunResult struct {
+	Status          string    `json:"status"` // completed, stopped (signal/cancel) or failed
+	Phase           string    `json:"phase"`  // replicator state when the run ended
+	Error           string    `json:"error,omitempty"`
+	StartedAt       time.Time `json:"startedAt"`
+	FinishedAt      time.Time `json:"finishedAt"`
+	DurationSeconds float64   `json:"durationSeconds"`
+
+	Snapshot RunSnapshotTotals `json:"snapshot"`
+	Journal  RunJournalTotals  `json:"journal"`
+
+	FlowLSNs map[int]uint64 `json:"flowLsns"` // last replayed LSN per FLOW
+}
+
+// RunSnapshotTotals counts what the full sync wrote.
+type RunSnapshotTotals struct {
+	Keys             int64 `json:"keys"`
+	Commands         int64 `json:"commands"`
+	InlineJournalOps int64 `json:"inlineJournalOps"`
+	PartialKeys      int64 `json:"partialKeys"`
+	OversizeKeys     int64 `json:"oversizeKeys"`
+	TypeFiltered     int64 `json:"typeFiltered"`
+}
+
+// RunJournalTotals counts what happened to journal commands.
+type RunJournalTotals struct {
+	Total      int64 `json:"total"`
+	ReplayedOK int64 `json:"replayedOk"`
+	Skipped    int64 `json:"skipped"`
+	Failed     int64 `json:"failed"`
+	Blocked    int64 `json:"blocked"`
+	Duplicates int64 `json:"duplicates"`
+}
+
+// resultPath returns where Run writes its RunResult.
+func (r *Replicator) resultPath() string {
+	name := "replicate.result.json"
+	if r.cfg.Migrate.SnapshotOnly {
+		name = "migrate.result.json"
+	}
+	return filepath.Join(r.cfg.ResolveStateDir(), name)
+}
+
+// buildRunResult snapshots the counters once the replicator has stopped.
+func (r *Replicator) buildRunResult(startedAt time.Time, runErr error) *RunResult {
+	now := time.Now()
+	res := &RunResult{
+		Status:          "completed",
+		Phase:           r.GetState().String(),
+		StartedAt:       startedAt,
+		FinishedAt:      now,
+		DurationSeconds: now.Sub(startedAt).Seconds(),
+		FlowLSNs:        make(map[int]uint64),
+	}
+	switch {
+	case errors.Is(runErr, context.Canceled):
+		res.Status = "stopped"
+	case runErr != nil:
+		res.Status = "failed"
+		res.Error = runErr.Error()
+	}
+
+	r.rdbStats.mu.Lock()
+	res.Snapshot = RunSnapshotTotals{
+		Keys:             r.rdbStats.Keys,
+		Commands:         r.rdbStats.Commands,
+		InlineJournalOps: r.rdbStats.InlineJournalOps,
+		PartialKeys:      r.rdbStats.PartialKeys,
+		OversizeKeys:     r.rdbStats.OversizeKeys,
+		TypeFiltered:     r.rdbStats.TypeFiltered,
+	}
+	r.rdbStats.mu.Unlock()
+
+	r.replayStats.mu.Lock()
+	res.Journal = RunJournalTotals{
+		Total:      r.replayStats.TotalCommands,
+		ReplayedOK: r.replayStats.ReplayedOK,
+		Skipped:    r.replayStats.Skipped,
+		Failed:     r.replayStats.Failed,
+		Blocked:    r.replayStats.Blocked,
+		Duplicates: r.replayStats.Duplicates,
+	}
+	for flowID, lsn := range r.replayStats.FlowLSNs {
+		res.FlowLSNs[flowID] = lsn
+	}
+	r.replayStats.mu.Unlock()
+	return res
+}
+
+// writeRunResult writes res atomically, so readers never see a partial file.
+func writeRunResult(path string, res *RunResult) error {
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create result dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write result file: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,62 @@
+package replica
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"df2redis/internal/config"
+)
+
+func TestRunResultFile(t *testing.T) {
+	r := NewReplicator(&config.Config{})
+	defer r.cancel()
+	r.state = StateStableSync
+	r.rdbStats.Keys = 10
+	r.replayStats.TotalCommands = 5
+	r.replayStats.ReplayedOK = 4
+	r.replayStats.Failed = 1
+	r.replayStats.FlowLSNs = map[int]uint64{0: 7, 1: 9}
+
+	started := time.Now().Add(-time.Minute)
+	cases := []struct {
+		err    error
+		status string
+	}{
+		{nil, "completed"},
+		{fmt.Errorf("stop: %w", context.Canceled), "stopped"},
+		{&RunError{State: StateStableSync, Err: errors.New("boom")}, "failed"},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(t.TempDir(), "state", "replicate.result.json")
+		if err := writeRunResult(path, r.buildRunResult(started, tc.err)); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got RunResult
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != tc.status {
+			t.Errorf("err=%v: status = %q, want %q", tc.err, got.Status, tc.status)
+		}
+		if (got.Error != "") != (tc.status == "failed") {
+			t.Errorf("err=%v: error field = %q", tc.err, got.Error)
+		}
+		if got.Phase != StateStableSync.String() || got.Snapshot.Keys != 10 ||
+			got.Journal.ReplayedOK != 4 || got.Journal.Failed != 1 || got.FlowLSNs[1] != 9 {
+			t.Errorf("unexpected totals: %+v", got)
+		}
+		if got.DurationSeconds < 59 {
+			t.Errorf("duration = %v", got.DurationSeconds)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package replica
+
+import (
+	"sync"
+	"time"
+)
+
+// retryQueue holds journal entries that failed on a transient target error
+// (connection loss, CLUSTERDOWN, LOADING, ...) during stable sync, in stream
+// order. While it is non-empty every new command is appended behind it so a
+// key never sees its writes applied out of order, and checkpoints stop short
+// of the oldest queued entry of each FLOW.
+type retryQueue struct {
+	mu       sync.Mutex
+	items    []retryItem
+	capacity int
+
+	attempt  int       // consecutive failed drain attempts
+	nextTry  time.Time // backoff deadline for the next drain
+	draining bool      // set while entries are re-applied, so failures aren't re-queued
+}
+
+type retryItem struct {
+	flowID int
+	entry  *JournalEntry
+}
+
+const (
+	retryBaseDelay = 200 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+)
+
+func newRetryQueue(capacity int) *retryQueue {
+	return &retryQueue{capacity: capacity}
+}
+
+// Len returns the number of queued entries (0 for a nil queue).
+func (q *retryQueue) Len() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+// Full reports whether the queue reached its capacity.
+func (q *retryQueue) Full() bool {
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items) >= q.capacity
+}
+
+// push appends an entry; the first failure arms the backoff.
+func (q *retryQueue) push(flowID int, entry *JournalEntry) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		q.attempt = 1
+		q.nextTry = time.Now().Add(retryBaseDelay)
+	}
+	q.items = append(q.items, retryItem{flowID: flowID, entry: entry})
+}
+
+// due reports whether the backoff for the next drain has elapsed.
+func (q *retryQueue) due() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items) > 0 && !time.Now().Before(q.nextTry)
+}
+
+// head returns the oldest queued entry.
+func (q *retryQueue) head() (retryItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 {
+		return retryItem{}, false
+	}
+	return q.items[0], true
+}
+
+// pop removes the oldest entry after it was applied and resets the backoff.
+func (q *retryQueue) pop() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.items[0] = retryItem{}
+	q.items = q.items[1:]
+	q.attempt = 0
+}
+
+// backoff schedules the next drain after a failed attempt.
+func (q *retryQueue) backoff() time.Duration {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delay := retryBaseDelay << min(q.attempt, 6)
+	if delay > retryMaxDelay {
+		delay = retryMaxDelay
+	}
+	q.attempt++
+	q.nextTry = time.Now().Add(delay)
+	return delay
+}
+
+// oldestLSNs returns the LSN of the oldest queued entry per FLOW; checkpoints
+// must not move past it.
+func (q *retryQueue) oldestLSNs() map[int]uint64 {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	oldest := make(map[int]uint64)
+	for _, it := range q.items {
+		if _, ok := oldest[it.flowID]; !ok && it.entry.LSN > 0 {
+			oldest[it.flowID] = it.entry.LSN
+		}
+	}
+	return oldest
+}
+
+func (q *retryQueue) isDraining() bool {
+	if q == nil {
+		return false
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.draining
+}
+
+func (q *retryQueue) setDraining(on bool) {
+	q.mu.Lock()
+	q.draining = on
+	q.mu.Unlock()
+}
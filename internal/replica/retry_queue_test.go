@@ -0,0 +1,139 @@
+package replica
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"df2redis/internal/config"
+)
+
+func TestRetryQueueKeepsStreamOrder(t *testing.T) {
+	q := newRetryQueue(10)
+	for i, flowID := range []int{1, 0, 1} {
+		q.push(flowID, &JournalEntry{Opcode: OpCommand, LSN: uint64(i + 1)})
+	}
+	for want := uint64(1); want <= 3; want++ {
+		it, ok := q.head()
+		if !ok || it.entry.LSN != want {
+			t.Fatalf("head = %+v, %v; want LSN %d", it.entry, ok, want)
+		}
+		q.pop()
+	}
+	if _, ok := q.head(); ok || q.Len() != 0 {
+		t.Fatalf("queue not empty after popping everything (Len=%d)", q.Len())
+	}
+}
+
+func TestRetryQueueBackoff(t *testing.T) {
+	q := newRetryQueue(10)
+	q.push(0, &JournalEntry{LSN: 1})
+	if q.due() {
+		t.Fatal("queue due immediately after the first failure")
+	}
+
+	want := []time.Duration{400 * time.Millisecond, 800 * time.Millisecond, 1600 * time.Millisecond,
+		3200 * time.Millisecond, 6400 * time.Millisecond, retryMaxDelay, retryMaxDelay}
+	for i, w := range want {
+		if got := q.backoff(); got != w {
+			t.Fatalf("backoff #%d = %v, want %v", i+1, got, w)
+		}
+	}
+
+	// A successful apply resets the backoff
+	q.pop()
+	q.push(0, &JournalEntry{LSN: 2})
+	if got := q.backoff(); got != 400*time.Millisecond {
+		t.Fatalf("backoff after a reset = %v, want 400ms", got)
+	}
+}
+
+func TestRetryQueueOldestLSNs(t *testing.T) {
+	var nilQueue *retryQueue
+	if got := nilQueue.oldestLSNs(); got != nil {
+		t.Fatalf("nil queue oldestLSNs = %v", got)
+	}
+
+	q := newRetryQueue(10)
+	q.push(0, &JournalEntry{LSN: 0}) // inline journal entry, no position
+	q.push(0, &JournalEntry{LSN: 50})
+	q.push(1, &JournalEntry{LSN: 7})
+	q.push(0, &JournalEntry{LSN: 51})
+	got := q.oldestLSNs()
+	if len(got) != 2 || got[0] != 50 || got[1] != 7 {
+		t.Fatalf("oldestLSNs = %v, want map[0:50 1:7]", got)
+	}
+}
+
+// TestCheckpointStopsBeforeQueuedWrite checks the clamp: a checkpoint never
+// covers a write still waiting in the retry queue.
+func TestCheckpointStopsBeforeQueuedWrite(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Checkpoint.Enabled = true
+	cfg.Checkpoint.Path = filepath.Join(t.TempDir(), "checkpoint.json")
+	r := NewReplicator(cfg)
+	defer r.cancel()
+	r.masterInfo = MasterInfo{ReplID: "repl", SyncID: "SYNC1"}
+	r.replayStats.FlowLSNs = map[int]uint64{0: 60, 1: 30}
+	r.retryQ = newRetryQueue(10)
+	r.retryQ.push(0, &JournalEntry{Opcode: OpCommand, LSN: 50})
+
+	if err := r.saveCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	cp, err := r.checkpointMgr.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.FlowLSNs[0] != 49 || cp.FlowLSNs[1] != 30 {
+		t.Fatalf("checkpoint FlowLSNs = %v, want map[0:49 1:30]", cp.FlowLSNs)
+	}
+}
+
+// TestRetryQueueStallsWhenFull fills the queue while the target is loading,
+// then drains it the way the replay loop does once the queue is full: the
+// drain blocks until every write landed, and only then are they applied.
+func TestRetryQueueStallsWhenFull(t *testing.T) {
+	var loading atomic.Bool
+	loading.Store(true)
+	target, cc := newStubTarget(t, func([]string) string {
+		if loading.Load() {
+			return "-LOADING Redis is loading the dataset in memory\r\n"
+		}
+		return "+OK\r\n"
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &Replicator{cfg: &config.Config{}, clusterClient: cc, ctx: ctx, cancel: cancel}
+	r.retryQ = newRetryQueue(2)
+
+	for i, key := range []string{"a", "b"} {
+		entry := &JournalEntry{Opcode: OpCommand, Command: "SET", Args: []string{key, "1"}, LSN: uint64(i + 1)}
+		if err := r.replayCommand(0, entry); err != errQueuedForRetry {
+			t.Fatalf("replay %s = %v, want errQueuedForRetry", key, err)
+		}
+	}
+	if !r.retryQ.Full() {
+		t.Fatal("queue not full after two queued writes")
+	}
+	if got := r.appliedLSN(0); got != 0 {
+		t.Fatalf("applied LSN = %d while writes are queued, want 0", got)
+	}
+
+	time.AfterFunc(300*time.Millisecond, func() { loading.Store(false) })
+	r.drainRetryQueue(r.retryQ.Full())
+
+	if n := r.retryQ.Len(); n != 0 {
+		t.Fatalf("%d entries still queued after a blocking drain", n)
+	}
+	if got := r.appliedLSN(0); got != 2 {
+		t.Fatalf("applied LSN after the drain = %d, want 2", got)
+	}
+	cmds := target.received()
+	last := cmds[len(cmds)-2:]
+	if last[0][1] != "a" || last[1][1] != "b" {
+		t.Fatalf("writes landed as %v, want a then b", last)
+	}
+}
@@ -0,0 +1,83 @@
+package replica
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"df2redis/internal/config"
+	"df2redis/internal/state"
+	"df2redis/internal/tracing"
+)
+
+// RunError reports a replication failure together with the phase it happened in.
+type RunError struct {
+	State ReplicaState // replicator state when the failure surfaced
+	Err   error
+}
+
+func (e *RunError) Error() string {
+	return fmt.Sprintf("replication failed during %s: %v", e.State, e.Err)
+}
+
+func (e *RunError) Unwrap() error {
+	return e.Err
+}
+
+// Run executes full sync followed by incremental replication until ctx is
+// cancelled or replication fails. It never exits the process; cancellation
+// triggers the same graceful shutdown as Ctrl+C and returns ctx.Err().
+// store may be nil when dashboard metrics aren't needed. The outcome is
+// recorded in <stateDir>/replicate.result.json (see RunResult).
+func Run(ctx context.Context, cfg *config.Config, store *state.Store) (err error) {
+	defer tracing.Init(cfg.Observability.OTLPEndpoint, cfg.Observability.ServiceName)()
+
+	startedAt := time.Now()
+	r := NewReplicator(cfg)
+	if store != nil {
+		r.AttachStateStore(store)
+	}
+	defer func() {
+		path := r.resultPath()
+		if werr := writeRunResult(path, r.buildRunResult(startedAt, err)); werr != nil {
+			log.Printf("⚠ Failed to write run result %s: %v", path, werr)
+		} else {
+			log.Printf("📝 Run result written to %s", path)
+		}
+	}()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- r.Start()
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			if cfg.Migrate.SnapshotOnly {
+				// Dragonfly keeps streaming after STARTSTABLE: hang up with
+				// FIN rather than leaving the sockets to the process exit
+				r.Stop()
+				r.recordPipelineStatus("completed", "Migration (Snapshot Only) finished successfully")
+			}
+			return nil
+		}
+		runErr := &RunError{State: r.GetState(), Err: err}
+		r.recordEvent(state.SeverityError, "fatal", runErr.Error())
+		// Close connections with FIN rather than RST so Dragonfly cleans up normally
+		r.Stop()
+		return runErr
+	case <-ctx.Done():
+		r.Stop()
+		return ctx.Err()
+	}
+}
+
+// RunMigration performs a snapshot-only migration: full sync, then return.
+// cfg is copied so the caller's SnapshotOnly setting is left untouched.
+func RunMigration(ctx context.Context, cfg *config.Config, store *state.Store) error {
+	migrateCfg := *cfg
+	migrateCfg.Migrate.SnapshotOnly = true
+	return Run(ctx, &migrateCfg, store)
+}
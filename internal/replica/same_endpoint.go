@@ -0,0 +1,56 @@
+package replica
+
+import (
+	"fmt"
+	"strings"
+
+	"df2redis/internal/config"
+	"df2redis/internal/redisx"
+)
+
+// checkSameEndpoint refuses a target whose masters include the source, now
+// that the cluster topology is known: by address (a seed can differ from
+// the node addresses it announces) and by INFO server run_id, which also
+// catches two names for one host. migrate.allowSameEndpoint skips it.
+func (r *Replicator) checkSameEndpoint(name string, cc *redisx.ClusterClient) error {
+	if r.cfg.Migrate.AllowSameEndpoint {
+		return nil
+	}
+	for _, sr := range cc.SlotRanges() {
+		if sr.Addr != "" && config.SameEndpoint(r.cfg.Source.Addr, sr.Addr) {
+			return fmt.Errorf("%s master %s is the source %s (set migrate.allowSameEndpoint to override)", name, sr.Addr, r.cfg.Source.Addr)
+		}
+	}
+
+	if r.mainConn == nil {
+		return nil // snapshot file loads never connect to the source
+	}
+	info, err := r.mainConn.Info("server")
+	if err != nil {
+		return nil
+	}
+	sourceID := infoField(info, "run_id")
+	if sourceID == "" {
+		return nil
+	}
+	// Best effort: masters that can't be asked were still compared by address
+	var same error
+	cc.ForEachMaster(func(client *redisx.Client) error {
+		info, err := client.Info("server")
+		if err == nil && infoField(info, "run_id") == sourceID {
+			same = fmt.Errorf("%s master %s has the source's run_id %s: it is the same server (set migrate.allowSameEndpoint to override)", name, client.Addr(), sourceID)
+		}
+		return same
+	})
+	return same
+}
+
+// infoField returns the value of field in an INFO reply, or "".
+func infoField(info, field string) string {
+	for _, line := range strings.Split(info, "\n") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(line), field+":"); ok {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,125 @@
+package replica
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// skippedKeysMaxBytes bounds state/skipped_keys.log; past it the file is
+// rotated to skipped_keys.log.1 (one generation is kept), so a snapshot full
+// of expired keys cannot fill the disk.
+const skippedKeysMaxBytes = 64 << 20
+
+// Reasons recorded in skipped_keys.log.
+const (
+	skipReasonExpired  = "expired"
+	skipReasonBloom    = "bloom-unsupported"
+	skipReasonFiltered = "type-filtered"
+	skipReasonOversize = "oversize"
+)
+
+// skippedKeyLog records every snapshot key the replicator drops instead of
+// writing, one line per key:
+//
+//	<RFC3339 time> <reason> db=<n> type=<type> <quoted key>
+//
+// so "N keys skipped" turns into a list that can be handled by hand after
+// the migration. The file is opened on the first skipped key; a nil log
+// records nothing.
+type skippedKeyLog struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64
+	f        *os.File
+	w        *bufio.Writer
+	size     int64
+	failed   bool
+	written  int64
+}
+
+func newSkippedKeyLog(path string) *skippedKeyLog {
+	if path == "" {
+		return nil
+	}
+	return &skippedKeyLog{path: path, maxBytes: skippedKeysMaxBytes}
+}
+
+// Record appends entry with the reason it was skipped.
+func (l *skippedKeyLog) Record(entry *RDBEntry, reason string) {
+	if l == nil {
+		return
+	}
+	line := time.Now().Format(time.RFC3339) + " " + reason +
+		" db=" + strconv.Itoa(entry.DbIndex) + " type=" + rdbTypeFamily(entry.Type) +
+		" " + strconv.Quote(entry.Key) + "\n"
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failed {
+		return
+	}
+	if l.f == nil || l.size+int64(len(line)) > l.maxBytes {
+		if err := l.rotate(); err != nil {
+			log.Printf("  ⚠ Cannot write skipped keys to %s: %v", l.path, err)
+			l.failed = true
+			return
+		}
+	}
+	n, _ := l.w.WriteString(line)
+	l.size += int64(n)
+	l.written++
+}
+
+// rotate opens the log, first moving a full file aside. Callers hold l.mu.
+func (l *skippedKeyLog) rotate() error {
+	if l.f != nil {
+		l.w.Flush()
+		l.f.Close()
+		l.f = nil
+		if err := os.Rename(l.path, l.path+".1"); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.w, l.size = f, bufio.NewWriterSize(f, 64*1024), info.Size()
+	return nil
+}
+
+// Close flushes the log and reports where the skipped keys went.
+func (l *skippedKeyLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	log.Printf("  📝 %d skipped keys listed in %s", l.written, l.path)
+	err := l.w.Flush()
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+	if err != nil {
+		return fmt.Errorf("close skipped keys log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package replica
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSkippedKeyLogRecordsAndRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "skipped_keys.log")
+	l := newSkippedKeyLog(path)
+	l.maxBytes = 200
+
+	for i := 0; i < 5; i++ {
+		l.Record(&RDBEntry{Key: "user:1", Type: RDB_TYPE_HASH, DbIndex: 2}, skipReasonFiltered)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cur, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := os.ReadFile(path + ".1")
+	if err != nil {
+		t.Fatalf("expected a rotated file: %v", err)
+	}
+	if len(cur) > 200 || len(old) > 200 {
+		t.Fatalf("files exceed the bound: %d and %d bytes", len(cur), len(old))
+	}
+	line := strings.SplitN(string(cur), "\n", 2)[0]
+	if !strings.HasSuffix(line, ` type-filtered db=2 type=hash "user:1"`) {
+		t.Fatalf("unexpected line %q", line)
+	}
+}
+
+func TestSkippedKeyLogNil(t *testing.T) {
+	var l *skippedKeyLog
+	l.Record(&RDBEntry{Key: "k"}, skipReasonExpired)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,224 @@
+package replica
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"df2redis/internal/state"
+	"df2redis/internal/tracing"
+)
+
+// Full-load engines (migrate.fullLoadEngine).
+const (
+	FullLoadNative = "native" // snapshot streamed live over the Dragonfly FLOW connections
+	FullLoadShake  = "shake"  // snapshot read from the RDB file redis-shake left at migrate.snapshotPath
+)
+
+// loadSnapshotFile imports the RDB snapshot at migrate.snapshotPath with the
+// native parser and the same writer path as a live full sync, so a dump
+// produced by redis-shake (or BGSAVE) gets our type support and write modes.
+// The path may also be a directory or glob of files, e.g. one RDB per
+// Dragonfly shard from a backup; each file is then parsed concurrently as
+// its own FLOW. A file carries no replication offsets, so there is no
+// journal to continue from and the run is always snapshot-only.
+func (r *Replicator) loadSnapshotFile() error {
+	location := r.cfg.ResolvedMigrateConfig().SnapshotPath
+	paths, err := ExpandSnapshotPaths(location)
+	if err != nil {
+		return err
+	}
+	numFlows := len(paths)
+	log.Println("")
+	if numFlows == 1 {
+		log.Printf("📦 Loading RDB snapshot from %s...", paths[0])
+	} else {
+		log.Printf("📦 Loading %d RDB snapshot files from %s in parallel...", numFlows, location)
+	}
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	r.initFlowTracking(numFlows)
+	r.metricsMu.Lock()
+	r.snapshotStartTime = time.Now()
+	r.metricsMu.Unlock()
+
+	snapCtx, snapSpan := tracing.Start(r.ctx, "snapshot", "source.file", location, "files", numFlows)
+	defer snapSpan.End()
+
+	writerPool := newSharedWriterPool(r.cfg)
+	defer writerPool.Close()
+
+	r.startFlowWriters(numFlows, writerPool, snapCtx)
+	for _, m := range r.mirrors {
+		m.r.startFlowWriters(numFlows, writerPool, snapCtx)
+	}
+
+	var dups *dupTracker
+	if max := r.cfg.Migrate.DuplicateKeyTracking; max > 0 && numFlows > 1 {
+		dups = newDupTracker(max)
+		log.Printf("  • Tracking up to %d keys for duplicates across files", max)
+	}
+
+	results := make([]snapshotFileResult, numFlows)
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(flowID int, path string) {
+			defer wg.Done()
+			results[flowID] = r.loadSnapshotShard(flowID, path, dups)
+			if results[flowID].err != nil {
+				// Stop the other files too; the run fails either way
+				r.cancel()
+			}
+		}(i, path)
+	}
+	wg.Wait()
+
+	log.Println("⏸  Stopping async writers and flushing remaining batches...")
+	for _, fw := range r.flowWriters {
+		fw.Stop()
+	}
+	r.stopMirrorWriters()
+
+	var keys, skipped, failed int
+	var parseErr error
+	for i, res := range results {
+		keys += res.keys
+		skipped += res.skipped
+		failed += res.failed
+		if parseErr == nil && res.err != nil && !errors.Is(res.err, errSnapshotCancelled) {
+			parseErr = fmt.Errorf("%s: %w", paths[i], res.err)
+		}
+		if numFlows > 1 {
+			log.Printf("  [FLOW-%d] %s: %d keys, skipped %d, failed %d", i, filepath.Base(paths[i]), res.keys, res.skipped, res.failed)
+		}
+	}
+	if parseErr == nil && r.ctx.Err() != nil {
+		parseErr = errSnapshotCancelled
+	}
+	if parseErr != nil {
+		return parseErr
+	}
+	if err := r.writeBudget.Err(); err != nil {
+		return err
+	}
+
+	for i, fw := range r.flowWriters {
+		received, written, batches := fw.GetStats()
+		log.Printf("  [FLOW-%d] Writer stats: received=%d, written=%d, batches=%d, bytes=~%.1fMB",
+			i, received, written, batches, float64(fw.GetBytesWritten())/(1024*1024))
+	}
+	log.Printf("  ✓ RDB file: total %d keys, skipped %d (expired/unsupported), failed %d", keys, skipped, failed)
+	if n := dups.Duplicates(); n > 0 {
+		log.Printf("  ⚠ %d keys were found in more than one file; the last write won", n)
+	}
+	r.recordStage("snapshot-file", "completed", fmt.Sprintf("%d keys loaded from %s", keys, location))
+	severity := state.SeverityInfo
+	if failed > 0 {
+		severity = state.SeverityWarn
+	}
+	r.recordEvent(severity, "full-sync", fmt.Sprintf("RDB file %s loaded: %d keys, %d skipped, %d failed", location, keys, skipped, failed))
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	return nil
+}
+
+// errSnapshotCancelled is returned by loadSnapshotShard when the run stops
+// before the file is fully read.
+var errSnapshotCancelled = errors.New("snapshot load cancelled")
+
+// snapshotFileResult counts what one snapshot file contributed.
+type snapshotFileResult struct {
+	keys, skipped, failed int
+	err                   error
+}
+
+// loadSnapshotShard parses one snapshot file and queues its keys on the
+// writer of flowID.
+func (r *Replicator) loadSnapshotShard(flowID int, path string, dups *dupTracker) (res snapshotFileResult) {
+	defer r.recoverFlow(flowID, flowPhaseFile, func(err error) { res.err = err })
+	rc, compression, err := OpenSnapshotFile(path)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer rc.Close()
+	if compression != SnapshotPlain {
+		log.Printf("  [FLOW-%d] • Decompressing %s on the fly", flowID, compression)
+	}
+	flowWriter := r.flowWriters[flowID]
+
+	parser := NewRDBParser(rc, flowID)
+	parser.dumpTargetVersion = r.dumpTargetVersion
+	parser.dumpMinElements = r.dumpMinElements
+	if len(r.cfg.Conflict.NotifyPatterns) > 0 {
+		parser.forceCommands = r.matchesNotifyPattern
+	}
+	// Journal blobs only exist on live streams
+	parser.onJournalEntry = func(*JournalEntry) error { return nil }
+
+	if err := parser.ParseHeader(); err != nil {
+		res.err = fmt.Errorf("failed to parse RDB header: %w", err)
+		return res
+	}
+
+	for {
+		if err := r.ctx.Err(); err != nil {
+			res.err = errSnapshotCancelled
+			return res
+		}
+		entry, err := parser.ParseNext()
+		if err == io.EOF {
+			return res
+		}
+		if err != nil {
+			res.err = fmt.Errorf("parsing failed after %d keys: %w", res.keys, err)
+			return res
+		}
+		if entry.Type == RDB_TYPE_FULLSYNC_END_MARKER {
+			continue
+		}
+		if entry.IsExpired() {
+			r.skippedKeys.Record(entry, skipReasonExpired)
+			res.skipped++
+			continue
+		}
+		if entry.Type == RDB_TYPE_SBF && !r.restoreBloom {
+			log.Printf("  [FLOW-%d] ⊘ Skipped bloom filter key=%s (set migrate.restoreBloomFilters and load RedisBloom on the target to recreate it)", flowID, entry.Key)
+			r.skippedKeys.Record(entry, skipReasonBloom)
+			res.skipped++
+			continue
+		}
+		if !r.typeFilter.Allowed(entry.Type) {
+			r.countTypeFiltered(entry)
+			res.skipped++
+			continue
+		}
+		if write, err := r.checkOversize(flowID, entry); err != nil {
+			res.err = err
+			return res
+		} else if !write {
+			res.skipped++
+			continue
+		}
+		if dups != nil {
+			dups.observe(entry.DbIndex, entry.Key, flowID)
+		}
+		if r.transformer != nil {
+			r.transformer.TransformEntry(entry)
+		}
+		r.mirrorSnapshotEntry(flowID, entry)
+
+		if err := flowWriter.Enqueue(entry); err != nil {
+			log.Printf("  [FLOW-%d] ⚠ Write failed (key=%s): %v", flowID, entry.Key, err)
+			res.failed++
+			r.recordWriteResults(0, 1)
+			continue
+		}
+		res.keys++
+		r.onSnapshotKey(flowID)
+	}
+}
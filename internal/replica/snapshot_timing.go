@@ -0,0 +1,62 @@
+package replica
+
+import (
+	"fmt"
+	"time"
+)
+
+// snapshotTiming is the wall time one FLOW spent in each stage of the
+// snapshot phase. Network, Decompress, Decode and Journal happen inside
+// ParseNext; Enqueue is time the reader waited on a full writer queue; Write
+// is batch flush time in the writer goroutine, which overlaps with the rest.
+type snapshotTiming struct {
+	Network    time.Duration
+	Decompress time.Duration
+	Decode     time.Duration
+	Journal    time.Duration
+	Enqueue    time.Duration
+	Write      time.Duration
+}
+
+func (t *snapshotTiming) add(o snapshotTiming) {
+	t.Network += o.Network
+	t.Decompress += o.Decompress
+	t.Decode += o.Decode
+	t.Journal += o.Journal
+	t.Enqueue += o.Enqueue
+	t.Write += o.Write
+}
+
+func (t snapshotTiming) String() string {
+	r := func(d time.Duration) time.Duration { return d.Truncate(time.Millisecond) }
+	return fmt.Sprintf("network=%v, decompress=%v, decode=%v, inline_journal=%v, enqueue_wait=%v, write=%v",
+		r(t.Network), r(t.Decompress), r(t.Decode), r(t.Journal), r(t.Enqueue), r(t.Write))
+}
+
+// dominant names the stage that took the most time and its share of the
+// total. Enqueue is left out: waiting on the writer is already counted as
+// write time. Returns "" when nothing was measured.
+func (t snapshotTiming) dominant() (string, float64) {
+	stages := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"network reads", t.Network},
+		{"decompression", t.Decompress},
+		{"RDB decoding", t.Decode},
+		{"inline journal replay", t.Journal},
+		{"target writes", t.Write},
+	}
+	var sum time.Duration
+	best := 0
+	for i, s := range stages {
+		sum += s.d
+		if s.d > stages[best].d {
+			best = i
+		}
+	}
+	if sum <= 0 {
+		return "", 0
+	}
+	return stages[best].name, float64(stages[best].d) / float64(sum)
+}
@@ -0,0 +1,46 @@
+package replica
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSnapshotTimingDominant(t *testing.T) {
+	var total snapshotTiming
+	total.add(snapshotTiming{Network: time.Second, Decode: 2 * time.Second})
+	total.add(snapshotTiming{Write: 5 * time.Second, Enqueue: 10 * time.Second})
+
+	stage, share := total.dominant()
+	if stage != "target writes" || share != 0.625 {
+		t.Fatalf("dominant = %q %.3f, want target writes 0.625", stage, share)
+	}
+	if stage, _ := (snapshotTiming{}).dominant(); stage != "" {
+		t.Fatalf("empty timing dominant = %q", stage)
+	}
+}
+
+func TestParserTimingsCountReads(t *testing.T) {
+	var stream bytes.Buffer
+	stream.WriteString("REDIS0011")
+	stream.WriteByte(RDB_OPCODE_EOF)
+	stream.Write(make([]byte, 8))
+
+	p := NewRDBParser(&slowReader{r: &stream, delay: 5 * time.Millisecond}, 0)
+	if err := p.ParseHeader(); err != nil {
+		t.Fatal(err)
+	}
+	if got := p.Timings(); got.Read < 5*time.Millisecond || got.Decompress != 0 {
+		t.Fatalf("timings = %+v, want read >= 5ms and no decompression", got)
+	}
+}
+
+type slowReader struct {
+	r     *bytes.Buffer
+	delay time.Duration
+}
+
+func (s *slowReader) Read(b []byte) (int, error) {
+	time.Sleep(s.delay)
+	return s.r.Read(b)
+}
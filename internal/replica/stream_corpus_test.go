@@ -0,0 +1,354 @@
+package replica
+
+import (
+	"bytes"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// The stream corpus lives in testdata/streams: each <name>.rdb is the byte
+// stream of one FLOW connection (RDB header through FULLSYNC_END and the EOF
+// token), each <name>.journal a stable-sync journal stream. <file>.golden
+// holds the decoded entries. Run with -update to regenerate the synthetic-*
+// streams and rewrite every golden file, then review the diff.
+var updateCorpus = flag.Bool("update", false, "regenerate synthetic streams and golden files in testdata/streams")
+
+const corpusDir = "testdata/streams"
+
+func TestStreamCorpus(t *testing.T) {
+	if *updateCorpus {
+		for name, data := range syntheticStreams(t) {
+			if err := os.WriteFile(filepath.Join(corpusDir, name), data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(corpusDir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ran := 0
+	for _, path := range files {
+		var decode func([]byte) (string, error)
+		switch filepath.Ext(path) {
+		case ".rdb":
+			decode = decodeRDBStream
+		case ".journal":
+			decode = decodeJournalStream
+		default:
+			continue
+		}
+		ran++
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := decode(data)
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			golden := path + ".golden"
+			if *updateCorpus {
+				if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("missing golden file (run with -update): %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("decoded entries differ from %s\n--- got\n%s--- want\n%s", golden, got, want)
+			}
+		})
+	}
+	if ran == 0 {
+		t.Fatalf("no streams found in %s", corpusDir)
+	}
+}
+
+// decodeRDBStream runs a FLOW stream through RDBParser and describes every
+// key, inline journal entry and FULLSYNC_END marker in order.
+func decodeRDBStream(data []byte) (string, error) {
+	var out strings.Builder
+	p := NewRDBParser(bytes.NewReader(data), 0)
+	p.onJournalEntry = func(e *JournalEntry) error {
+		fmt.Fprintf(&out, "inline %s\n", describeJournalEntry(e))
+		return nil
+	}
+	if err := p.ParseHeader(); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(&out, "header version=%d\n", p.rdbVersion)
+	for {
+		entry, err := p.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out.String(), err
+		}
+		fmt.Fprintln(&out, describeRDBEntry(entry))
+	}
+	out.WriteString("eof\n")
+	return out.String(), nil
+}
+
+func decodeJournalStream(data []byte) (string, error) {
+	var out strings.Builder
+	jr := NewJournalReader(bytes.NewReader(data))
+	for {
+		entry, err := jr.ReadEntry()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return out.String(), err
+		}
+		fmt.Fprintln(&out, describeJournalEntry(entry))
+	}
+	out.WriteString("eof\n")
+	return out.String(), nil
+}
+
+func describeRDBEntry(e *RDBEntry) string {
+	if e.Type == RDB_TYPE_FULLSYNC_END_MARKER {
+		return "fullsync-end"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "key db=%d %q type=%d", e.DbIndex, e.Key, e.Type)
+	if e.ExpireMs != 0 {
+		fmt.Fprintf(&b, " expire=%d", e.ExpireMs)
+	}
+	switch v := e.Value.(type) {
+	case *StringValue:
+		fmt.Fprintf(&b, " string %q", v.Value)
+	case *HashValue:
+		fields := make([]string, 0, len(v.Fields))
+		for f := range v.Fields {
+			fields = append(fields, f)
+		}
+		sort.Strings(fields)
+		b.WriteString(" hash")
+		for _, f := range fields {
+			fmt.Fprintf(&b, " %q=%q", f, v.Fields[f])
+			if ttl, ok := v.FieldExpiry[f]; ok {
+				fmt.Fprintf(&b, "@%d", ttl)
+			}
+		}
+	case *ListValue:
+		fmt.Fprintf(&b, " list %q", v.Elements)
+	case *SetValue:
+		fmt.Fprintf(&b, " set %q", v.Members)
+	case *ZSetValue:
+		b.WriteString(" zset")
+		for _, m := range v.Members {
+			fmt.Fprintf(&b, " %q:%g", m.Member, m.Score)
+		}
+	default:
+		fmt.Fprintf(&b, " %T", v)
+	}
+	return b.String()
+}
+
+func describeJournalEntry(e *JournalEntry) string {
+	switch e.Opcode {
+	case OpCommand, OpExpired:
+		return fmt.Sprintf("%s db=%d txid=%d shards=%d %q %q", e.Opcode, e.DbIndex, e.TxID, e.ShardCnt, e.Command, e.Args)
+	case OpLSN:
+		return fmt.Sprintf("LSN %d", e.LSN)
+	default:
+		return e.Opcode.String()
+	}
+}
+
+// ============ Synthetic stream builders ============
+
+// farFuture keeps TTLs in the corpus from expiring relative to the test clock.
+const farFuture = int64(4102444800000) // 2100-01-01
+
+// packedUint encodes n in the RDB length encoding used by journal records.
+func packedUint(n uint64) []byte {
+	switch {
+	case n < 1<<6:
+		return []byte{byte(n)}
+	case n < 1<<14:
+		return []byte{0x40 | byte(n>>8), byte(n)}
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32([]byte{0x80}, uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64([]byte{0x81}, n)
+	}
+}
+
+func packedString(s string) []byte {
+	return append(packedUint(uint64(len(s))), s...)
+}
+
+// journalRecord encodes a COMMAND/EXPIRED journal record.
+func journalRecord(op JournalOpcode, txid uint64, cmd string, args ...string) []byte {
+	var payload []byte
+	size := len(cmd)
+	for _, a := range args {
+		size += len(a)
+	}
+	payload = append(payload, packedUint(uint64(1+len(args)))...)
+	payload = append(payload, packedUint(uint64(size))...)
+	payload = append(payload, packedString(cmd)...)
+	for _, a := range args {
+		payload = append(payload, packedString(a)...)
+	}
+	rec := []byte{byte(op)}
+	rec = append(rec, packedUint(txid)...)
+	rec = append(rec, packedUint(1)...) // shard count
+	return append(rec, payload...)
+}
+
+func journalSelect(db uint64) []byte { return append([]byte{byte(OpSelect)}, packedUint(db)...) }
+func journalLSN(lsn uint64) []byte   { return append([]byte{byte(OpLSN)}, packedUint(lsn)...) }
+
+// journalBlob wraps records in RDB_OPCODE_JOURNAL_BLOB.
+func journalBlob(records ...[]byte) []byte {
+	blob := bytes.Join(records, nil)
+	out := []byte{RDB_OPCODE_JOURNAL_BLOB}
+	out = append(out, packedUint(uint64(len(records)))...)
+	return append(out, rdbString(blob)...)
+}
+
+func rdbKey(typ byte, key string, value ...[]byte) []byte {
+	out := append([]byte{typ}, rdbString([]byte(key))...)
+	for _, v := range value {
+		out = append(out, v...)
+	}
+	return out
+}
+
+func rdbStr(s string) []byte { return rdbString([]byte(s)) }
+
+func rdbHeader(aux ...string) []byte {
+	out := []byte("REDIS0011")
+	for i := 0; i+1 < len(aux); i += 2 {
+		out = append(out, RDB_OPCODE_AUX)
+		out = append(out, rdbStr(aux[i])...)
+		out = append(out, rdbStr(aux[i+1])...)
+	}
+	return out
+}
+
+// fullSyncEnd is the marker, its eight zero bytes and the 40-byte EOF token
+// Dragonfly sends after STARTSTABLE.
+func fullSyncEnd(journalAfter ...[]byte) []byte {
+	out := []byte{RDB_OPCODE_FULLSYNC_END, 0, 0, 0, 0, 0, 0, 0, 0}
+	for _, j := range journalAfter {
+		out = append(out, j...)
+	}
+	return append(out, strings.Repeat("0123456789abcdef", 3)[:40]...)
+}
+
+func syntheticStreams(t *testing.T) map[string][]byte {
+	t.Helper()
+	expireMs := binary.LittleEndian.AppendUint64([]byte{RDB_OPCODE_EXPIRETIME_MS}, uint64(farFuture))
+	score := func(f float64) []byte { return binary.LittleEndian.AppendUint64(nil, math.Float64bits(f)) }
+	ttl := fmt.Sprint(farFuture)
+
+	// Every value encoding Dragonfly streams for plain keys
+	var basic bytes.Buffer
+	basic.Write(rdbHeader("redis-ver", "7.4.0", "df-ver", "v1.36.0"))
+	basic.Write([]byte{RDB_OPCODE_SELECTDB, 0, RDB_OPCODE_RESIZEDB, 9, 1})
+	basic.Write(rdbKey(RDB_TYPE_STRING, "str", rdbStr("hello")))
+	basic.Write(expireMs)
+	basic.Write(rdbKey(RDB_TYPE_STRING, "str:ttl", rdbStr("bye")))
+	basic.Write(rdbKey(RDB_TYPE_HASH, "hash:plain", []byte{2}, rdbStr("f1"), rdbStr("v1"), rdbStr("f2"), rdbStr("v2")))
+	basic.Write(rdbKey(RDB_TYPE_HASH_LISTPACK, "hash:lp", rdbString(encodeListpack("a", "1", "b", "2"))))
+	basic.Write(rdbKey(RDB_TYPE_HASH_LISTPACK_EX, "hash:lpex",
+		binary.LittleEndian.AppendUint64(nil, uint64(farFuture)),
+		rdbString(encodeListpack("keep", "x", "0", "short", "y", ttl))))
+	basic.Write(rdbKey(RDB_TYPE_SET_INTSET, "set:int", rdbString(encodeIntset(2, -3, 7, 300))))
+	basic.Write(rdbKey(RDB_TYPE_SET_LISTPACK, "set:lp", rdbString(encodeListpack("m1", "m2"))))
+	basic.Write(rdbKey(RDB_TYPE_ZSET_2, "zset:plain", []byte{2}, rdbStr("low"), score(-1.5), rdbStr("high"), score(10)))
+	basic.Write(rdbKey(RDB_TYPE_ZSET_LISTPACK, "zset:lp", rdbString(encodeListpack("z1", "1", "z2", "2.25"))))
+	basic.Write(rdbKey(RDB_TYPE_LIST_QUICKLIST_2, "list", []byte{2},
+		[]byte{QUICKLIST_NODE_CONTAINER_PACKED}, rdbString(encodeListpack("a", "b")),
+		[]byte{QUICKLIST_NODE_CONTAINER_PLAIN}, rdbStr("big element")))
+	basic.Write([]byte{RDB_OPCODE_SELECTDB, 3})
+	basic.Write(rdbKey(RDB_TYPE_STRING, "db3:str", rdbStr("other db")))
+	basic.Write(fullSyncEnd())
+
+	// LZ4 and ZSTD blobs, including a key right after a blob ends
+	var lz4Data bytes.Buffer
+	lw := lz4.NewWriter(&lz4Data)
+	if _, err := lw.Write(bytes.Join([][]byte{
+		rdbKey(RDB_TYPE_STRING, "lz4:1", rdbStr(strings.Repeat("x", 200))),
+		rdbKey(RDB_TYPE_SET_LISTPACK, "lz4:2", rdbString(encodeListpack("p", "q"))),
+	}, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := lw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zstdData := zw.EncodeAll(rdbKey(RDB_TYPE_STRING, "zstd:1", rdbStr(strings.Repeat("y", 200))), nil)
+	zw.Close()
+
+	var compressed bytes.Buffer
+	compressed.Write(rdbHeader())
+	compressed.Write([]byte{RDB_OPCODE_SELECTDB, 0})
+	compressed.WriteByte(RDB_OPCODE_COMPRESSED_LZ4_BLOB_START)
+	compressed.Write(rdbString(lz4Data.Bytes()))
+	compressed.Write(rdbKey(RDB_TYPE_STRING, "plain", rdbStr("between blobs")))
+	compressed.WriteByte(RDB_OPCODE_COMPRESSED_ZSTD_BLOB_START)
+	compressed.Write(rdbString(zstdData))
+	compressed.Write(fullSyncEnd())
+
+	// Inline journal during the snapshot and after FULLSYNC_END
+	var inline bytes.Buffer
+	inline.Write(rdbHeader())
+	inline.Write([]byte{RDB_OPCODE_SELECTDB, 0})
+	inline.Write(rdbKey(RDB_TYPE_STRING, "before", rdbStr("1")))
+	inline.Write(journalBlob(
+		journalSelect(0),
+		journalRecord(OpCommand, 11, "SET", "during", "2"),
+		journalLSN(12),
+		journalRecord(OpExpired, 13, "PEXPIRE", "before", "1"),
+	))
+	inline.Write(rdbKey(RDB_TYPE_STRING, "after", rdbStr("3")))
+	inline.WriteByte(RDB_OPCODE_JOURNAL_OFFSET)
+	inline.Write(binary.LittleEndian.AppendUint64(nil, 42))
+	inline.Write(fullSyncEnd(journalBlob(journalRecord(OpCommand, 14, "DEL", "during"))))
+
+	// Stable-sync journal stream
+	var stable bytes.Buffer
+	stable.Write(journalSelect(0))
+	stable.Write(journalRecord(OpCommand, 100, "SET", "k", "v"))
+	stable.Write(journalLSN(101))
+	stable.WriteByte(byte(OpPing))
+	stable.Write(journalRecord(OpCommand, 102, "HSET", "h", "f\r\n", "bin\x00ary"))
+	stable.Write(journalRecord(OpExpired, 103, "DEL", "k"))
+	stable.Write(journalSelect(2))
+	stable.Write(journalRecord(OpCommand, 104, "LPUSH", "l", strings.Repeat("z", 100)))
+	stable.Write(journalRecord(OpCommand, 105, "PING"))
+
+	return map[string][]byte{
+		"synthetic-basic.rdb":      basic.Bytes(),
+		"synthetic-compressed.rdb": compressed.Bytes(),
+		"synthetic-inline.rdb":     inline.Bytes(),
+		"synthetic-stable.journal": stable.Bytes(),
+	}
+}
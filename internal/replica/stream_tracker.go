@@ -0,0 +1,90 @@
+package replica
+
+import (
+	"encoding/hex"
+	"io"
+	"strings"
+)
+
+// flowBufSize is the size of the buffered reader shared by the RDB parser and
+// the journal reader of each FLOW.
+const flowBufSize = 1024 * 1024
+
+// streamTracker counts the bytes received on a FLOW connection and keeps the
+// most recent ones, so a desynced stream can be traced back to an offset.
+// It sits below the FLOW's bufio.Reader; the history therefore has to cover a
+// full buffer plus some context to reach bytes the parser already consumed.
+type streamTracker struct {
+	r     io.Reader
+	total int64  // bytes received from the network
+	ring  []byte // last len(ring) bytes received
+	pos   int    // next write position in ring
+}
+
+func newStreamTracker(r io.Reader) *streamTracker {
+	return &streamTracker{r: r, ring: make([]byte, flowBufSize+256)}
+}
+
+func (t *streamTracker) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.record(p[:n])
+	}
+	return n, err
+}
+
+func (t *streamTracker) record(b []byte) {
+	t.total += int64(len(b))
+	if len(b) >= len(t.ring) {
+		copy(t.ring, b[len(b)-len(t.ring):])
+		t.pos = 0
+		return
+	}
+	n := copy(t.ring[t.pos:], b)
+	if n < len(b) {
+		copy(t.ring, b[n:])
+	}
+	t.pos = (t.pos + len(b)) % len(t.ring)
+}
+
+// window returns up to n bytes of the stream ending at offset end, limited to
+// what is still held in the history.
+func (t *streamTracker) window(end int64, n int) []byte {
+	if end > t.total || end <= 0 {
+		return nil
+	}
+	back := t.total - end // bytes received after end
+	if back+int64(n) > int64(len(t.ring)) {
+		n = int(int64(len(t.ring)) - back)
+	}
+	if int64(n) > end {
+		n = int(end)
+	}
+	if n <= 0 {
+		return nil
+	}
+
+	out := make([]byte, n)
+	start := (t.pos - int(back) - n) % len(t.ring)
+	if start < 0 {
+		start += len(t.ring)
+	}
+	for i := range out {
+		out[i] = t.ring[(start+i)%len(t.ring)]
+	}
+	return out
+}
+
+// hexBlock renders b as an indented hex dump for multi-line log output.
+func hexBlock(b []byte) string {
+	if len(b) == 0 {
+		return "    (none)\n"
+	}
+	var sb strings.Builder
+	for _, line := range strings.SplitAfter(hex.Dump(b), "\n") {
+		if line != "" {
+			sb.WriteString("    " + line)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,71 @@
+package replica
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"df2redis/internal/config"
+	"df2redis/internal/redisx"
+)
+
+// checkTargetMode compares target.type with what the seed reports in INFO
+// cluster, so pointing a cluster config at a standalone node (or the other
+// way round) is caught before any data is written. A mismatch is a warning,
+// or an error with target.strictType. Probe failures are left to the real
+// dial to report.
+func checkTargetMode(ctx context.Context, tc config.TargetConfig, seed, clientName string) error {
+	probeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	client, err := redisx.Dial(probeCtx, redisx.Config{
+		Addr:        seed,
+		Password:    tc.Password,
+		TLS:         tc.TLS,
+		TLSCAFile:   tc.TLSCAFile,
+		TLSInsecure: tc.TLSInsecure,
+		Name:        clientName,
+	})
+	if err != nil {
+		return nil
+	}
+	defer client.Close()
+	info, err := client.Info("cluster")
+	if err != nil {
+		return nil
+	}
+	detected, ok := clusterEnabled(info)
+	if !ok {
+		return nil
+	}
+
+	configured := strings.Contains(strings.ToLower(tc.Type), "cluster")
+	if configured == detected {
+		return nil
+	}
+	var msg string
+	if configured {
+		msg = fmt.Sprintf("target.type=%s but %s reports cluster_enabled:0; it is a standalone node, so keys would not be sharded", tc.Type, seed)
+	} else {
+		msg = fmt.Sprintf("target.type=%s but %s reports cluster_enabled:1; writes for slots it doesn't own will fail with MOVED (use type: redis-cluster)", tc.Type, seed)
+	}
+	if tc.StrictType {
+		return fmt.Errorf("%s (target.strictType)", msg)
+	}
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	log.Printf("⚠️  Target mode mismatch: %s", msg)
+	log.Println("   Check that target.addr points at the intended endpoint; set target.strictType to fail instead.")
+	log.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	return nil
+}
+
+// clusterEnabled reads cluster_enabled from INFO cluster output.
+func clusterEnabled(info string) (enabled, ok bool) {
+	for _, line := range strings.Split(info, "\n") {
+		if v, found := strings.CutPrefix(strings.TrimSpace(line), "cluster_enabled:"); found {
+			return v == "1", true
+		}
+	}
+	return false, false
+}
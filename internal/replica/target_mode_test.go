@@ -0,0 +1,20 @@
+package replica
+
+import "testing"
+
+func TestClusterEnabled(t *testing.T) {
+	cases := []struct {
+		info        string
+		enabled, ok bool
+	}{
+		{"# Cluster\r\ncluster_enabled:1\r\n", true, true},
+		{"# Cluster\r\ncluster_enabled:0\r\n", false, true},
+		{"# Cluster\r\n", false, false},
+	}
+	for _, tc := range cases {
+		enabled, ok := clusterEnabled(tc.info)
+		if enabled != tc.enabled || ok != tc.ok {
+			t.Errorf("clusterEnabled(%q) = %v, %v; want %v, %v", tc.info, enabled, ok, tc.enabled, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package replica
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"df2redis/internal/redisx"
+)
+
+// waitTargetReplicas runs WAIT on every target master once the snapshot has
+// been flushed, so operators can tell whether the imported data reached the
+// target's own replicas. WAIT only covers writes made on the same
+// connection, which is why it goes through the writer clients rather than a
+// fresh connection. The outcome is logged and recorded as the target-wait
+// stage; a shortfall does not stop replication.
+func (r *Replicator) waitTargetReplicas() {
+	want := r.cfg.Target.WaitReplicas
+	if want <= 0 || r.clusterClient == nil {
+		return
+	}
+	timeoutMs := r.cfg.Target.WaitTimeoutMs
+	log.Printf("⏳ Waiting for %d target replica(s) per master to acknowledge the import (timeout %dms)...", want, timeoutMs)
+
+	var short []string
+	masters := 0
+	err := r.clusterClient.ForEachMaster(func(client *redisx.Client) error {
+		// Give the socket a little longer than WAIT itself so the reply isn't cut off
+		reply, err := client.DoWithTimeout(time.Duration(timeoutMs)*time.Millisecond+5*time.Second, "WAIT", want, timeoutMs)
+		if err != nil {
+			return fmt.Errorf("WAIT on %s: %w", client.Addr(), err)
+		}
+		acked, err := redisx.ToInt64(reply)
+		if err != nil {
+			return fmt.Errorf("WAIT on %s: %w", client.Addr(), err)
+		}
+		masters++
+		log.Printf("  %s: %d/%d replicas acknowledged", client.Addr(), acked, want)
+		if acked < int64(want) {
+			short = append(short, fmt.Sprintf("%s=%d", client.Addr(), acked))
+		}
+		return nil
+	})
+
+	switch {
+	case err != nil:
+		log.Printf("⚠ Target WAIT failed: %v", err)
+		r.recordStage("target-wait", "error", err.Error())
+	case len(short) > 0:
+		msg := fmt.Sprintf("%d/%d masters below %d replica acks: %s", len(short), masters, want, strings.Join(short, ", "))
+		log.Printf("⚠ Target WAIT: %s", msg)
+		r.recordStage("target-wait", "error", msg)
+	default:
+		msg := fmt.Sprintf("%d masters acknowledged by %d replica(s)", masters, want)
+		log.Printf("✓ Target WAIT: %s", msg)
+		r.recordStage("target-wait", "completed", msg)
+	}
+}
@@ -0,0 +1,160 @@
+package replica
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"df2redis/internal/config"
+	"df2redis/internal/redisx"
+)
+
+// Transformer rewrites keys and values on their way to the target. It is
+// applied to every snapshot entry before it is written and to every journal
+// command before it is replayed.
+type Transformer interface {
+	// TransformEntry may rewrite a snapshot entry's key or value in place.
+	TransformEntry(entry *RDBEntry)
+	// TransformCommand returns the arguments to replay for a journal command
+	// (cmd is upper-cased). It must not modify args.
+	TransformCommand(cmd string, args []string) []string
+}
+
+// checkRewriteSource refuses key rewriting from a Dragonfly cluster source.
+// Its journal drops migrated slots with DFLYCLUSTER FLUSHSLOTS, which names
+// source slots; a renamed key hashes to a different slot, so the flush would
+// miss it and delete unrelated keys instead.
+func (r *Replicator) checkRewriteSource() error {
+	if r.transformer == nil {
+		return nil
+	}
+	reply, err := r.mainConn.Do("INFO", "cluster")
+	if err == nil {
+		var info string
+		if info, err = redisx.ToString(reply); err == nil {
+			if enabled, ok := clusterEnabled(info); ok && enabled {
+				return fmt.Errorf("migrate.keyRewrites can't be used with a cluster-mode source: its DFLYCLUSTER FLUSHSLOTS commands name source slots, which rewritten keys no longer hash into")
+			}
+			return nil
+		}
+	}
+	log.Printf("  ⚠ Cannot tell whether the source runs in cluster mode (%v); FLUSHSLOTS will be skipped while keys are rewritten", err)
+	return nil
+}
+
+// prefixRewriter implements migrate.keyRewrites: the first rule whose From
+// prefix matches a key replaces that prefix with To.
+type prefixRewriter struct {
+	rules []config.KeyRewrite
+}
+
+// newPrefixRewriter returns nil when no rules are configured.
+func newPrefixRewriter(rules []config.KeyRewrite) Transformer {
+	if len(rules) == 0 {
+		return nil
+	}
+	return &prefixRewriter{rules: rules}
+}
+
+func (p *prefixRewriter) rewriteKey(key string) string {
+	for _, rule := range p.rules {
+		if rest, ok := strings.CutPrefix(key, rule.From); ok {
+			return rule.To + rest
+		}
+	}
+	return key
+}
+
+func (p *prefixRewriter) TransformEntry(entry *RDBEntry) {
+	entry.Key = p.rewriteKey(entry.Key)
+}
+
+func (p *prefixRewriter) TransformCommand(cmd string, args []string) []string {
+	var out []string
+	for _, i := range commandKeyIndexes(cmd, args) {
+		if key := p.rewriteKey(args[i]); key != args[i] {
+			if out == nil {
+				out = append([]string(nil), args...)
+			}
+			out[i] = key
+		}
+	}
+	if out == nil {
+		return args
+	}
+	return out
+}
+
+// commandKeyIndexes returns the positions of key arguments in a journal
+// command. Commands not listed here carry a single key as their first
+// argument; keyless commands return nil.
+func commandKeyIndexes(cmd string, args []string) []int {
+	if len(args) == 0 || keylessCommands[cmd] {
+		return nil
+	}
+	switch cmd {
+	case "DEL", "UNLINK", "EXISTS", "TOUCH", "MGET", "WATCH",
+		"SINTERSTORE", "SUNIONSTORE", "SDIFFSTORE", "PFMERGE":
+		return allIndexes(len(args), 0, 1)
+	case "MSET", "MSETNX":
+		return allIndexes(len(args), 0, 2)
+	case "RENAME", "RENAMENX", "SMOVE", "LMOVE", "RPOPLPUSH", "BLMOVE", "BRPOPLPUSH", "COPY",
+		"ZRANGESTORE", "GEOSEARCHSTORE":
+		return allIndexes(min(len(args), 2), 0, 1)
+	case "BLPOP", "BRPOP", "BZPOPMIN", "BZPOPMAX":
+		// key... timeout
+		return allIndexes(len(args)-1, 0, 1)
+	case "BITOP":
+		// BITOP op destkey key...
+		return allIndexes(len(args), 1, 1)
+	case "ZUNIONSTORE", "ZINTERSTORE", "ZDIFFSTORE":
+		// dest numkeys key...
+		return append([]int{0}, numKeysIndexes(args, 1)...)
+	case "LMPOP", "ZMPOP":
+		// numkeys key...
+		return numKeysIndexes(args, 0)
+	case "BLMPOP", "BZMPOP", "EVAL", "EVALSHA", "EVAL_RO", "EVALSHA_RO", "FCALL", "FCALL_RO":
+		// timeout numkeys key... / script numkeys key...
+		return numKeysIndexes(args, 1)
+	case "SORT", "SORT_RO", "GEORADIUS", "GEORADIUSBYMEMBER":
+		// key ... [STORE|STOREDIST dest]
+		idx := []int{0}
+		for i := 1; i+1 < len(args); i++ {
+			if opt := strings.ToUpper(args[i]); opt == "STORE" || opt == "STOREDIST" {
+				idx = append(idx, i+1)
+				i++
+			}
+		}
+		return idx
+	}
+	return []int{0}
+}
+
+// keylessCommands take no key arguments, so nothing in them is rewritten.
+var keylessCommands = map[string]bool{
+	"DFLYCLUSTER": true, "FLUSHDB": true, "FLUSHALL": true, "SELECT": true, "SWAPDB": true,
+	"PING": true, "MULTI": true, "EXEC": true, "DISCARD": true, "SCRIPT": true, "FUNCTION": true,
+	"PUBLISH": true, "SPUBLISH": true,
+}
+
+// numKeysIndexes returns the key positions of a "numkeys key..." list whose
+// count is args[at]. A malformed count yields no keys.
+func numKeysIndexes(args []string, at int) []int {
+	if at >= len(args) {
+		return nil
+	}
+	n, err := strconv.Atoi(args[at])
+	if err != nil || n < 0 {
+		return nil
+	}
+	return allIndexes(min(len(args), at+1+n), at+1, 1)
+}
+
+func allIndexes(n, start, step int) []int {
+	var idx []int
+	for i := start; i < n; i += step {
+		idx = append(idx, i)
+	}
+	return idx
+}
@@ -0,0 +1,56 @@
+package replica
+
+import (
+	"reflect"
+	"testing"
+
+	"df2redis/internal/config"
+)
+
+func TestPrefixRewriter(t *testing.T) {
+	tr := newPrefixRewriter([]config.KeyRewrite{{From: "old:", To: "new:"}, {From: "o", To: "x"}})
+
+	entry := &RDBEntry{Key: "old:user:1"}
+	tr.TransformEntry(entry)
+	if entry.Key != "new:user:1" {
+		t.Errorf("TransformEntry key = %q, want new:user:1", entry.Key)
+	}
+
+	cases := []struct {
+		cmd  string
+		args []string
+		want []string
+	}{
+		{"SET", []string{"old:a", "old:value"}, []string{"new:a", "old:value"}},
+		{"MSET", []string{"old:a", "old:1", "other", "2"}, []string{"new:a", "old:1", "xther", "2"}},
+		{"DEL", []string{"old:a", "keep", "old:b"}, []string{"new:a", "keep", "new:b"}},
+		{"RENAME", []string{"old:a", "old:b"}, []string{"new:a", "new:b"}},
+		{"BITOP", []string{"AND", "old:d", "old:s"}, []string{"AND", "new:d", "new:s"}},
+		{"ZUNIONSTORE", []string{"old:d", "2", "old:a", "old:b", "WEIGHTS", "1", "2"},
+			[]string{"new:d", "2", "new:a", "new:b", "WEIGHTS", "1", "2"}},
+		{"HSET", []string{"keep", "old:field", "v"}, []string{"keep", "old:field", "v"}},
+		{"ZRANGESTORE", []string{"old:d", "old:s", "0", "-1"}, []string{"new:d", "new:s", "0", "-1"}},
+		{"GEOSEARCHSTORE", []string{"old:d", "old:s", "FROMMEMBER", "old:m", "BYRADIUS", "1", "km"},
+			[]string{"new:d", "new:s", "FROMMEMBER", "old:m", "BYRADIUS", "1", "km"}},
+		{"SORT", []string{"old:l", "BY", "old:w_*", "STORE", "old:d"}, []string{"new:l", "BY", "old:w_*", "STORE", "new:d"}},
+		{"LMPOP", []string{"2", "old:a", "old:b", "LEFT", "COUNT", "2"}, []string{"2", "new:a", "new:b", "LEFT", "COUNT", "2"}},
+		{"BZMPOP", []string{"0", "1", "old:z", "MIN"}, []string{"0", "1", "new:z", "MIN"}},
+		{"BLPOP", []string{"old:a", "old:b", "0"}, []string{"new:a", "new:b", "0"}},
+		{"DFLYCLUSTER", []string{"FLUSHSLOTS", "0", "100"}, []string{"FLUSHSLOTS", "0", "100"}},
+		{"PUBLISH", []string{"old:chan", "old:msg"}, []string{"old:chan", "old:msg"}},
+	}
+	for _, c := range cases {
+		orig := append([]string(nil), c.args...)
+		got := tr.TransformCommand(c.cmd, c.args)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s %v -> %v, want %v", c.cmd, orig, got, c.want)
+		}
+		if !reflect.DeepEqual(c.args, orig) {
+			t.Errorf("%s modified its input args: %v", c.cmd, c.args)
+		}
+	}
+
+	if newPrefixRewriter(nil) != nil {
+		t.Error("newPrefixRewriter(nil) should disable rewriting")
+	}
+}
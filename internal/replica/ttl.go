@@ -0,0 +1,58 @@
+package replica
+
+import (
+	"strconv"
+	"strings"
+)
+
+// expireArgs builds the key-level TTL command for an absolute expiry.
+// Relative mode (default) sends PEXPIRE with the time left on this host's
+// clock; absolute mode (conflict.ttlMode: absolute) sends PEXPIREAT so the
+// target's clock decides. A TTL that already ran out becomes 1ms so the key
+// still expires instead of living forever.
+func expireArgs(key string, expireMs int64, absolute bool) []interface{} {
+	if absolute {
+		return []interface{}{"PEXPIREAT", key, strconv.FormatInt(expireMs, 10)}
+	}
+	return []interface{}{"PEXPIRE", key, strconv.FormatInt(remainingTTL(expireMs), 10)}
+}
+
+// remainingTTL converts an absolute expiry into milliseconds left (at least 1).
+func remainingTTL(expireMs int64) int64 {
+	if remaining := expireMs - getCurrentTimeMillis(); remaining > 0 {
+		return remaining
+	}
+	return 1
+}
+
+// normalizeExpire rewrites EXPIRE, PEXPIRE and EXPIREAT journal commands into
+// PEXPIREAT with an absolute millisecond deadline, relative TTLs counting from
+// nowMs (when the entry was received). Options such as NX/GT are kept.
+// ok is false when the command is not in the expire family or its TTL is not
+// an integer; such commands are replayed unchanged.
+func normalizeExpire(cmd string, args []string, nowMs int64) (string, []string, bool) {
+	if len(args) < 2 {
+		return cmd, args, false
+	}
+	n, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return cmd, args, false
+	}
+	var deadline int64
+	switch strings.ToUpper(cmd) {
+	case "EXPIRE":
+		deadline = nowMs + n*1000
+	case "PEXPIRE":
+		deadline = nowMs + n
+	case "EXPIREAT":
+		deadline = n * 1000
+	case "PEXPIREAT":
+		deadline = n
+	default:
+		return cmd, args, false
+	}
+	out := make([]string, len(args))
+	copy(out, args)
+	out[1] = strconv.FormatInt(deadline, 10)
+	return "PEXPIREAT", out, true
+}
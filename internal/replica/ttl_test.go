@@ -0,0 +1,41 @@
+package replica
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeExpire(t *testing.T) {
+	const now = int64(1_700_000_000_000)
+	cases := []struct {
+		cmd  string
+		args []string
+		want []string
+	}{
+		{"EXPIRE", []string{"k", "10"}, []string{"k", "1700000010000"}},
+		{"expire", []string{"k", "10", "NX"}, []string{"k", "1700000010000", "NX"}},
+		{"PEXPIRE", []string{"k", "1500"}, []string{"k", "1700000001500"}},
+		{"EXPIREAT", []string{"k", "1800000000", "GT"}, []string{"k", "1800000000000", "GT"}},
+		{"PEXPIREAT", []string{"k", "1800000000123"}, []string{"k", "1800000000123"}},
+		{"EXPIRE", []string{"k", "-1"}, []string{"k", "1699999999000"}},
+	}
+	for _, c := range cases {
+		cmd, args, ok := normalizeExpire(c.cmd, c.args, now)
+		if !ok || cmd != "PEXPIREAT" || !reflect.DeepEqual(args, c.want) {
+			t.Errorf("%s %v -> %s %v (ok=%v), want PEXPIREAT %v", c.cmd, c.args, cmd, args, ok, c.want)
+		}
+	}
+
+	for _, c := range []struct {
+		cmd  string
+		args []string
+	}{
+		{"SET", []string{"k", "10"}},
+		{"EXPIRE", []string{"k"}},
+		{"EXPIRE", []string{"k", "soon"}},
+	} {
+		if cmd, args, ok := normalizeExpire(c.cmd, c.args, now); ok || cmd != c.cmd || !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%s %v should be left unchanged, got %s %v", c.cmd, c.args, cmd, args)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package replica
+
+import "strings"
+
+// typeFilter restricts a full sync to some data types (migrate.includeTypes).
+// A nil filter lets every type through.
+type typeFilter struct {
+	include map[string]bool
+}
+
+func newTypeFilter(types []string) *typeFilter {
+	if len(types) == 0 {
+		return nil
+	}
+	f := &typeFilter{include: make(map[string]bool, len(types))}
+	for _, t := range types {
+		f.include[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+	return f
+}
+
+// Allowed reports whether snapshot entries of RDB type t are written.
+func (f *typeFilter) Allowed(t byte) bool {
+	if f == nil {
+		return true
+	}
+	return f.include[rdbTypeFamily(t)]
+}
+
+// rdbTypeFamily maps an RDB type byte to the data type name used by
+// migrate.includeTypes: string, hash, list, set, zset, stream or bloom.
+func rdbTypeFamily(t byte) string {
+	switch t {
+	case RDB_TYPE_SBF:
+		return "bloom"
+	case RDB_TYPE_MODULE, RDB_TYPE_MODULE_2:
+		return "module"
+	case RDB_TYPE_JSON:
+		return "json"
+	}
+	// RDBTypeName labels are "<type> (<encoding>)"
+	name, _, _ := strings.Cut(RDBTypeName(t), " ")
+	return name
+}
+
+func (r *Replicator) countTypeFiltered(entry *RDBEntry) {
+	r.skippedKeys.Record(entry, skipReasonFiltered)
+	r.rdbStats.mu.Lock()
+	r.rdbStats.TypeFiltered++
+	r.rdbStats.mu.Unlock()
+}
@@ -0,0 +1,25 @@
+package replica
+
+import "testing"
+
+func TestTypeFilter(t *testing.T) {
+	var all *typeFilter
+	if !all.Allowed(RDB_TYPE_ZSET_LISTPACK) {
+		t.Fatal("nil filter must allow every type")
+	}
+
+	f := newTypeFilter([]string{"string", " Hash "})
+	for _, typ := range []byte{RDB_TYPE_STRING, RDB_TYPE_HASH_LISTPACK, RDB_TYPE_HASH_LISTPACK_EX} {
+		if !f.Allowed(typ) {
+			t.Errorf("%s should be allowed", RDBTypeName(typ))
+		}
+	}
+	for _, typ := range []byte{RDB_TYPE_SET_INTSET, RDB_TYPE_ZSET_2, RDB_TYPE_LIST_QUICKLIST_2, RDB_TYPE_STREAM_LISTPACKS, RDB_TYPE_SBF} {
+		if f.Allowed(typ) {
+			t.Errorf("%s should be filtered", RDBTypeName(typ))
+		}
+	}
+	if got := rdbTypeFamily(RDB_TYPE_SBF); got != "bloom" {
+		t.Errorf("rdbTypeFamily(SBF) = %q, want bloom", got)
+	}
+}
@@ -0,0 +1,78 @@
+package replica
+
+import (
+	"fmt"
+	"sync"
+)
+
+// minRateSample is the number of attempted writes required before
+// migrate.maxWriteFailureRate is enforced, so a few early failures on a
+// small sample don't abort the run.
+const minRateSample = 1000
+
+// writeBudget counts attempted and failed writes across all FLOWs and trips
+// once migrate.maxWriteFailures or migrate.maxWriteFailureRate is exceeded.
+// A nil budget never trips.
+type writeBudget struct {
+	maxFailures int
+	maxRate     float64
+
+	mu       sync.Mutex
+	attempts int64
+	failures int64
+	err      error
+}
+
+func newWriteBudget(maxFailures int, maxRate float64) *writeBudget {
+	if maxFailures <= 0 && maxRate <= 0 {
+		return nil
+	}
+	return &writeBudget{maxFailures: maxFailures, maxRate: maxRate}
+}
+
+// Record adds a batch of write results. It returns the abort error only on
+// the call that exceeds the threshold; use Err afterwards.
+func (b *writeBudget) Record(success, failed int) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.attempts += int64(success + failed)
+	b.failures += int64(failed)
+	if b.err != nil {
+		return nil
+	}
+
+	switch {
+	case b.maxFailures > 0 && b.failures > int64(b.maxFailures):
+		b.err = fmt.Errorf("write failures exceeded migrate.maxWriteFailures=%d: %s",
+			b.maxFailures, b.summaryLocked())
+	case b.maxRate > 0 && b.attempts >= minRateSample && b.rateLocked() > b.maxRate:
+		b.err = fmt.Errorf("write failure rate exceeded migrate.maxWriteFailureRate=%.4g: %s",
+			b.maxRate, b.summaryLocked())
+	}
+	return b.err
+}
+
+// Err returns the abort error, or nil while the budget holds.
+func (b *writeBudget) Err() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+func (b *writeBudget) rateLocked() float64 {
+	if b.attempts == 0 {
+		return 0
+	}
+	return float64(b.failures) / float64(b.attempts)
+}
+
+func (b *writeBudget) summaryLocked() string {
+	return fmt.Sprintf("%d of %d writes failed (%.2f%%)",
+		b.failures, b.attempts, b.rateLocked()*100)
+}
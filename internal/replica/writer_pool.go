@@ -0,0 +1,93 @@
+package replica
+
+import (
+	"log"
+	"sync"
+
+	"df2redis/internal/config"
+)
+
+// defaultWriterPoolSize is the shared write budget used when
+// migrate.globalMaxConcurrentWrites is not set. It matches the old
+// per-FLOW split (400 total for cluster, one pipeline connection for standalone).
+func defaultWriterPoolSize(targetType string) int {
+	if targetType == "redis-standalone" || targetType == "redis" {
+		return 50
+	}
+	return 400
+}
+
+// newSharedWriterPool sizes the pool from migrate.globalMaxConcurrentWrites:
+// unset uses defaultWriterPoolSize, an explicit 0 means no global cap and
+// returns nil, so every FlowWriter writes on its own goroutines.
+func newSharedWriterPool(cfg *config.Config) *WriterPool {
+	limit := cfg.Migrate.GlobalMaxConcurrentWrites
+	if limit == nil {
+		return NewWriterPool(defaultWriterPoolSize(cfg.Target.Type))
+	}
+	if *limit == 0 {
+		log.Printf("  • No global write cap (migrate.globalMaxConcurrentWrites=0), FLOWs write independently")
+		return nil
+	}
+	return NewWriterPool(*limit)
+}
+
+// WriterPool is a bounded set of write workers shared by every FlowWriter.
+// FLOWs submit pipeline-sized chunks instead of writing them on their own
+// goroutines, so an idle worker picks up whichever FLOW has work queued and a
+// busy shard can use capacity the others leave idle.
+type WriterPool struct {
+	jobs   chan func()
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewWriterPool starts workers goroutines draining a shared job queue.
+func NewWriterPool(workers int) *WriterPool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &WriterPool{jobs: make(chan func(), workers*2)}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer p.wg.Done()
+			for job := range p.jobs {
+				job()
+			}
+		}()
+	}
+	log.Printf("  • Shared writer pool started with %d workers", workers)
+	return p
+}
+
+// Submit queues job for the next free worker, blocking while the queue is
+// full. After Close the job runs on the caller's goroutine instead.
+func (p *WriterPool) Submit(job func()) {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		job()
+		return
+	}
+	p.jobs <- job
+	p.mu.RUnlock()
+}
+
+// Close stops accepting jobs and waits for queued ones to finish. Safe to call
+// more than once, and on a nil pool.
+func (p *WriterPool) Close() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.jobs)
+	p.mu.Unlock()
+	p.wg.Wait()
+}
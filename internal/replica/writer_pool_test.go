@@ -0,0 +1,27 @@
+package replica
+
+import (
+	"testing"
+
+	"df2redis/internal/config"
+)
+
+func TestSharedWriterPoolSize(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Target.Type = "redis-cluster"
+
+	pool := newSharedWriterPool(cfg)
+	if pool == nil {
+		t.Fatal("unset globalMaxConcurrentWrites should use the default pool")
+	}
+	pool.Close()
+
+	zero := 0
+	cfg.Migrate.GlobalMaxConcurrentWrites = &zero
+	if pool := newSharedWriterPool(cfg); pool != nil {
+		pool.Close()
+		t.Fatal("globalMaxConcurrentWrites=0 means no global cap, got a pool")
+	}
+	var none *WriterPool
+	none.Close() // callers defer Close unconditionally
+}
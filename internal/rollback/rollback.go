@@ -0,0 +1,260 @@
+package rollback
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"df2redis/internal/config"
+	"df2redis/internal/redisx"
+	"df2redis/internal/state"
+)
+
+// Options controls how far the rollback goes.
+type Options struct {
+	// FlushTarget deletes migrated keys from the Redis target.
+	FlushTarget bool
+	// Match scopes the target flush (SCAN MATCH pattern). Required with FlushTarget.
+	Match string
+	// StopTimeout bounds how long we wait for a running replicator to stop.
+	StopTimeout time.Duration
+}
+
+// Marker is persisted to stateDir/rollback.json so a later run can tell
+// what was rolled back and resume from the retained checkpoint.
+type Marker struct {
+	RolledBackAt   time.Time `json:"rolledBackAt"`
+	SourceAddr     string    `json:"sourceAddr"`
+	SourceRole     string    `json:"sourceRole"`
+	PreviousStatus string    `json:"previousStatus"`
+	ReplicatorStop bool      `json:"replicatorStopped"`
+	TargetFlushed  bool      `json:"targetFlushed"`
+	FlushPattern   string    `json:"flushPattern,omitempty"`
+	DeletedKeys    int64     `json:"deletedKeys"`
+	CheckpointPath string    `json:"checkpointPath,omitempty"`
+	Resumable      bool      `json:"resumable"`
+}
+
+// activeStatuses lists pipeline states that mean a replicator is still running.
+var activeStatuses = map[string]bool{
+	"starting":    true,
+	"handshake":   true,
+	"full_sync":   true,
+	"incremental": true,
+}
+
+// Run verifies that Dragonfly can take traffic back, stops a running
+// replicator, optionally flushes the target and writes the rollback marker.
+func Run(ctx context.Context, cfg *config.Config, store *state.Store, opts Options) (*Marker, error) {
+	if opts.FlushTarget && strings.TrimSpace(opts.Match) == "" {
+		return nil, errors.New("flushing the target requires an explicit key pattern (use '*' to flush everything)")
+	}
+	if opts.StopTimeout <= 0 {
+		opts.StopTimeout = 60 * time.Second
+	}
+
+	snap, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("read status file: %w", err)
+	}
+	marker := &Marker{
+		SourceAddr:     cfg.Source.Addr,
+		PreviousStatus: snap.PipelineStatus,
+	}
+
+	// 1. Dragonfly must still be reachable and primary, otherwise there is nothing to roll back to.
+	role, err := sourceRole(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("rollback not possible, Dragonfly source %s is unreachable: %w", cfg.Source.Addr, err)
+	}
+	marker.SourceRole = role
+	if role != "master" {
+		return nil, fmt.Errorf("rollback not possible, Dragonfly source %s reports role=%s (expected master)", cfg.Source.Addr, role)
+	}
+	log.Printf("✓ Dragonfly source %s is reachable (role=%s)", cfg.Source.Addr, role)
+
+	if err := store.SetPipelineStatus("rolling_back", "Starting rollback process"); err != nil {
+		return nil, fmt.Errorf("update status: %w", err)
+	}
+
+	// 2. Stop the running replicator, if any.
+	if activeStatuses[snap.PipelineStatus] {
+		log.Printf("→ Replicator is %s, requesting stop...", snap.PipelineStatus)
+		if err := requestStop(ctx, cfg, store, opts.StopTimeout); err != nil {
+			return nil, err
+		}
+		marker.ReplicatorStop = true
+		log.Printf("✓ Replicator stopped")
+	}
+
+	// 3. Optionally remove migrated keys from the target.
+	if opts.FlushTarget {
+		deleted, err := flushTarget(ctx, cfg, opts.Match)
+		if err != nil {
+			return nil, fmt.Errorf("flush target: %w", err)
+		}
+		marker.TargetFlushed = true
+		marker.FlushPattern = opts.Match
+		marker.DeletedKeys = deleted
+		log.Printf("✓ Removed %d keys matching %q from target", deleted, opts.Match)
+	}
+
+	// 4. Record a resumable marker. The checkpoint is kept so replication can be resumed.
+	cpPath := cfg.ResolveCheckpointPath()
+	if _, err := os.Stat(cpPath); err == nil {
+		marker.CheckpointPath = cpPath
+		marker.Resumable = !marker.TargetFlushed
+	}
+	marker.RolledBackAt = time.Now()
+	if err := writeMarker(cfg.RollbackMarkerPath(), marker); err != nil {
+		return nil, err
+	}
+
+	msg := "Rollback complete; Dragonfly remains primary"
+	if marker.TargetFlushed {
+		msg = fmt.Sprintf("Rollback complete; removed %d target keys matching %q", marker.DeletedKeys, marker.FlushPattern)
+	}
+	if err := store.SetPipelineStatus("rolled_back", msg); err != nil {
+		return nil, fmt.Errorf("update status: %w", err)
+	}
+	return marker, nil
+}
+
+// sourceRole returns the replication role reported by the Dragonfly source.
+func sourceRole(ctx context.Context, cfg *config.Config) (string, error) {
+	client, err := redisx.Dial(ctx, redisx.Config{
+		Addr:        cfg.Source.Addr,
+		Password:    cfg.Source.Password,
+		TLS:         cfg.Source.TLS,
+		TLSCAFile:   cfg.Source.TLSCAFile,
+		TLSInsecure: cfg.Source.TLSInsecure,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	info, err := client.Info("replication")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "role:") {
+			return strings.TrimPrefix(line, "role:"), nil
+		}
+	}
+	return "", errors.New("INFO replication did not report a role")
+}
+
+// requestStop writes the stop request marker and waits for the replicator
+// to leave its active state.
+func requestStop(ctx context.Context, cfg *config.Config, store *state.Store, timeout time.Duration) error {
+	path := cfg.StopRequestPath()
+	if err := os.WriteFile(path, []byte(time.Now().Format(time.RFC3339)), 0o644); err != nil {
+		return fmt.Errorf("write stop request: %w", err)
+	}
+	defer os.Remove(path)
+
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		snap, err := store.Load()
+		if err == nil && !activeStatuses[snap.PipelineStatus] {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("replicator did not stop within %v (status=%s); stop it manually and retry", timeout, snap.PipelineStatus)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// flushTarget deletes keys matching pattern on every target master.
+func flushTarget(ctx context.Context, cfg *config.Config, pattern string) (int64, error) {
+	seeds := cfg.Target.Cluster.Seeds
+	if len(seeds) == 0 {
+		seeds = []string{cfg.Target.Addr}
+	}
+
+	var (
+		cc  *redisx.ClusterClient
+		err error
+	)
+	if strings.Contains(strings.ToLower(cfg.Target.Type), "cluster") {
+		cc, err = redisx.DialClusterWithOptions(ctx, seeds, cfg.Target.Password, cfg.Target.ClusterOptions())
+	} else {
+		cc, err = redisx.DialStandaloneWithOptions(ctx, seeds[0], cfg.Target.Password, cfg.Target.ClusterOptions())
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer cc.Close()
+
+	var deleted int64
+	err = cc.ForEachMaster(func(client *redisx.Client) error {
+		cursor := "0"
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			reply, err := client.Do("SCAN", cursor, "MATCH", pattern, "COUNT", 1000)
+			if err != nil {
+				return err
+			}
+			arr, ok := reply.([]interface{})
+			if !ok || len(arr) != 2 {
+				return fmt.Errorf("unexpected SCAN reply %T", reply)
+			}
+			next, err := redisx.ToString(arr[0])
+			if err != nil {
+				return err
+			}
+			keys, err := redisx.ToStringSlice(arr[1])
+			if err != nil {
+				return err
+			}
+			// Delete one key per command so cluster slot rules never get in the way.
+			for _, key := range keys {
+				n, err := client.Do("UNLINK", key)
+				if err != nil {
+					return err
+				}
+				if c, convErr := redisx.ToInt64(n); convErr == nil {
+					deleted += c
+				}
+			}
+			if next == "0" {
+				return nil
+			}
+			cursor = next
+		}
+	})
+	return deleted, err
+}
+
+func writeMarker(path string, marker *Marker) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create state dir: %w", err)
+	}
+	data, err := json.MarshalIndent(marker, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write rollback marker: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -6,29 +6,50 @@ const (
 	MetricTargetKeysCurrent     = "target.keys.current"
 	MetricSyncedKeys            = "sync.keys.applied"
 	MetricFlowImportedFormat    = "flow.%d.imported_keys"
+	MetricFlowLSNFormat         = "flow.%d.lsn"
 	MetricCheckpointSavedAtUnix = "checkpoint.last_saved_unix"
 
+	// FlowWriter backpressure (a full channel stalls the FLOW read and Dragonfly's heartbeat)
+	MetricFlowQueueLenFormat         = "flow.%d.queue.len"
+	MetricFlowQueueCapFormat         = "flow.%d.queue.cap"
+	MetricFlowEnqueueBlockedFormat   = "flow.%d.enqueue.blocked"
+	MetricFlowEnqueueBlockedMsFormat = "flow.%d.enqueue.blocked_ms"
+
+	// Estimated payload bytes flushed by each FlowWriter (byte throughput)
+	MetricFlowBytesWrittenFormat = "flow.%d.bytes.written"
+
+	// Journal position of the last command read from each FLOW and of the
+	// last one written to the target; equal once the FLOW is caught up
+	MetricFlowReceivedLSNFormat = "flow.%d.lsn.received"
+	MetricFlowAppliedLSNFormat  = "flow.%d.lsn.applied"
+
 	// RDB phase metrics (snapshot import)
-	MetricRdbOpsTotal          = "sync.rdb.ops.total"
-	MetricRdbOpsSuccess        = "sync.rdb.ops.success"
-	MetricRdbInlineJournalOps  = "sync.rdb.inline_journal.ops" // Inline journal entries applied during RDB
+	MetricRdbOpsTotal         = "sync.rdb.ops.total"
+	MetricRdbOpsSuccess       = "sync.rdb.ops.success"
+	MetricRdbInlineJournalOps = "sync.rdb.inline_journal.ops" // Inline journal entries applied during RDB
 
 	// Incremental phase metrics (journal streaming)
-	MetricIncrementalLSNCurrent = "sync.incremental.lsn.current"
-	MetricIncrementalLSNApplied = "sync.incremental.lsn.applied"
-	MetricIncrementalLagMs      = "sync.incremental.lag.ms"
-	MetricIncrementalOpsTotal   = "sync.incremental.ops.total"
-	MetricIncrementalOpsSuccess = "sync.incremental.ops.success"
-	MetricIncrementalOpsSkipped = "sync.incremental.ops.skipped"
-	MetricIncrementalOpsFailed  = "sync.incremental.ops.failed"
+	MetricIncrementalLSNCurrent   = "sync.incremental.lsn.current"
+	MetricIncrementalLSNApplied   = "sync.incremental.lsn.applied"
+	MetricIncrementalLagMs        = "sync.incremental.lag.ms"
+	MetricIncrementalOpsTotal     = "sync.incremental.ops.total"
+	MetricIncrementalOpsSuccess   = "sync.incremental.ops.success"
+	MetricIncrementalOpsSkipped   = "sync.incremental.ops.skipped"
+	MetricIncrementalOpsFailed    = "sync.incremental.ops.failed"
+	MetricIncrementalOpsBlocked   = "sync.incremental.ops.blocked"   // Rejected by the command allow/deny list
+	MetricIncrementalOpsDuplicate = "sync.incremental.ops.duplicate" // Dropped: LSN already applied
+
+	// Journal entries read but not written yet
+	MetricRetryQueueLen    = "sync.incremental.retry_queue.len"    // waiting for the target to recover
+	MetricReorderBufferLen = "sync.incremental.reorder_buffer.len" // held for cross-FLOW TxID ordering
 
 	// Performance metrics (QPS and Latency)
-	MetricQPSCurrent     = "perf.qps.current"
-	MetricQPSPeak        = "perf.qps.peak"
-	MetricQPSAvg         = "perf.qps.avg"
-	MetricLatencyP50     = "perf.latency.p50"
-	MetricLatencyP95     = "perf.latency.p95"
-	MetricLatencyP99     = "perf.latency.p99"
-	MetricLatencyAvg     = "perf.latency.avg"
-	MetricLatencyMax     = "perf.latency.max"
+	MetricQPSCurrent = "perf.qps.current"
+	MetricQPSPeak    = "perf.qps.peak"
+	MetricQPSAvg     = "perf.qps.avg"
+	MetricLatencyP50 = "perf.latency.p50"
+	MetricLatencyP95 = "perf.latency.p95"
+	MetricLatencyP99 = "perf.latency.p99"
+	MetricLatencyAvg = "perf.latency.avg"
+	MetricLatencyMax = "perf.latency.max"
 )
@@ -19,9 +19,20 @@ type StageSnapshot struct {
 type Event struct {
 	Timestamp time.Time `json:"timestamp"`
 	Type      string    `json:"type"`
+	Severity  string    `json:"severity,omitempty"` // info, warn or error
 	Message   string    `json:"message"`
 }
 
+// Event severities.
+const (
+	SeverityInfo  = "info"
+	SeverityWarn  = "warn"
+	SeverityError = "error"
+)
+
+// MaxEvents caps the timeline; older events are dropped first.
+const MaxEvents = 200
+
 // Snapshot is the persisted status structure.
 type Snapshot struct {
 	PipelineStatus string                   `json:"pipelineStatus"`
@@ -148,15 +159,45 @@ func (s *Store) SetPipelineStatus(status string, message string) error {
 	}
 	snap.PipelineStatus = status
 	if message != "" {
-		snap.Events = append(snap.Events, Event{
+		severity := SeverityInfo
+		if status == "error" {
+			severity = SeverityError
+		}
+		appendEvent(&snap, Event{
 			Timestamp: time.Now(),
 			Type:      status,
+			Severity:  severity,
 			Message:   message,
 		})
 	}
 	return s.write(snap)
 }
 
+// AddEvent appends a timeline event, keeping only the last MaxEvents.
+func (s *Store) AddEvent(severity, eventType, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap, err := s.load()
+	if err != nil {
+		return err
+	}
+	appendEvent(&snap, Event{
+		Timestamp: time.Now(),
+		Type:      eventType,
+		Severity:  severity,
+		Message:   message,
+	})
+	return s.write(snap)
+}
+
+func appendEvent(snap *Snapshot, ev Event) {
+	snap.Events = append(snap.Events, ev)
+	if n := len(snap.Events) - MaxEvents; n > 0 {
+		snap.Events = append([]Event(nil), snap.Events[n:]...)
+	}
+}
+
 // RecordMetric stores numeric metrics.
 func (s *Store) RecordMetric(name string, value float64) error {
 	s.mu.Lock()
@@ -0,0 +1,308 @@
+// Package tracing emits OpenTelemetry-compatible spans over OTLP/HTTP (JSON
+// encoding), so handshake, snapshot and write timings can be exported to any
+// OTLP collector without pulling the OTel SDK into the build.
+// Tracing is off unless Init is called with an endpoint; every span method is
+// a no-op on a nil *Span.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	exportInterval  = 5 * time.Second
+	exportBatchSize = 512
+	queueSize       = 8192
+)
+
+// Span is a single timed operation.
+type Span struct {
+	exp      *exporter
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+
+	mu     sync.Mutex
+	end    time.Time
+	attrs  []attribute
+	errMsg string
+	ended  bool
+}
+
+type attribute struct {
+	key   string
+	value interface{}
+}
+
+type spanCtxKey struct{}
+
+var (
+	globalMu  sync.RWMutex
+	globalExp *exporter
+)
+
+// Init starts exporting spans to endpoint (e.g. "http://localhost:4318").
+// An empty endpoint disables tracing. The returned function flushes pending
+// spans and must be called before exit.
+func Init(endpoint, serviceName string) func() {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return func() {}
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "http://" + endpoint
+	}
+	if serviceName == "" {
+		serviceName = "df2redis"
+	}
+
+	exp := &exporter{
+		url:         strings.TrimRight(endpoint, "/") + "/v1/traces",
+		serviceName: serviceName,
+		queue:       make(chan *Span, queueSize),
+		done:        make(chan struct{}),
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+	exp.wg.Add(1)
+	go exp.run()
+
+	globalMu.Lock()
+	globalExp = exp
+	globalMu.Unlock()
+	log.Printf("📡 Tracing enabled: exporting spans to %s", exp.url)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			globalMu.Lock()
+			globalExp = nil
+			globalMu.Unlock()
+			close(exp.done)
+			exp.wg.Wait()
+		})
+	}
+}
+
+// Start opens a span as a child of the span carried by ctx (if any).
+// It returns ctx unchanged and a nil span when tracing is disabled.
+func Start(ctx context.Context, name string, kv ...interface{}) (context.Context, *Span) {
+	globalMu.RLock()
+	exp := globalExp
+	globalMu.RUnlock()
+	if exp == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s := &Span{exp: exp, name: name, start: time.Now()}
+	if parent := FromContext(ctx); parent != nil {
+		s.traceID = parent.traceID
+		s.parentID = parent.spanID
+	} else {
+		rand.Read(s.traceID[:])
+	}
+	rand.Read(s.spanID[:])
+	s.SetAttributes(kv...)
+	return context.WithValue(ctx, spanCtxKey{}, s), s
+}
+
+// FromContext returns the span stored in ctx, or nil.
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(spanCtxKey{}).(*Span)
+	return s
+}
+
+// SetAttributes records key/value pairs: SetAttributes("flow.id", 3, "batch.size", 500).
+func (s *Span) SetAttributes(kv ...interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i+1 < len(kv); i += 2 {
+		s.attrs = append(s.attrs, attribute{key: fmt.Sprint(kv[i]), value: kv[i+1]})
+	}
+}
+
+// RecordError marks the span as failed. A nil error is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.errMsg = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it for export. Extra calls are ignored.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	select {
+	case s.exp.queue <- s:
+	default:
+		// Never block the data path on a slow collector
+	}
+}
+
+// exporter batches ended spans and posts them as OTLP/HTTP JSON.
+type exporter struct {
+	url         string
+	serviceName string
+	queue       chan *Span
+	done        chan struct{}
+	wg          sync.WaitGroup
+	client      *http.Client
+	warned      bool
+}
+
+func (e *exporter) run() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, exportBatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			e.export(batch)
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-e.done:
+			for {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (e *exporter) export(spans []*Span) {
+	otlpSpans := make([]map[string]interface{}, 0, len(spans))
+	for _, s := range spans {
+		otlpSpans = append(otlpSpans, s.otlp())
+	}
+	payload := map[string]interface{}{
+		"resourceSpans": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": []interface{}{otlpAttr("service.name", e.serviceName)},
+				},
+				"scopeSpans": []interface{}{
+					map[string]interface{}{
+						"scope": map[string]interface{}{"name": "df2redis"},
+						"spans": otlpSpans,
+					},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = fmt.Errorf("collector returned %s", resp.Status)
+		}
+	}
+	if err != nil && !e.warned {
+		// Warn once; a missing collector must not flood the migration logs
+		e.warned = true
+		log.Printf("⚠ Tracing export to %s failed (further failures suppressed): %v", e.url, err)
+	}
+}
+
+func (s *Span) otlp() map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attrs := make([]interface{}, 0, len(s.attrs))
+	for _, a := range s.attrs {
+		attrs = append(attrs, otlpAttr(a.key, a.value))
+	}
+	span := map[string]interface{}{
+		"traceId":           hex.EncodeToString(s.traceID[:]),
+		"spanId":            hex.EncodeToString(s.spanID[:]),
+		"name":              s.name,
+		"kind":              1, // SPAN_KIND_INTERNAL
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+		"attributes":        attrs,
+	}
+	if s.parentID != [8]byte{} {
+		span["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+	}
+	if s.errMsg != "" {
+		span["status"] = map[string]interface{}{"code": 2, "message": s.errMsg} // STATUS_CODE_ERROR
+	}
+	return span
+}
+
+func otlpAttr(key string, value interface{}) map[string]interface{} {
+	var v map[string]interface{}
+	switch x := value.(type) {
+	case string:
+		v = map[string]interface{}{"stringValue": x}
+	case bool:
+		v = map[string]interface{}{"boolValue": x}
+	case int:
+		v = map[string]interface{}{"intValue": strconv.FormatInt(int64(x), 10)}
+	case int64:
+		v = map[string]interface{}{"intValue": strconv.FormatInt(x, 10)}
+	case uint64:
+		v = map[string]interface{}{"intValue": strconv.FormatUint(x, 10)}
+	case float64:
+		v = map[string]interface{}{"doubleValue": x}
+	case time.Duration:
+		v = map[string]interface{}{"doubleValue": float64(x) / float64(time.Millisecond)}
+	default:
+		v = map[string]interface{}{"stringValue": fmt.Sprint(x)}
+	}
+	return map[string]interface{}{"key": key, "value": v}
+}
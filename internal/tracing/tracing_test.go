@@ -0,0 +1,152 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// otlpPayload is the subset of an OTLP/HTTP JSON export the tests inspect.
+type otlpPayload struct {
+	ResourceSpans []struct {
+		Resource struct {
+			Attributes []otlpKV `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			Spans []otlpSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+type otlpSpan struct {
+	TraceID      string   `json:"traceId"`
+	SpanID       string   `json:"spanId"`
+	ParentSpanID string   `json:"parentSpanId"`
+	Name         string   `json:"name"`
+	Start        string   `json:"startTimeUnixNano"`
+	End          string   `json:"endTimeUnixNano"`
+	Attributes   []otlpKV `json:"attributes"`
+	Status       *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+type otlpKV struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+// collector records every export posted to /v1/traces.
+func collector(t *testing.T) (*httptest.Server, func() []otlpPayload) {
+	var mu sync.Mutex
+	var got []otlpPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.URL.Path != "/v1/traces" {
+			t.Errorf("collector got %s %s, want POST /v1/traces", req.Method, req.URL.Path)
+		}
+		if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		body, _ := io.ReadAll(req.Body)
+		var p otlpPayload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Errorf("decode export: %v\n%s", err, body)
+		}
+		mu.Lock()
+		got = append(got, p)
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []otlpPayload {
+		mu.Lock()
+		defer mu.Unlock()
+		return got
+	}
+}
+
+func TestExportParentAndChildSpans(t *testing.T) {
+	srv, exports := collector(t)
+	shutdown := Init(srv.URL+"/", "df2redis-test")
+
+	ctx, parent := Start(context.Background(), "handshake", "source.addr", "10.0.0.1:6379")
+	_, child := Start(ctx, "handshake.flow", "flow.id", 3, "ok", true)
+	child.RecordError(errors.New("boom"))
+	child.End()
+	parent.End()
+	parent.End() // extra calls are ignored
+	shutdown()
+
+	var spans []otlpSpan
+	for _, p := range exports() {
+		if len(p.ResourceSpans) != 1 || len(p.ResourceSpans[0].ScopeSpans) != 1 {
+			t.Fatalf("export shape = %+v, want one resource and one scope", p)
+		}
+		rs := p.ResourceSpans[0]
+		if len(rs.Resource.Attributes) != 1 || rs.Resource.Attributes[0].Key != "service.name" ||
+			rs.Resource.Attributes[0].Value["stringValue"] != "df2redis-test" {
+			t.Fatalf("resource attributes = %+v", rs.Resource.Attributes)
+		}
+		if rs.ScopeSpans[0].Scope.Name != "df2redis" {
+			t.Fatalf("scope = %q, want df2redis", rs.ScopeSpans[0].Scope.Name)
+		}
+		spans = append(spans, rs.ScopeSpans[0].Spans...)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	byName := map[string]otlpSpan{}
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	p, c := byName["handshake"], byName["handshake.flow"]
+
+	if len(p.TraceID) != 32 || len(p.SpanID) != 16 {
+		t.Fatalf("parent IDs = %q/%q, want 16 and 8 hex bytes", p.TraceID, p.SpanID)
+	}
+	if p.ParentSpanID != "" {
+		t.Fatalf("root span has parentSpanId %q", p.ParentSpanID)
+	}
+	if c.TraceID != p.TraceID || c.ParentSpanID != p.SpanID || c.SpanID == p.SpanID {
+		t.Fatalf("child trace/parent/span = %s/%s/%s, want %s/%s/<new>",
+			c.TraceID, c.ParentSpanID, c.SpanID, p.TraceID, p.SpanID)
+	}
+	if p.Start == "" || p.End == "" || p.Status != nil {
+		t.Fatalf("parent span = %+v", p)
+	}
+	if c.Status == nil || c.Status.Code != 2 || c.Status.Message != "boom" {
+		t.Fatalf("child status = %+v, want error boom", c.Status)
+	}
+
+	attrs := map[string]map[string]interface{}{}
+	for _, a := range c.Attributes {
+		attrs[a.Key] = a.Value
+	}
+	if attrs["flow.id"]["intValue"] != "3" || attrs["ok"]["boolValue"] != true {
+		t.Fatalf("child attributes = %+v", c.Attributes)
+	}
+}
+
+func TestDisabledTracingIsNoop(t *testing.T) {
+	Init("", "")()
+
+	ctx := context.Background()
+	got, span := Start(ctx, "snapshot")
+	if span != nil || got != ctx {
+		t.Fatalf("Start with tracing off = %v, %v; want ctx unchanged and a nil span", got, span)
+	}
+	span.SetAttributes("k", "v")
+	span.RecordError(errors.New("ignored"))
+	span.End()
+	if FromContext(got) != nil {
+		t.Fatal("FromContext found a span with tracing off")
+	}
+}
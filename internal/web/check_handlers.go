@@ -77,11 +77,16 @@ func (s *DashboardServer) handleCheckStart(w http.ResponseWriter, r *http.Reques
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
 	s.checkCancel = cancel
+	s.checkDone = done
 	s.checkMu.Unlock()
 
 	// Start validation task
-	go s.runRealCheckTask(ctx, req.CompareMode, req.CompareTimes, req.QPS, req.BatchCount, req.Parallel)
+	go func() {
+		defer close(done)
+		s.runRealCheckTask(ctx, req.CompareMode, req.CompareTimes, req.QPS, req.BatchCount, req.Parallel)
+	}()
 
 	writeJSON(w, map[string]interface{}{
 		"success": true,
@@ -89,6 +94,9 @@ func (s *DashboardServer) handleCheckStart(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+// checkStopTimeout bounds how long a stop request waits for the check to exit.
+const checkStopTimeout = 10 * time.Second
+
 // handleCheckStop handles POST /api/check/stop
 func (s *DashboardServer) handleCheckStop(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -97,9 +105,8 @@ func (s *DashboardServer) handleCheckStop(w http.ResponseWriter, r *http.Request
 	}
 
 	s.checkMu.Lock()
-	defer s.checkMu.Unlock()
-
 	if !s.checkRunning {
+		s.checkMu.Unlock()
 		writeJSON(w, map[string]interface{}{
 			"success": false,
 			"message": "No validation task is running",
@@ -111,13 +118,32 @@ func (s *DashboardServer) handleCheckStop(w http.ResponseWriter, r *http.Request
 	if s.checkCancel != nil {
 		s.checkCancel()
 	}
+	done := s.checkDone
+	s.checkMu.Unlock()
 
-	s.checkRunning = false
-	if s.checkStatus != nil {
-		s.checkStatus.Running = false
-		s.checkStatus.Message = "Stopped by user"
+	// Wait for the checker to release its connections and goroutines, so a new
+	// check can't start while the old one is still running
+	stopped := true
+	if done != nil {
+		select {
+		case <-done:
+		case <-time.After(checkStopTimeout):
+			stopped = false
+		}
 	}
 
+	s.updateCheckStatus(func(status *CheckStatus) {
+		status.Running = false
+		status.Message = "Stopped by user"
+	})
+
+	if !stopped {
+		writeJSON(w, map[string]interface{}{
+			"success": false,
+			"message": "Stop requested; the validation task is still shutting down",
+		})
+		return
+	}
 	writeJSON(w, map[string]interface{}{
 		"success": true,
 		"message": "Validation task stopped",
@@ -183,18 +209,24 @@ func (s *DashboardServer) runRealCheckTask(ctx context.Context, compareModeInt i
 
 	// Create checker options
 	cfg := checker.Config{
-		SourceAddr:     s.cfg.Source.Addr,
-		SourcePassword: s.cfg.Source.Password,
-		TargetAddr:     s.cfg.Target.Addr,
-		TargetPassword: s.cfg.Target.Password,
-		Mode:           cm,
-		QPS:            qps,
-		Parallel:       parallel,
-		ResultDir:      stateDir,
-		BatchSize:      batchCount,
-		Timeout:        3600,
-		CompareTimes:   compareTimes,
-		TaskName:       "dashboard-check",
+		SourceAddr:        s.cfg.Source.Addr,
+		SourcePassword:    s.cfg.Source.Password,
+		TargetAddr:        s.cfg.Target.Addr,
+		TargetPassword:    s.cfg.Target.Password,
+		SourceTLS:         s.cfg.Source.TLS,
+		SourceTLSCAFile:   s.cfg.Source.TLSCAFile,
+		SourceTLSInsecure: s.cfg.Source.TLSInsecure,
+		TargetTLS:         s.cfg.Target.TLS,
+		TargetTLSCAFile:   s.cfg.Target.TLSCAFile,
+		TargetTLSInsecure: s.cfg.Target.TLSInsecure,
+		Mode:              cm,
+		QPS:               qps,
+		Parallel:          parallel,
+		ResultDir:         stateDir,
+		BatchSize:         batchCount,
+		Timeout:           3600,
+		CompareTimes:      compareTimes,
+		TaskName:          "dashboard-check",
 	}
 
 	c := checker.NewChecker(cfg)
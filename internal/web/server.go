@@ -38,6 +38,7 @@ type DashboardServer struct {
 	checkMu      sync.RWMutex
 	checkRunning bool
 	checkCancel  context.CancelFunc
+	checkDone    chan struct{} // closed when the running check task returns
 	checkStatus  *CheckStatus
 
 	// Dedicated logger for dashboard events
@@ -443,6 +444,12 @@ type flowProgress struct {
 	Message      string    `json:"message,omitempty"`
 	ImportedKeys float64   `json:"importedKeys"`
 	UpdatedAt    time.Time `json:"updatedAt,omitempty"`
+
+	// Writer backpressure: channel occupancy and time the FLOW spent blocked
+	QueueLen        float64 `json:"queueLen"`
+	QueueCap        float64 `json:"queueCap"`
+	BlockedEnqueues float64 `json:"blockedEnqueues"`
+	BlockedMillis   float64 `json:"blockedMillis"`
 }
 
 type incrementalInfo struct {
@@ -574,6 +581,11 @@ func buildFlowStats(snap state.Snapshot) []flowProgress {
 			Message:      info.Message,
 			ImportedKeys: snap.Metrics[metricKey],
 			UpdatedAt:    info.UpdatedAt,
+
+			QueueLen:        snap.Metrics[fmt.Sprintf(state.MetricFlowQueueLenFormat, id)],
+			QueueCap:        snap.Metrics[fmt.Sprintf(state.MetricFlowQueueCapFormat, id)],
+			BlockedEnqueues: snap.Metrics[fmt.Sprintf(state.MetricFlowEnqueueBlockedFormat, id)],
+			BlockedMillis:   snap.Metrics[fmt.Sprintf(state.MetricFlowEnqueueBlockedMsFormat, id)],
 		})
 	}
 	sort.Slice(flows, func(i, j int) bool {
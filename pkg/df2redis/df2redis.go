@@ -0,0 +1,46 @@
+// Package df2redis exposes the migration engine as a Go library so it can be
+// embedded in other services. It never calls os.Exit nor touches the flag
+// package; the df2redis CLI is a thin wrapper around the same entry points.
+package df2redis
+
+import (
+	"context"
+
+	"df2redis/internal/config"
+	"df2redis/internal/replica"
+	"df2redis/internal/state"
+)
+
+// Config is the migration configuration (same schema as the YAML file).
+type Config = config.Config
+
+// ValidationError is returned by Config.Validate.
+type ValidationError = config.ValidationError
+
+// Store persists pipeline status and metrics for the dashboard.
+type Store = state.Store
+
+// RunError wraps replication failures with the phase they happened in.
+type RunError = replica.RunError
+
+// LoadConfig reads a YAML configuration file and applies defaults.
+func LoadConfig(path string) (*Config, error) {
+	return config.Load(path)
+}
+
+// NewStore returns a status store backed by the given JSON file.
+func NewStore(path string) *Store {
+	return state.NewStore(path)
+}
+
+// Replicate runs full sync plus incremental replication until ctx is
+// cancelled (returns ctx.Err()) or replication fails (returns *RunError).
+func Replicate(ctx context.Context, cfg *Config, store *Store) error {
+	return replica.Run(ctx, cfg, store)
+}
+
+// RunMigration performs a snapshot-only migration and returns once the
+// full sync has been written to the target.
+func RunMigration(ctx context.Context, cfg *Config, store *Store) error {
+	return replica.RunMigration(ctx, cfg, store)
+}